@@ -158,6 +158,159 @@ func (x *Sup) isNode()        {}
 func (x *Sup) Pos() token.Pos { return x.HatPos }
 func (x *Sup) End() token.Pos { return x.Node.End() }
 
+// Env is a LaTeX environment.
+// ex:
+//  \begin{itemize} \item a \end{itemize}
+//  \begin{equation} x^n + y^n = z^n \end{equation}
+type Env struct {
+	Left  token.Pos // position of '\begin'
+	Name  *Ident    // name of the environment
+	Args  List      // arguments of the environment
+	List  List
+	Right token.Pos // position of '\end'
+}
+
+func (x *Env) isNode()        {}
+func (x *Env) Pos() token.Pos { return x.Left }
+func (x *Env) End() token.Pos { return x.Right }
+
+// Section is a sectioning command, together with its content.
+// ex:
+//  \section{Introduction}\label{sec:intro} ...
+//  \subsection*{Acknowledgments} ...
+type Section struct {
+	Macro *Macro // sectioning command (\part, \chapter, \section, ...)
+	Level int    // sectioning level, from -1 (\part) to 5 (\subparagraph)
+	Title List   // title of the section
+	Label string // label of the section, if any
+	List  List   // content of the section, including its subsections
+}
+
+func (x *Section) isNode()        {}
+func (x *Section) Pos() token.Pos { return x.Macro.Pos() }
+func (x *Section) End() token.Pos {
+	if len(x.List) > 0 {
+		return x.List.End()
+	}
+	return x.Macro.End()
+}
+
+// Document is a full LaTeX document.
+type Document struct {
+	Class    *DocumentClass // document class, if any
+	Packages []*Package     // packages loaded in the preamble
+	Defs     []*Macro       // definitions of the preamble (\newcommand, \def, ...)
+	Preamble List           // content of the preamble
+	Body     *Env           // document environment, if any
+}
+
+func (x *Document) isNode() {}
+func (x *Document) Pos() token.Pos {
+	if len(x.Preamble) > 0 {
+		return x.Preamble.Pos()
+	}
+	if x.Body != nil {
+		return x.Body.Pos()
+	}
+	return -1
+}
+
+func (x *Document) End() token.Pos {
+	if x.Body != nil {
+		return x.Body.End()
+	}
+	return x.Preamble.End()
+}
+
+// DocumentClass describes the class of a document.
+// ex:
+//  \documentclass[a4paper,12pt]{article}
+type DocumentClass struct {
+	Name    string
+	Options []string
+	Macro   *Macro // \documentclass command
+}
+
+// Package describes a package loaded by a document.
+// ex:
+//  \usepackage[utf8]{inputenc}
+type Package struct {
+	Name    string
+	Options []string
+	Macro   *Macro // \usepackage command
+}
+
+// Table is a tabular-like environment (tabular, tabular*, array).
+// ex:
+//  \begin{tabular}{l|c} \hline a & b \\ \end{tabular}
+type Table struct {
+	Left  token.Pos // position of '\begin'
+	Name  *Ident    // name of the environment
+	Args  List      // arguments of the environment, besides the column specification
+	Spec  []ColSpec // column specification
+	Rows  [][]Cell
+	Lines []HLine // horizontal rules
+	Right token.Pos // position of '\end'
+}
+
+func (x *Table) isNode()        {}
+func (x *Table) Pos() token.Pos { return x.Left }
+func (x *Table) End() token.Pos { return x.Right }
+
+// Columns returns the column specifications that describe actual columns,
+// ie: the specification without rules and inter-column material.
+func (x *Table) Columns() []ColSpec {
+	var cols []ColSpec
+	for _, c := range x.Spec {
+		if c.IsColumn() {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// ColSpec is an item of the column specification of a table.
+// ex:
+//  l, c, r, p{3cm}, |, @{}, >{\bfseries}
+type ColSpec struct {
+	Kind rune   // 'l', 'c', 'r', 'p', 'm', 'b', 'X', '|', '@', '!', '>', '<', ...
+	Arg  string // argument of the item, if any (ex: "3cm" for p{3cm})
+}
+
+// IsColumn returns whether the item describes a column, as opposed to a
+// rule or inter-column material.
+func (c ColSpec) IsColumn() bool {
+	switch c.Kind {
+	case '|', '@', '!', '>', '<':
+		return false
+	}
+	return true
+}
+
+func (c ColSpec) String() string {
+	if c.Arg == "" && c.Kind != '@' && c.Kind != '!' {
+		return string(c.Kind)
+	}
+	return string(c.Kind) + "{" + c.Arg + "}"
+}
+
+// Cell is a cell of a table.
+type Cell struct {
+	List List
+	Span int       // number of columns spanned by the cell
+	Spec []ColSpec // column specification of a \multicolumn cell
+}
+
+// HLine is a horizontal rule of a table.
+// ex:
+//  \hline
+//  \cline{2-3}
+type HLine struct {
+	Row  int // index of the row below the rule
+	From int // first column of a partial rule (1-based), 0 for \hline
+	To   int // last column of a partial rule (1-based), 0 for \hline
+}
+
 // Print prints node to w.
 func Print(o io.Writer, node Node) {
 	switch node := node.(type) {
@@ -213,6 +366,78 @@ func Print(o io.Writer, node Node) {
 			Print(o, n)
 		}
 		fmt.Fprintf(o, "]")
+	case *Env:
+		fmt.Fprintf(o, "ast.Env{%q", node.Name.Name)
+		if len(node.Args) > 0 {
+			fmt.Fprintf(o, ", Args:")
+			for i, n := range node.Args {
+				if i > 0 {
+					fmt.Fprintf(o, ", ")
+				}
+				Print(o, n)
+			}
+		}
+		if len(node.List) > 0 {
+			fmt.Fprintf(o, ", List:")
+			for i, n := range node.List {
+				if i > 0 {
+					fmt.Fprintf(o, ", ")
+				}
+				Print(o, n)
+			}
+		}
+		fmt.Fprintf(o, "}")
+	case *Section:
+		fmt.Fprintf(o, "ast.Section{%q, Level:%d", node.Macro.Name.Name, node.Level)
+		fmt.Fprintf(o, ", Title:")
+		Print(o, node.Title)
+		if node.Label != "" {
+			fmt.Fprintf(o, ", Label:%q", node.Label)
+		}
+		if len(node.List) > 0 {
+			fmt.Fprintf(o, ", List:")
+			for i, n := range node.List {
+				if i > 0 {
+					fmt.Fprintf(o, ", ")
+				}
+				Print(o, n)
+			}
+		}
+		fmt.Fprintf(o, "}")
+	case *Document:
+		fmt.Fprintf(o, "ast.Document{Preamble:")
+		Print(o, node.Preamble)
+		fmt.Fprintf(o, ", Body:")
+		switch node.Body {
+		case nil:
+			fmt.Fprintf(o, "<nil>")
+		default:
+			Print(o, node.Body)
+		}
+		fmt.Fprintf(o, "}")
+	case *Table:
+		fmt.Fprintf(o, "ast.Table{%q, Spec:\"", node.Name.Name)
+		for _, c := range node.Spec {
+			fmt.Fprintf(o, "%v", c)
+		}
+		fmt.Fprintf(o, "\", Rows:[")
+		for i, row := range node.Rows {
+			if i > 0 {
+				fmt.Fprintf(o, ", ")
+			}
+			fmt.Fprintf(o, "[")
+			for j, cell := range row {
+				if j > 0 {
+					fmt.Fprintf(o, ", ")
+				}
+				if cell.Span > 1 {
+					fmt.Fprintf(o, "%d:", cell.Span)
+				}
+				Print(o, cell.List)
+			}
+			fmt.Fprintf(o, "]")
+		}
+		fmt.Fprintf(o, "]}")
 	case *Word:
 		fmt.Fprintf(o, "ast.Word{%q}", node.Text)
 	case *Literal:
@@ -263,4 +488,8 @@ var (
 	_ Node = (*Sup)(nil)
 	_ Node = (*Sub)(nil)
 	_ Node = (*Symbol)(nil)
+	_ Node = (*Env)(nil)
+	_ Node = (*Section)(nil)
+	_ Node = (*Document)(nil)
+	_ Node = (*Table)(nil)
 )
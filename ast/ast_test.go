@@ -96,6 +96,50 @@ func TestPrint(t *testing.T) {
 			node: &Ident{Name: `\cos`},
 			want: `ast.Ident{"\\cos"}`,
 		},
+		{
+			node: &Env{
+				Left: 3,
+				Name: &Ident{Name: "equation"},
+				List: List{&Word{Text: "x"}},
+			},
+			pos:  3,
+			want: `ast.Env{"equation", List:ast.Word{"x"}}`,
+		},
+		{
+			node: &Section{
+				Macro: &Macro{
+					Name: &Ident{NamePos: 5, Name: `\section`},
+					Args: List{&Arg{List: List{&Word{Text: "Intro"}}}},
+				},
+				Level: 1,
+				Title: List{&Word{Text: "Intro"}},
+				Label: "sec:intro",
+				List:  List{&Word{Text: "hello"}},
+			},
+			pos:  5,
+			want: `ast.Section{"\\section", Level:1, Title:ast.List{ast.Word{"Intro"}}, Label:"sec:intro", List:ast.Word{"hello"}}`,
+		},
+		{
+			node: &Table{
+				Left: 2,
+				Name: &Ident{Name: "tabular"},
+				Spec: []ColSpec{{Kind: 'l'}, {Kind: '|'}, {Kind: 'p', Arg: "3cm"}, {Kind: '@'}},
+				Rows: [][]Cell{
+					{{List: List{&Word{Text: "a"}}, Span: 1}, {List: List{&Word{Text: "b"}}, Span: 1}},
+					{{List: List{&Word{Text: "c"}}, Span: 2}},
+				},
+			},
+			pos:  2,
+			want: `ast.Table{"tabular", Spec:"l|p{3cm}@{}", Rows:[[ast.List{ast.Word{"a"}}, ast.List{ast.Word{"b"}}], [2:ast.List{ast.Word{"c"}}]]}`,
+		},
+		{
+			node: &Document{
+				Preamble: List{&Macro{Name: &Ident{NamePos: 1, Name: `\title`}}},
+				Body:     &Env{Left: 10, Name: &Ident{Name: "document"}},
+			},
+			pos:  1,
+			want: `ast.Document{Preamble:ast.List{ast.Macro{"\\title"}}, Body:ast.Env{"document"}}`,
+		},
 	} {
 		t.Run("", func(t *testing.T) {
 			o := new(strings.Builder)
@@ -56,6 +56,35 @@ func Walk(v Visitor, node Node) {
 	case *Sup:
 		Walk(v, n.Node)
 
+	case *Env:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkNodes(v, n.Args)
+		walkNodes(v, n.List)
+
+	case *Table:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkNodes(v, n.Args)
+		for _, row := range n.Rows {
+			for _, cell := range row {
+				walkNodes(v, cell.List)
+			}
+		}
+
+	case *Section:
+		// the title is part of the arguments of the sectioning command.
+		Walk(v, n.Macro)
+		walkNodes(v, n.List)
+
+	case *Document:
+		walkNodes(v, n.Preamble)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
 	default:
 		panic(fmt.Errorf("unknown ast node %#v (type=%T)", n, n))
 	}
@@ -65,6 +65,21 @@ func TestWalk(t *testing.T) {
 			},
 			want: "*ast.Sup *ast.Literal <nil> <nil>",
 		},
+		{
+			node: &Document{
+				Preamble: List{&Word{Text: "x"}},
+				Body: &Env{
+					Name: &Ident{Name: "document"},
+					List: List{
+						&Section{
+							Macro: &Macro{Name: &Ident{Name: `\section`}},
+							List:  List{&Word{Text: "y"}},
+						},
+					},
+				},
+			},
+			want: "*ast.Document *ast.Word <nil> *ast.Env *ast.Ident <nil> *ast.Section *ast.Macro *ast.Ident <nil> <nil> *ast.Word <nil> <nil> <nil> <nil>",
+		},
 	} {
 		t.Run("", func(t *testing.T) {
 			o := new(strings.Builder)
@@ -0,0 +1,790 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package canon normalizes LaTeX math syntax trees into a canonical form,
+// so that semantically equivalent expressions can be compared.
+//
+// Normalization:
+//   - drops spacing macros (\, \; \quad ...) and spaces,
+//   - unwraps math expressions ($...$, \(...\)) and flattens brace groups,
+//   - unifies \dfrac and \tfrac into \frac,
+//   - rewrites a/b into \frac{a}{b} and folds products of fractions into a
+//     single fraction (\frac{1}{2}x is \frac{x}{2}),
+//   - drops explicit multiplication operators (\cdot, \times, *),
+//   - sorts the operands of sums and products.
+package canon // import "github.com/go-latex/latex/canon"
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/mtex/symbols"
+)
+
+// Equal reports whether a and b are structurally equal, once normalized.
+func Equal(a, b ast.Node) bool {
+	return equal(Normalize(a), Normalize(b))
+}
+
+// Normalize returns the canonical form of the provided math expression.
+// The input node is not modified.
+// The positions of the returned nodes are not meaningful.
+func Normalize(node ast.Node) ast.Node {
+	n := normalizer{sort: true}
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case ast.List:
+		return n.list(node)
+	case *ast.MathExpr:
+		return n.list(node.List)
+	default:
+		return n.list(ast.List{node})
+	}
+}
+
+type normalizer struct {
+	sort bool // whether operands of sums and products may be reordered.
+}
+
+// list normalizes a list of nodes.
+func (n normalizer) list(list ast.List) ast.List {
+	var atoms ast.List
+	for _, node := range list {
+		atoms = n.expand(atoms, node)
+	}
+
+	// relations and punctuation split the list into independent segments.
+	var (
+		out ast.List
+		beg = 0
+	)
+	for i, node := range atoms {
+		if !isBarrier(node) {
+			continue
+		}
+		out = append(out, n.sum(atoms[beg:i])...)
+		out = append(out, node)
+		beg = i + 1
+	}
+	out = append(out, n.sum(atoms[beg:])...)
+	if out == nil {
+		out = ast.List{}
+	}
+	return out
+}
+
+// expand appends the normalized form of node to atoms.
+func (n normalizer) expand(atoms ast.List, node ast.Node) ast.List {
+	switch node := node.(type) {
+	case nil:
+		return atoms
+	case ast.List:
+		for _, x := range node {
+			atoms = n.expand(atoms, x)
+		}
+		return atoms
+	case *ast.MathExpr:
+		for _, x := range node.List {
+			atoms = n.expand(atoms, x)
+		}
+		return atoms
+	case *ast.Env:
+		switch node.Name.Name {
+		case "equation", "equation*", "displaymath", "math":
+			for _, x := range node.List {
+				atoms = n.expand(atoms, x)
+			}
+			return atoms
+		}
+		// other environments are kept as opaque atoms.
+		return append(atoms, clone(node))
+	case *ast.Table:
+		return append(atoms, clone(node))
+	case *ast.Symbol:
+		switch node.Text {
+		case " ", `\ `, "~":
+			return atoms
+		}
+		return append(atoms, &ast.Symbol{Text: node.Text})
+	case *ast.Word:
+		// in math mode, each letter is a variable of its own.
+		for _, c := range node.Text {
+			atoms = append(atoms, &ast.Word{Text: string(c)})
+		}
+		return atoms
+	case *ast.Literal:
+		return append(atoms, &ast.Literal{Text: node.Text})
+	case *ast.Macro:
+		if isSpacing(node.Name.Name) {
+			return atoms
+		}
+		return append(atoms, n.macro(node))
+	case *ast.Sup:
+		return append(atoms, &ast.Sup{Node: n.script(node.Node)})
+	case *ast.Sub:
+		// subscripts are (mostly) indices: do not reorder them.
+		return append(atoms, &ast.Sub{Node: normalizer{}.script(node.Node)})
+	default:
+		// documents, sections and other nodes are kept as opaque atoms.
+		return append(atoms, clone(node))
+	}
+}
+
+func (n normalizer) macro(node *ast.Macro) ast.Node {
+	name := node.Name.Name
+	switch name {
+	case `\dfrac`, `\tfrac`:
+		name = `\frac`
+	}
+	o := &ast.Macro{
+		Name: &ast.Ident{Name: name},
+		Args: make(ast.List, 0, len(node.Args)),
+	}
+	for _, arg := range node.Args {
+		switch arg := arg.(type) {
+		case *ast.Arg:
+			o.Args = append(o.Args, &ast.Arg{List: n.arg(name, arg.List)})
+		case *ast.OptArg:
+			o.Args = append(o.Args, &ast.OptArg{List: n.arg(name, arg.List)})
+		default:
+			o.Args = append(o.Args, clone(arg))
+		}
+	}
+	return o
+}
+
+func (n normalizer) arg(macro string, list ast.List) ast.List {
+	if isText(macro) {
+		// text arguments are kept verbatim.
+		o := make(ast.List, len(list))
+		for i, node := range list {
+			o[i] = clone(node)
+		}
+		return o
+	}
+	return n.list(list)
+}
+
+// script normalizes the content of a sub- or superscript.
+// x^2 and x^{2} have the same canonical form.
+func (n normalizer) script(node ast.Node) ast.Node {
+	list := n.list(ast.List{node})
+	if len(list) == 1 {
+		return list[0]
+	}
+	return list
+}
+
+// factor is an atom followed by its sub- and superscripts.
+type factor ast.List
+
+func (f factor) key() string {
+	o := new(strings.Builder)
+	for _, node := range f {
+		ast.Print(o, node)
+	}
+	return o.String()
+}
+
+// rank orders factors by kind: numbers, variables, macros and then groups.
+func (f factor) rank() int {
+	switch f[0].(type) {
+	case *ast.Literal:
+		return 0
+	case *ast.Word:
+		return 1
+	case *ast.Macro:
+		return 2
+	default:
+		return 3
+	}
+}
+
+type term struct {
+	sign    string // "+" or "-"
+	factors []factor
+	sorted  bool // whether factors may be reordered.
+}
+
+func (t term) key() string {
+	o := new(strings.Builder)
+	for _, f := range t.factors {
+		o.WriteString(f.key())
+	}
+	return o.String()
+}
+
+// sum normalizes a segment of atoms, without relations nor punctuation.
+func (n normalizer) sum(atoms ast.List) ast.List {
+	if len(atoms) == 0 {
+		return nil
+	}
+
+	var (
+		terms []term
+		cur   = term{sign: "+", sorted: n.sort}
+		open  = false
+	)
+	for _, f := range n.factors(atoms) {
+		if sym, ok := f[0].(*ast.Symbol); ok && len(f) == 1 {
+			switch sym.Text {
+			case "+", "-":
+				if open || len(cur.factors) > 0 {
+					terms = append(terms, cur)
+				}
+				cur = term{sign: sym.Text, sorted: n.sort}
+				open = true
+				continue
+			}
+		}
+		cur.factors = append(cur.factors, f)
+	}
+	terms = append(terms, cur)
+
+	for i := range terms {
+		terms[i] = n.product(terms[i])
+	}
+
+	if n.sort {
+		sort.SliceStable(terms, func(i, j int) bool {
+			return terms[i].key() < terms[j].key()
+		})
+	}
+
+	var out ast.List
+	for i, t := range terms {
+		if i > 0 || t.sign == "-" {
+			out = append(out, &ast.Symbol{Text: t.sign})
+		}
+		for _, f := range t.factors {
+			out = append(out, f...)
+		}
+	}
+	return out
+}
+
+// factors groups atoms into factors: parenthesized groups and atoms,
+// together with their scripts.
+// An operator (\sin, \sum, ...) and everything that follows it is a single
+// factor.
+func (n normalizer) factors(atoms ast.List) []factor {
+	var (
+		fs  []factor
+		cur factor
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			// x_i^2 and x^2_i are the same.
+			beg := len(cur)
+			for beg > 0 && isScript(cur[beg-1]) {
+				beg--
+			}
+			scripts := cur[beg:]
+			sort.SliceStable(scripts, func(i, j int) bool {
+				_, si := scripts[i].(*ast.Sub)
+				_, sj := scripts[j].(*ast.Sup)
+				return si && sj
+			})
+			fs = append(fs, cur)
+		}
+		cur = nil
+	}
+
+	for i := 0; i < len(atoms); i++ {
+		node := atoms[i]
+		switch node := node.(type) {
+		case *ast.Sub, *ast.Sup:
+			cur = append(cur, node)
+			continue
+		case *ast.Symbol:
+			switch node.Text {
+			case "'", "!":
+				cur = append(cur, node)
+				continue
+			case "+", "-":
+				flush()
+				cur = factor{node}
+				flush()
+				continue
+			case "(", "[":
+				j := closing(atoms, i)
+				if j < 0 {
+					break
+				}
+				group := factor{node}
+				group = append(group, n.sum(atoms[i+1:j])...)
+				group = append(group, atoms[j])
+				if w, ok := lastAtom(cur).(*ast.Word); ok && len(cur) == 1 {
+					// function application: f(x)
+					cur = append(factor{w}, group...)
+				} else {
+					flush()
+					cur = group
+				}
+				i = j
+				continue
+			}
+		case *ast.Macro:
+			if isOperator(node.Name.Name) {
+				// the operand extends up to the end of the term.
+				flush()
+				j := i + 1
+				for j < len(atoms) && isScript(atoms[j]) {
+					j++
+				}
+				cur = append(factor{node}, atoms[i+1:j]...)
+				k := endOfTerm(atoms, j)
+				cur = append(cur, n.sum(atoms[j:k])...)
+				flush()
+				i = k - 1
+				continue
+			}
+		}
+		flush()
+		cur = factor{node}
+	}
+	flush()
+	return fs
+}
+
+// product normalizes the factors of a term.
+func (n normalizer) product(t term) term {
+	var fs []factor
+	for i := 0; i < len(t.factors); i++ {
+		f := t.factors[i]
+		if len(f) == 1 {
+			switch op := f[0].(type) {
+			case *ast.Symbol:
+				switch op.Text {
+				case "*":
+					continue
+				case "/":
+					if len(fs) > 0 && i+1 < len(t.factors) {
+						num := fs[len(fs)-1]
+						den := t.factors[i+1]
+						fs[len(fs)-1] = factor{frac(ast.List(num), ast.List(den))}
+						i++
+						continue
+					}
+				}
+			case *ast.Macro:
+				switch op.Name.Name {
+				case `\cdot`, `\times`:
+					continue
+				}
+				if symbols.BinaryOperators.Has(op.Name.Name) {
+					t.sorted = false
+				}
+			}
+		}
+		fs = append(fs, f)
+	}
+	t.factors = fs
+	if !t.sorted {
+		return t
+	}
+
+	// fold fractions: a\frac{b}{c} is \frac{ab}{c}.
+	var (
+		num   []factor
+		den   []factor
+		nfrac = 0
+	)
+	for _, f := range t.factors {
+		m, ok := f[0].(*ast.Macro)
+		if !ok || len(f) != 1 || m.Name.Name != `\frac` || len(m.Args) != 2 {
+			num = append(num, f)
+			continue
+		}
+		nfrac++
+		num = append(num, n.operands(m.Args[0].(*ast.Arg).List)...)
+		den = append(den, n.operands(m.Args[1].(*ast.Arg).List)...)
+	}
+	if nfrac == 0 {
+		sortFactors(num)
+		t.factors = num
+		return t
+	}
+
+	num = dropOnes(num)
+	den = dropOnes(den)
+	sortFactors(num)
+	sortFactors(den)
+
+	t.factors = []factor{{frac(flatten(num), flatten(den))}}
+	return t
+}
+
+// operands returns the factors of a normalized list.
+// A list that is not a simple product is returned as a single,
+// parenthesized, factor.
+func (n normalizer) operands(list ast.List) []factor {
+	fs := n.factors(list)
+	for _, f := range fs {
+		for _, node := range f {
+			if isBarrier(node) {
+				return []factor{paren(list)}
+			}
+		}
+		if sym, ok := f[0].(*ast.Symbol); ok && len(f) == 1 {
+			switch sym.Text {
+			case "+", "-", "/":
+				return []factor{paren(list)}
+			}
+		}
+		if m, ok := f[0].(*ast.Macro); ok && len(f) == 1 && symbols.BinaryOperators.Has(m.Name.Name) {
+			return []factor{paren(list)}
+		}
+	}
+	return fs
+}
+
+func sortFactors(fs []factor) {
+	sort.SliceStable(fs, func(i, j int) bool {
+		ri, rj := fs[i].rank(), fs[j].rank()
+		if ri != rj {
+			return ri < rj
+		}
+		return fs[i].key() < fs[j].key()
+	})
+}
+
+func dropOnes(fs []factor) []factor {
+	o := fs[:0:0]
+	for _, f := range fs {
+		if lit, ok := f[0].(*ast.Literal); ok && len(f) == 1 && lit.Text == "1" {
+			continue
+		}
+		o = append(o, f)
+	}
+	if len(o) == 0 {
+		o = append(o, factor{&ast.Literal{Text: "1"}})
+	}
+	return o
+}
+
+func flatten(fs []factor) ast.List {
+	var o ast.List
+	for _, f := range fs {
+		o = append(o, f...)
+	}
+	return o
+}
+
+func frac(num, den ast.List) *ast.Macro {
+	return &ast.Macro{
+		Name: &ast.Ident{Name: `\frac`},
+		Args: ast.List{
+			&ast.Arg{List: unparen(num)},
+			&ast.Arg{List: unparen(den)},
+		},
+	}
+}
+
+func paren(list ast.List) factor {
+	f := factor{&ast.Symbol{Text: "("}}
+	f = append(f, list...)
+	f = append(f, &ast.Symbol{Text: ")"})
+	return f
+}
+
+func isParen(f factor) bool {
+	if len(f) < 2 {
+		return false
+	}
+	beg, ok1 := f[0].(*ast.Symbol)
+	end, ok2 := f[len(f)-1].(*ast.Symbol)
+	return ok1 && ok2 && beg.Text == "(" && end.Text == ")"
+}
+
+// unparen removes the outer parentheses of a list: they are implied by the
+// arguments of \frac.
+func unparen(list ast.List) ast.List {
+	if !isParen(factor(list)) || closing(list, 0) != len(list)-1 {
+		return list
+	}
+	return list[1 : len(list)-1]
+}
+
+// closing returns the index of the delimiter closing the one at atoms[i],
+// or -1.
+func closing(atoms ast.List, i int) int {
+	var (
+		open  = atoms[i].(*ast.Symbol).Text
+		close = map[string]string{"(": ")", "[": "]"}[open]
+		depth = 0
+	)
+	for j := i; j < len(atoms); j++ {
+		sym, ok := atoms[j].(*ast.Symbol)
+		if !ok {
+			continue
+		}
+		switch sym.Text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// endOfTerm returns the index of the first top-level + or - sign from atoms[i],
+// or len(atoms).
+func endOfTerm(atoms ast.List, i int) int {
+	depth := 0
+	for ; i < len(atoms); i++ {
+		sym, ok := atoms[i].(*ast.Symbol)
+		if !ok {
+			continue
+		}
+		switch sym.Text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case "+", "-":
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+func lastAtom(f factor) ast.Node {
+	if len(f) == 0 {
+		return nil
+	}
+	return f[len(f)-1]
+}
+
+func isScript(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Sub, *ast.Sup:
+		return true
+	}
+	return false
+}
+
+// isBarrier returns whether node splits a list into independent segments.
+func isBarrier(node ast.Node) bool {
+	var name string
+	switch node := node.(type) {
+	case *ast.Symbol:
+		name = node.Text
+		switch name {
+		case ".", "!":
+			return false
+		}
+	case *ast.Macro:
+		name = node.Name.Name
+		switch name {
+		case `\le`, `\ge`, `\ne`, `\to`, `\gets`:
+			return true
+		}
+	default:
+		return false
+	}
+	return symbols.RelationSymbols.Has(name) ||
+		symbols.ArrowSymbols.Has(name) ||
+		symbols.PunctuationSymbols.Has(name)
+}
+
+// isOperator returns whether the named macro applies to what follows it.
+func isOperator(name string) bool {
+	return symbols.FunctionNames.Has(strings.TrimPrefix(name, `\`)) ||
+		symbols.OverUnderSymbols.Has(name) ||
+		symbols.DropSubSymbols.Has(name) ||
+		name == `\operatorname`
+}
+
+func isSpacing(name string) bool {
+	switch name {
+	case `\,`, `\:`, `\;`, `\!`, `\>`, `\ `,
+		`\quad`, `\qquad`, `\enspace`, `\thinspace`,
+		`\hspace`:
+		return true
+	}
+	return false
+}
+
+func isText(name string) bool {
+	switch name {
+	case `\operatorname`, `\mbox`:
+		return true
+	}
+	return strings.HasPrefix(name, `\text`)
+}
+
+func clone(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case ast.List:
+		o := make(ast.List, len(node))
+		for i, x := range node {
+			o[i] = clone(x)
+		}
+		return o
+	case *ast.Word:
+		return &ast.Word{Text: node.Text}
+	case *ast.Literal:
+		return &ast.Literal{Text: node.Text}
+	case *ast.Symbol:
+		return &ast.Symbol{Text: node.Text}
+	case *ast.Ident:
+		return &ast.Ident{Name: node.Name}
+	case *ast.Macro:
+		return &ast.Macro{
+			Name: &ast.Ident{Name: node.Name.Name},
+			Args: clone(node.Args).(ast.List),
+		}
+	case *ast.Arg:
+		return &ast.Arg{List: clone(node.List).(ast.List)}
+	case *ast.OptArg:
+		return &ast.OptArg{List: clone(node.List).(ast.List)}
+	case *ast.MathExpr:
+		return &ast.MathExpr{Delim: node.Delim, List: clone(node.List).(ast.List)}
+	case *ast.Sub:
+		return &ast.Sub{Node: clone(node.Node)}
+	case *ast.Sup:
+		return &ast.Sup{Node: clone(node.Node)}
+	case *ast.Env:
+		return &ast.Env{
+			Name: &ast.Ident{Name: node.Name.Name},
+			Args: clone(node.Args).(ast.List),
+			List: clone(node.List).(ast.List),
+		}
+	case *ast.Table:
+		o := &ast.Table{
+			Name:  &ast.Ident{Name: node.Name.Name},
+			Args:  clone(node.Args).(ast.List),
+			Spec:  append([]ast.ColSpec(nil), node.Spec...),
+			Rows:  make([][]ast.Cell, len(node.Rows)),
+			Lines: append([]ast.HLine(nil), node.Lines...),
+		}
+		for i, row := range node.Rows {
+			o.Rows[i] = make([]ast.Cell, len(row))
+			for j, cell := range row {
+				o.Rows[i][j] = ast.Cell{
+					List: clone(cell.List).(ast.List),
+					Span: cell.Span,
+					Spec: append([]ast.ColSpec(nil), cell.Spec...),
+				}
+			}
+		}
+		return o
+	default:
+		// other nodes are never modified: share them with the input.
+		return node
+	}
+}
+
+// equal reports whether a and b are structurally equal.
+// Positions are ignored.
+func equal(a, b ast.Node) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case ast.List:
+		b, ok := b.(ast.List)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case *ast.Word:
+		b, ok := b.(*ast.Word)
+		return ok && a.Text == b.Text
+	case *ast.Literal:
+		b, ok := b.(*ast.Literal)
+		return ok && a.Text == b.Text
+	case *ast.Symbol:
+		b, ok := b.(*ast.Symbol)
+		return ok && a.Text == b.Text
+	case *ast.Ident:
+		b, ok := b.(*ast.Ident)
+		return ok && a.Name == b.Name
+	case *ast.Macro:
+		b, ok := b.(*ast.Macro)
+		return ok && equal(a.Name, b.Name) && equal(a.Args, b.Args)
+	case *ast.Arg:
+		b, ok := b.(*ast.Arg)
+		return ok && equal(a.List, b.List)
+	case *ast.OptArg:
+		b, ok := b.(*ast.OptArg)
+		return ok && equal(a.List, b.List)
+	case *ast.MathExpr:
+		b, ok := b.(*ast.MathExpr)
+		return ok && equal(a.List, b.List)
+	case *ast.Sub:
+		b, ok := b.(*ast.Sub)
+		return ok && equal(a.Node, b.Node)
+	case *ast.Sup:
+		b, ok := b.(*ast.Sup)
+		return ok && equal(a.Node, b.Node)
+	case *ast.Env:
+		b, ok := b.(*ast.Env)
+		return ok && equal(a.Name, b.Name) && equal(a.Args, b.Args) && equal(a.List, b.List)
+	case *ast.Table:
+		b, ok := b.(*ast.Table)
+		if !ok || !equal(a.Name, b.Name) || !equal(a.Args, b.Args) ||
+			!reflect.DeepEqual(a.Spec, b.Spec) || len(a.Rows) != len(b.Rows) {
+			return false
+		}
+		for i := range a.Rows {
+			if len(a.Rows[i]) != len(b.Rows[i]) {
+				return false
+			}
+			for j, ca := range a.Rows[i] {
+				cb := b.Rows[i][j]
+				if ca.Span != cb.Span || !reflect.DeepEqual(ca.Spec, cb.Spec) || !equal(ca.List, cb.List) {
+					return false
+				}
+			}
+		}
+		return true
+	case *ast.Section:
+		b, ok := b.(*ast.Section)
+		return ok && a.Level == b.Level && a.Label == b.Label &&
+			equal(a.Macro, b.Macro) && equal(a.Title, b.Title) && equal(a.List, b.List)
+	case *ast.Document:
+		b, ok := b.(*ast.Document)
+		if !ok || (a.Class == nil) != (b.Class == nil) || len(a.Packages) != len(b.Packages) ||
+			len(a.Defs) != len(b.Defs) || !equal(a.Preamble, b.Preamble) {
+			return false
+		}
+		if a.Class != nil && (a.Class.Name != b.Class.Name || !reflect.DeepEqual(a.Class.Options, b.Class.Options)) {
+			return false
+		}
+		for i, pa := range a.Packages {
+			pb := b.Packages[i]
+			if pa.Name != pb.Name || !reflect.DeepEqual(pa.Options, pb.Options) {
+				return false
+			}
+		}
+		for i := range a.Defs {
+			if !equal(a.Defs[i], b.Defs[i]) {
+				return false
+			}
+		}
+		if a.Body == nil || b.Body == nil {
+			return a.Body == nil && b.Body == nil
+		}
+		return equal(a.Body, b.Body)
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
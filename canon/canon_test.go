@@ -0,0 +1,177 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package canon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex"
+	"github.com/go-latex/latex/ast"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `$b+a$`,
+			want:  `ast.List{ast.Word{"a"}, ast.Symbol{"+"}, ast.Word{"b"}}`,
+		},
+		{
+			input: `$-b+a$`,
+			want:  `ast.List{ast.Word{"a"}, ast.Symbol{"-"}, ast.Word{"b"}}`,
+		},
+		{
+			input: `$yx\,+\;2$`,
+			want:  `ast.List{ast.Lit{"2"}, ast.Symbol{"+"}, ast.Word{"x"}, ast.Word{"y"}}`,
+		},
+		{
+			input: `$x/2$`,
+			want:  `ast.List{ast.Macro{"\\frac", Args:{ast.Word{"x"}}, {ast.Lit{"2"}}}}`,
+		},
+		{
+			input: `$\dfrac{1}{2}x$`,
+			want:  `ast.List{ast.Macro{"\\frac", Args:{ast.Word{"x"}}, {ast.Lit{"2"}}}}`,
+		},
+		{
+			input: `$\frac{1}{2}$`,
+			want:  `ast.List{ast.Macro{"\\frac", Args:{ast.Lit{"1"}}, {ast.Lit{"2"}}}}`,
+		},
+		{
+			input: `$x^{2}_i$`,
+			want:  `ast.List{ast.Word{"x"}, ast.Sub{ast.Word{"i"}}, ast.Sup{ast.Lit{"2"}}}`,
+		},
+		{
+			input: `$\sin x + 1 = y$`,
+			want:  `ast.List{ast.Lit{"1"}, ast.Symbol{"+"}, ast.Macro{"\\sin"}, ast.Word{"x"}, ast.Symbol{"="}, ast.Word{"y"}}`,
+		},
+		{
+			input: `$(b+a)c$`,
+			want:  `ast.List{ast.Word{"c"}, ast.Symbol{"("}, ast.Word{"a"}, ast.Symbol{"+"}, ast.Word{"b"}, ast.Symbol{")"}}`,
+		},
+		{
+			input: `$f(x)$`,
+			want:  `ast.List{ast.Word{"f"}, ast.Symbol{"("}, ast.Word{"x"}, ast.Symbol{")"}}`,
+		},
+		{
+			input: `$\operatorname{ab}$`,
+			want:  `ast.List{ast.Macro{"\\operatorname", Args:{ast.Word{"ab"}}}}`,
+		},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			node, err := latex.ParseExpr(tc.input)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.input, err)
+			}
+
+			norm := Normalize(node)
+			o := new(strings.Builder)
+			ast.Print(o, norm)
+			if got, want := o.String(), tc.want; got != want {
+				t.Fatalf("invalid normalized form:\ngot= %s\nwant=%s", got, want)
+			}
+
+			o.Reset()
+			ast.Print(o, Normalize(norm))
+			if got, want := o.String(), tc.want; got != want {
+				t.Fatalf("normalization is not idempotent:\ngot= %s\nwant=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{`$a+b$`, `$b+a$`, true},
+		{`$a+b$`, `a+b`, true},
+		{`$a-b$`, `$-b+a$`, true},
+		{`$a-b$`, `$b-a$`, false},
+		{`$\frac{1}{2}x$`, `$x/2$`, true},
+		{`$\tfrac{1}{2}x$`, `$\frac{x}{2}$`, true},
+		{`$\frac{a}{b}c$`, `$\frac{ca}{b}$`, true},
+		{`$2\cdot x$`, `$x 2$`, true},
+		{`$x \times y$`, `$y*x$`, true},
+		{`$a\,b$`, `$ab$`, true},
+		{`$\sqrt{b+a}$`, `$\sqrt{a+b}$`, true},
+		{`$x^{b+a}$`, `$x^{a+b}$`, true},
+		{`$x^2$`, `$x^{2}$`, true},
+		{`$x^{2}_i$`, `$x_i^2$`, true},
+		{`$(x^2+y_i)^3$`, `$(y_i+x^2)^3$`, true},
+		{`$x_{ij}$`, `$x_{ji}$`, false},
+		{`$a=b$`, `$b=a$`, false},
+		{`$a+b=c$`, `$b+a=c$`, true},
+		{`$f(x+y)$`, `$f(y+x)$`, true},
+		{`$\sin x y$`, `$y \sin x$`, false},
+		{`$(a+b)/2$`, `$\frac{b+a}{2}$`, true},
+		{`$a \div b$`, `$b \div a$`, false},
+		{`\begin{equation}b+a\end{equation}`, `$a+b$`, true},
+		{`$\begin{array}{cc}1 & x\end{array}$`, `$\begin{array}{cc}1&x\end{array}$`, true},
+		{`$\begin{array}{cc}1 & x\end{array}$`, `$\begin{array}{cc}x & 1\end{array}$`, false},
+	} {
+		t.Run(tc.a+" vs "+tc.b, func(t *testing.T) {
+			a, err := latex.ParseExpr(tc.a)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.a, err)
+			}
+			b, err := latex.ParseExpr(tc.b)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.b, err)
+			}
+			if got, want := Equal(a, b), tc.want; got != want {
+				t.Fatalf("invalid equality: got=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestDocument(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{
+			`\documentclass{article}\begin{document}\section{A}$a+b$\end{document}`,
+			`\documentclass{article}` + "\n" + `\begin{document}\section{A}$a + b$\end{document}`,
+			true,
+		},
+		{
+			`\documentclass{article}\begin{document}\section{A}$a+b$\end{document}`,
+			`\documentclass{article}\begin{document}\section{B}$a+b$\end{document}`,
+			false,
+		},
+		{
+			`\documentclass{article}\usepackage{amsmath}\begin{document}x\end{document}`,
+			`\documentclass{article}\begin{document}x\end{document}`,
+			false,
+		},
+		{
+			`\documentclass[a4paper]{article}\begin{document}x\end{document}`,
+			`\documentclass{article}\begin{document}x\end{document}`,
+			false,
+		},
+	} {
+		t.Run(tc.a+" vs "+tc.b, func(t *testing.T) {
+			a, err := latex.ParseDocument(tc.a)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.a, err)
+			}
+			b, err := latex.ParseDocument(tc.b)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.b, err)
+			}
+			if got, want := Equal(a, b), tc.want; got != want {
+				t.Fatalf("invalid equality: got=%v, want=%v", got, want)
+			}
+			if got := Normalize(a); !Equal(got, a) {
+				t.Fatalf("invalid normalization: got=%v", got)
+			}
+		})
+	}
+}
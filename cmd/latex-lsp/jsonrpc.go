@@ -0,0 +1,147 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// request is a JSON-RPC request or notification.
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// isNotification returns whether the request expects no response.
+func (req *request) isNotification() bool {
+	return req.ID == nil
+}
+
+// response is a JSON-RPC response.
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+// notification is a JSON-RPC notification sent by the server.
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("jsonrpc: %s (code=%d)", e.Message, e.Code)
+}
+
+// conn reads and writes JSON-RPC messages framed with LSP base protocol
+// headers.
+type conn struct {
+	r *textproto.Reader
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		r: textproto.NewReader(bufio.NewReader(r)),
+		w: w,
+	}
+}
+
+// read reads the next message.
+func (c *conn) read() (*request, error) {
+	hdr, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("could not read message header: %w", err)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(hdr.Get("Content-Length")))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", hdr.Get("Content-Length"))
+	}
+
+	buf := make([]byte, n)
+	_, err = io.ReadFull(c.r.R, buf)
+	if err != nil {
+		return nil, fmt.Errorf("could not read message content: %w", err)
+	}
+
+	var req request
+	err = json.Unmarshal(buf, &req)
+	if err != nil {
+		return nil, &rpcError{Code: codeParseError, Message: err.Error()}
+	}
+	return &req, nil
+}
+
+// write writes a message.
+func (c *conn) write(msg interface{}) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not encode message: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(raw), raw)
+	if err != nil {
+		return fmt.Errorf("could not write message: %w", err)
+	}
+	return nil
+}
+
+// reply sends the response to a request.
+func (c *conn) reply(id *json.RawMessage, result interface{}, err error) error {
+	res := response{JSONRPC: "2.0", ID: id}
+	switch e := err.(type) {
+	case nil:
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("could not encode result: %w", err)
+		}
+		res.Result = (*json.RawMessage)(&raw)
+	case *rpcError:
+		res.Error = e
+	default:
+		res.Error = &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+	return c.write(res)
+}
+
+// notify sends a notification.
+func (c *conn) notify(method string, params interface{}) error {
+	return c.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
@@ -0,0 +1,33 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command latex-lsp is a language server for LaTeX documents.
+//
+// latex-lsp communicates with its client over stdin and stdout, using the
+// Language Server Protocol. It provides:
+//   - diagnostics for parse errors, unknown macros in math expressions and
+//     undefined, duplicate or unused labels,
+//   - completion of macro names and math symbols,
+//   - hover previews of math expressions, rendered with mtex,
+//   - go-to-definition from references to their labels.
+//
+// Usage:
+//
+//	$> latex-lsp
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetPrefix("latex-lsp: ")
+	log.SetFlags(0)
+
+	err := newServer(os.Stdin, os.Stdout).run()
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
@@ -0,0 +1,556 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-latex/latex"
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/drawtex/drawimg"
+	"github.com/go-latex/latex/font/lm"
+	"github.com/go-latex/latex/internal/tex2unicode"
+	"github.com/go-latex/latex/mtex"
+	"github.com/go-latex/latex/xref"
+)
+
+// server is a LaTeX language server.
+type server struct {
+	conn *conn
+	docs map[string]*document
+
+	shutdown bool
+}
+
+func newServer(r io.Reader, w io.Writer) *server {
+	return &server{
+		conn: newConn(r, w),
+		docs: make(map[string]*document),
+	}
+}
+
+// run serves requests until the client exits or closes the connection.
+func (srv *server) run() error {
+	for {
+		req, err := srv.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			var rerr *rpcError
+			if errors.As(err, &rerr) {
+				err = srv.conn.reply(nil, nil, rerr)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		if req.Method == "exit" {
+			if !srv.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+
+		res, err := srv.handle(req)
+		if req.isNotification() {
+			continue
+		}
+		err = srv.conn.reply(req.ID, res, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (srv *server) handle(req *request) (res interface{}, err error) {
+	defer func() {
+		e := recover()
+		if e == nil {
+			return
+		}
+		res = nil
+		err = &rpcError{Code: codeInternalError, Message: fmt.Sprint(e)}
+	}()
+
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncFull,
+				CompletionProvider: &completionOptions{
+					TriggerCharacters: []string{`\`},
+				},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "latex-lsp"},
+		}, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		srv.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc := &document{uri: params.TextDocument.URI, text: params.TextDocument.Text}
+		srv.docs[doc.uri] = doc
+		return nil, srv.publish(doc)
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := srv.doc(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			doc.text = params.ContentChanges[n-1].Text
+		}
+		return nil, srv.publish(doc)
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(srv.docs, params.TextDocument.URI)
+		return nil, srv.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := srv.doc(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return complete(doc, doc.offset(params.Position)), nil
+
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := srv.doc(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return preview(doc, doc.offset(params.Position)), nil
+
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := srv.doc(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return definition(doc, doc.offset(params.Position)), nil
+
+	default:
+		if strings.HasPrefix(req.Method, "$/") {
+			// optional notifications and requests may be ignored.
+			return nil, nil
+		}
+		return nil, &rpcError{
+			Code:    codeMethodNotFound,
+			Message: fmt.Sprintf("method %q not supported", req.Method),
+		}
+	}
+}
+
+func unmarshal(raw json.RawMessage, v interface{}) error {
+	err := json.Unmarshal(raw, v)
+	if err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (srv *server) doc(uri string) (*document, error) {
+	doc, ok := srv.docs[uri]
+	if !ok {
+		return nil, &rpcError{
+			Code:    codeInvalidParams,
+			Message: fmt.Sprintf("unknown document %q", uri),
+		}
+	}
+	return doc, nil
+}
+
+// publish sends the diagnostics of a document to the client.
+func (srv *server) publish(doc *document) error {
+	return srv.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Diagnostics: diagnose(doc),
+	})
+}
+
+// diagnose returns the parse errors and lint results of a document.
+//
+// The document is parsed as a whole with latex.ParseDocument.
+// Each math expression is then parsed with latex.ParseExpr, which rejects
+// unknown macros, and the cross-references are checked.
+func diagnose(doc *document) []diagnostic {
+	diags := []diagnostic{}
+
+	tree, err := latex.ParseDocument(doc.text)
+	if err != nil {
+		off := len(doc.text)
+		var perr *latex.Error
+		if errors.As(err, &perr) {
+			off = perr.Pos.Offset
+			err = errors.New(perr.Msg)
+		}
+		return append(diags, diagnostic{
+			Range:    doc.span(off, off),
+			Severity: severityError,
+			Source:   "latex",
+			Message:  err.Error(),
+		})
+	}
+
+	defs := make(map[string]bool)
+	for _, def := range tree.Defs {
+		if name := defName(def); name != "" {
+			defs[name] = true
+		}
+	}
+
+	for _, m := range maths(tree) {
+		err := parseExpr(mathBody(doc.text[m.beg:m.end]))
+		if err == nil {
+			continue
+		}
+		if name := strings.TrimPrefix(err.Error(), "unknown macro "); defs[name] {
+			// macro defined by the document.
+			continue
+		}
+		diags = append(diags, diagnostic{
+			Range:    doc.span(m.beg, m.end),
+			Severity: severityError,
+			Source:   "latex",
+			Message:  err.Error(),
+		})
+	}
+
+	for _, p := range xref.New(tree).Check() {
+		var (
+			beg = int(p.Pos)
+			sev = severityWarning
+		)
+		if p.Kind == xref.Unused {
+			sev = severityHint
+		}
+		diags = append(diags, diagnostic{
+			Range:    doc.span(beg, beg+len(p.Name)),
+			Severity: sev,
+			Source:   "xref",
+			Message:  p.String(),
+		})
+	}
+
+	return diags
+}
+
+// parseExpr parses a math expression, turning parser panics into errors.
+func parseExpr(expr string) (err error) {
+	defer func() {
+		e := recover()
+		if e == nil {
+			return
+		}
+		err = fmt.Errorf("%v", e)
+	}()
+	_, err = latex.ParseExpr(expr)
+	return err
+}
+
+// defName returns the name of the macro defined by a definition command.
+func defName(def *ast.Macro) string {
+	for _, arg := range def.Args {
+		arg, ok := arg.(*ast.Arg)
+		if !ok || len(arg.List) == 0 {
+			continue
+		}
+		if m, ok := arg.List[0].(*ast.Macro); ok {
+			return m.Name.Name
+		}
+		return ""
+	}
+	return ""
+}
+
+// math is a math expression of a document.
+type math struct {
+	beg, end int // boundaries of the expression, including its delimiters
+}
+
+// maths returns the outermost math expressions and environments of a
+// document.
+func maths(doc *ast.Document) []math {
+	var ms []math
+	ast.Inspect(doc, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.MathExpr:
+			end := int(node.Right) + len(closingDelim(node.Delim))
+			ms = append(ms, math{
+				beg: int(node.Left),
+				end: end,
+			})
+			return false
+		case *ast.Env:
+			if !latex.IsMathEnv(node.Name.Name) {
+				return true
+			}
+			end := int(node.Right) + len(`\end{`+node.Name.Name+`}`)
+			ms = append(ms, math{
+				beg: int(node.Left),
+				end: end,
+			})
+			return false
+		}
+		return true
+	})
+	return ms
+}
+
+func closingDelim(delim string) string {
+	switch delim {
+	case `\(`:
+		return `\)`
+	case `\[`:
+		return `\]`
+	default:
+		return delim
+	}
+}
+
+// complete returns the macros and symbols completing the macro name
+// under the cursor.
+func complete(doc *document, off int) completionList {
+	beg := off
+	for beg > 0 && isLetter(doc.text[beg-1]) {
+		beg--
+	}
+	if beg == 0 || doc.text[beg-1] != '\\' {
+		return completionList{Items: []completionItem{}}
+	}
+	beg--
+
+	var (
+		prefix = doc.text[beg:off]
+		span   = doc.span(beg, off)
+		items  = []completionItem{}
+	)
+	for _, name := range candidates() {
+		if !strings.HasPrefix(name.label, prefix) {
+			continue
+		}
+		items = append(items, completionItem{
+			Label:    name.label,
+			Kind:     name.kind,
+			Detail:   name.detail,
+			TextEdit: &textEdit{Range: span, NewText: name.label},
+		})
+	}
+	return completionList{Items: items}
+}
+
+func isLetter(c byte) bool {
+	return c < unicode.MaxASCII && unicode.IsLetter(rune(c))
+}
+
+type candidate struct {
+	label  string
+	kind   int
+	detail string
+}
+
+// candidates returns the sorted list of known macros and symbols.
+func candidates() []candidate {
+	var (
+		cands []candidate
+		seen  = make(map[string]int)
+	)
+	for _, name := range latex.Macros() {
+		if len(name) < 2 || !isLetter(name[1]) {
+			continue
+		}
+		seen[name] = len(cands)
+		cands = append(cands, candidate{label: name, kind: completionFunction})
+	}
+	for _, sym := range tex2unicode.Symbols() {
+		if sym == "" || !isLetter(sym[0]) {
+			continue
+		}
+		var (
+			name   = `\` + sym
+			detail = string(tex2unicode.Index(name, true))
+		)
+		if i, ok := seen[name]; ok {
+			cands[i].detail = detail
+			continue
+		}
+		seen[name] = len(cands)
+		cands = append(cands, candidate{label: name, kind: completionConstant, detail: detail})
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		return cands[i].label < cands[j].label
+	})
+	return cands
+}
+
+// preview returns a rendering of the math expression under the cursor.
+func preview(doc *document, off int) *hover {
+	tree, err := latex.ParseDocument(doc.text)
+	if err != nil {
+		return nil
+	}
+
+	for _, m := range maths(tree) {
+		if off < m.beg || m.end <= off {
+			continue
+		}
+		var (
+			src  = doc.text[m.beg:m.end]
+			span = doc.span(m.beg, m.end)
+			txt  = "```latex\n" + src + "\n```\n"
+		)
+		png, err := render(mathBody(src))
+		switch err {
+		case nil:
+			txt += "\n![preview](data:image/png;base64," + base64.StdEncoding.EncodeToString(png) + ")\n"
+		default:
+			txt += "\n*could not render expression: " + err.Error() + "*\n"
+		}
+		return &hover{
+			Contents: markupContent{Kind: "markdown", Value: txt},
+			Range:    &span,
+		}
+	}
+	return nil
+}
+
+// mathBody returns the provided math expression or environment as an
+// inline math expression.
+// Multi-line environments, such as align, are kept whole so their lines
+// and columns are laid out.
+func mathBody(src string) string {
+	switch {
+	case strings.HasPrefix(src, "$$"):
+		src = strings.TrimSuffix(strings.TrimPrefix(src, "$$"), "$$")
+	case strings.HasPrefix(src, "$"):
+		src = strings.TrimSuffix(strings.TrimPrefix(src, "$"), "$")
+	case strings.HasPrefix(src, `\(`), strings.HasPrefix(src, `\[`):
+		src = src[2 : len(src)-2]
+	case strings.HasPrefix(src, `\begin{`):
+		beg := strings.Index(src, "}") + 1
+		if !singleEnvs[src[len(`\begin{`):beg-1]] {
+			break
+		}
+		end := strings.LastIndex(src, `\end{`)
+		src = src[beg:end]
+	}
+	return "$" + strings.TrimSpace(src) + "$"
+}
+
+// singleEnvs are the math environments holding a single formula, previewed
+// as inline math.
+var singleEnvs = map[string]bool{
+	"math":        true,
+	"displaymath": true,
+	"equation":    true,
+	"equation*":   true,
+}
+
+// render renders a math expression as a PNG image.
+func render(expr string) (img []byte, err error) {
+	defer func() {
+		e := recover()
+		if e == nil {
+			return
+		}
+		img = nil
+		err = fmt.Errorf("%v", e)
+	}()
+
+	buf := new(bytes.Buffer)
+	err = mtex.Render(drawimg.NewRenderer(buf), expr, 12, 144, lm.Fonts())
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// definition returns the location of the label referenced under the cursor.
+func definition(doc *document, off int) []location {
+	tree, err := latex.ParseDocument(doc.text)
+	if err != nil {
+		return []location{}
+	}
+
+	idx := xref.New(tree)
+	for _, ref := range idx.Refs {
+		// the cursor may be on the key, or on the name of a single-key
+		// referencing command.
+		var (
+			beg = int(ref.Pos)
+			end = beg + len(ref.Name)
+		)
+		if off < beg || end < off {
+			beg = int(ref.Macro.Pos())
+			if off < beg || end < off || len(keysOf(idx, ref.Macro)) != 1 {
+				continue
+			}
+		}
+		lbl := idx.Label(ref.Name)
+		if lbl == nil {
+			return []location{}
+		}
+		beg = int(lbl.Pos)
+		return []location{{
+			URI:   doc.uri,
+			Range: doc.span(beg, beg+len(lbl.Name)),
+		}}
+	}
+	return []location{}
+}
+
+// keysOf returns the references made by the provided command.
+func keysOf(idx *xref.Index, macro *ast.Macro) []*xref.Ref {
+	var refs []*xref.Ref
+	for _, ref := range idx.Refs {
+		if ref.Macro == macro {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,319 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// session runs the server over the provided messages and returns the
+// messages sent back by the server.
+func session(t *testing.T, reqs ...string) []message {
+	t.Helper()
+
+	in := new(bytes.Buffer)
+	for _, req := range reqs {
+		fmt.Fprintf(in, "Content-Length: %d\r\n\r\n%s", len(req), req)
+	}
+	out := new(bytes.Buffer)
+	err := newServer(in, out).run()
+	if err != nil {
+		t.Fatalf("could not run server: %+v", err)
+	}
+
+	var (
+		r    = textproto.NewReader(bufio.NewReader(out))
+		msgs []message
+	)
+	for {
+		hdr, err := r.ReadMIMEHeader()
+		if err != nil {
+			break
+		}
+		n, err := strconv.Atoi(hdr.Get("Content-Length"))
+		if err != nil {
+			t.Fatalf("invalid header: %+v", err)
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(r.R, buf)
+		if err != nil {
+			t.Fatalf("could not read message: %+v", err)
+		}
+		var msg message
+		err = json.Unmarshal(buf, &msg)
+		if err != nil {
+			t.Fatalf("could not decode message %q: %+v", buf, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func didOpen(text string) string {
+	raw, _ := json.Marshal(text)
+	return `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///doc.tex","version":1,"text":` + string(raw) + `}}}`
+}
+
+func at(id int, method string, line, char int) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":{"textDocument":{"uri":"file:///doc.tex"},"position":{"line":%d,"character":%d}}}`, id, method, line, char)
+}
+
+func TestInitialize(t *testing.T) {
+	msgs := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"foo/bar","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	if got, want := len(msgs), 3; got != want {
+		t.Fatalf("invalid number of messages: got=%d, want=%d", got, want)
+	}
+
+	var res initializeResult
+	err := json.Unmarshal(msgs[0].Result, &res)
+	if err != nil {
+		t.Fatalf("could not decode result: %+v", err)
+	}
+	if got, want := res.Capabilities.TextDocumentSync, textDocumentSyncFull; got != want {
+		t.Fatalf("invalid sync kind: got=%d, want=%d", got, want)
+	}
+	if !res.Capabilities.HoverProvider || !res.Capabilities.DefinitionProvider {
+		t.Fatalf("invalid capabilities: %+v", res.Capabilities)
+	}
+
+	if msgs[1].Error == nil || msgs[1].Error.Code != codeMethodNotFound {
+		t.Fatalf("invalid error: %+v", msgs[1].Error)
+	}
+	if got, want := string(msgs[2].Result), "null"; got != want {
+		t.Fatalf("invalid shutdown result: got=%q, want=%q", got, want)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		want []diagnostic
+	}{
+		{
+			name: "ok",
+			text: `\documentclass{article}
+\begin{document}
+Let $x = \alpha$, see~\ref{eq}.
+\begin{equation}\label{eq}
+\sqrt{x}
+\end{equation}
+\end{document}
+`,
+			want: []diagnostic{},
+		},
+		{
+			name: "parse-error",
+			text: `\documentclass{article}
+\begin{document}
+\begin{itemize}
+\end{document}
+`,
+			want: []diagnostic{{
+				Range:    rng{Start: position{3, 4}, End: position{3, 4}},
+				Severity: severityError,
+				Source:   "latex",
+				Message:  `\begin{itemize} ended by \end{document}`,
+			}},
+		},
+		{
+			name: "unknown-macro",
+			text: `\documentclass{article}
+\newcommand{\R}{\mathbb{R}}
+\begin{document}
+$x \in \R$ and $\foo{x}$.
+\end{document}
+`,
+			want: []diagnostic{{
+				Range:    rng{Start: position{3, 15}, End: position{3, 24}},
+				Severity: severityError,
+				Source:   "latex",
+				Message:  `unknown macro \foo`,
+			}},
+		},
+		{
+			name: "xref",
+			text: `\begin{document}
+See~\ref{sec:a}.
+\section{Intro}\label{sec:b}
+\end{document}
+`,
+			want: []diagnostic{
+				{
+					Range:    rng{Start: position{1, 9}, End: position{1, 14}},
+					Severity: severityWarning,
+					Source:   "xref",
+					Message:  `undefined reference "sec:a"`,
+				},
+				{
+					Range:    rng{Start: position{2, 22}, End: position{2, 27}},
+					Severity: severityHint,
+					Source:   "xref",
+					Message:  `unused label "sec:b"`,
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msgs := session(t, didOpen(tc.text))
+			if got, want := len(msgs), 1; got != want {
+				t.Fatalf("invalid number of messages: got=%d, want=%d", got, want)
+			}
+			if got, want := msgs[0].Method, "textDocument/publishDiagnostics"; got != want {
+				t.Fatalf("invalid method: got=%q, want=%q", got, want)
+			}
+			var params publishDiagnosticsParams
+			err := json.Unmarshal(msgs[0].Params, &params)
+			if err != nil {
+				t.Fatalf("could not decode params: %+v", err)
+			}
+			got, _ := json.Marshal(params.Diagnostics)
+			want, _ := json.Marshal(tc.want)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("invalid diagnostics:\ngot= %s\nwant=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	msgs := session(t,
+		didOpen(`$\alp + \sq$`),
+		at(1, "textDocument/completion", 0, 5),
+		at(2, "textDocument/completion", 0, 11),
+		at(3, "textDocument/completion", 0, 1),
+	)
+	if got, want := len(msgs), 4; got != want {
+		t.Fatalf("invalid number of messages: got=%d, want=%d", got, want)
+	}
+
+	for _, tc := range []struct {
+		msg  message
+		want string
+		rng  rng
+	}{
+		{msgs[1], `\alpha`, rng{Start: position{0, 1}, End: position{0, 5}}},
+		{msgs[2], `\sqrt`, rng{Start: position{0, 8}, End: position{0, 11}}},
+	} {
+		var list completionList
+		err := json.Unmarshal(tc.msg.Result, &list)
+		if err != nil {
+			t.Fatalf("could not decode completion: %+v", err)
+		}
+		found := false
+		for _, item := range list.Items {
+			if !strings.HasPrefix(item.Label, tc.want[:3]) {
+				t.Fatalf("invalid completion item %q", item.Label)
+			}
+			if item.Label != tc.want {
+				continue
+			}
+			found = true
+			if item.TextEdit == nil || item.TextEdit.Range != tc.rng {
+				t.Fatalf("invalid edit for %q: %+v", item.Label, item.TextEdit)
+			}
+		}
+		if !found {
+			t.Fatalf("could not find %q in completion list", tc.want)
+		}
+	}
+
+	var list completionList
+	err := json.Unmarshal(msgs[3].Result, &list)
+	if err != nil {
+		t.Fatalf("could not decode completion: %+v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("invalid completion outside of a macro: %d items", len(list.Items))
+	}
+}
+
+func TestHover(t *testing.T) {
+	msgs := session(t,
+		didOpen("Let $\\sqrt{x}$ be.\n"),
+		at(1, "textDocument/hover", 0, 7),
+		at(2, "textDocument/hover", 0, 1),
+	)
+	if got, want := len(msgs), 3; got != want {
+		t.Fatalf("invalid number of messages: got=%d, want=%d", got, want)
+	}
+
+	var h hover
+	err := json.Unmarshal(msgs[1].Result, &h)
+	if err != nil {
+		t.Fatalf("could not decode hover: %+v", err)
+	}
+	if !strings.Contains(h.Contents.Value, "data:image/png;base64,") {
+		t.Fatalf("missing preview:\n%s", h.Contents.Value)
+	}
+	if got, want := *h.Range, (rng{Start: position{0, 4}, End: position{0, 14}}); got != want {
+		t.Fatalf("invalid range: got=%+v, want=%+v", got, want)
+	}
+
+	if got, want := string(msgs[2].Result), "null"; got != want {
+		t.Fatalf("invalid hover outside of math: got=%s, want=%s", got, want)
+	}
+
+	msgs = session(t,
+		didOpen("\\begin{align*}\na &= b \\\\\nc &= d\n\\end{align*}\n"),
+		at(1, "textDocument/hover", 1, 2),
+	)
+	if got, want := len(msgs), 2; got != want {
+		t.Fatalf("invalid number of messages: got=%d, want=%d", got, want)
+	}
+	h = hover{}
+	err = json.Unmarshal(msgs[1].Result, &h)
+	if err != nil {
+		t.Fatalf("could not decode hover: %+v", err)
+	}
+	if !strings.Contains(h.Contents.Value, "data:image/png;base64,") {
+		t.Fatalf("missing align preview:\n%s", h.Contents.Value)
+	}
+	if got, want := *h.Range, (rng{Start: position{0, 0}, End: position{3, 12}}); got != want {
+		t.Fatalf("invalid align range: got=%+v, want=%+v", got, want)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	msgs := session(t,
+		didOpen("\\section{A}\\label{sec:a}\nSee~\\ref{sec:a} and \\cref{sec:a,sec:b}.\n"),
+		at(1, "textDocument/definition", 1, 11),
+		at(2, "textDocument/definition", 1, 5),
+		at(3, "textDocument/definition", 1, 29),
+		at(4, "textDocument/definition", 1, 35),
+	)
+	if got, want := len(msgs), 5; got != want {
+		t.Fatalf("invalid number of messages: got=%d, want=%d", got, want)
+	}
+
+	want := `[{"uri":"file:///doc.tex","range":{"start":{"line":0,"character":18},"end":{"line":0,"character":23}}}]`
+	for i, w := range []string{want, want, want, "[]"} {
+		if got := string(msgs[i+1].Result); got != w {
+			t.Fatalf("invalid definition #%d:\ngot= %s\nwant=%s", i, got, w)
+		}
+	}
+}
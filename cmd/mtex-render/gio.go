@@ -322,6 +322,10 @@ func (r *gioRenderer) Render(width, height, dpi float64, c *drawtex.Canvas) erro
 			r.drawGlyph(dpi, opTex)
 		case drawtex.RectOp:
 			r.drawRect(dpi, opTex)
+		case drawtex.StrokedRectOp:
+			r.drawStrokedRect(dpi, opTex)
+		case drawtex.LineOp:
+			r.drawLine(dpi, opTex)
 		default:
 			panic(fmt.Errorf("unknown drawtex op %T", opTex))
 		}
@@ -342,7 +346,7 @@ func (r *gioRenderer) drawGlyph(dpi float64, tex drawtex.GlyphOp) {
 	if tex.Glyph.Metrics.Slanted {
 		lbl.Font.Style = text.Italic
 	}
-	lbl.Color = r.col
+	lbl.Color = r.color(tex.Color)
 	lbl.Alignment = text.Start
 	lbl.Layout(r.gtx)
 }
@@ -361,7 +365,53 @@ func (r *gioRenderer) drawRect(dpi float64, tex drawtex.RectOp) {
 		Path: p.End(),
 	}.Op().Add(r.gtx.Ops)
 
-	paint.Fill(r.gtx.Ops, r.col)
+	paint.Fill(r.gtx.Ops, r.color(tex.Color))
+}
+
+func (r *gioRenderer) drawStrokedRect(dpi float64, tex drawtex.StrokedRectOp) {
+	defer op.Save(r.gtx.Ops).Load()
+
+	var p clip.Path
+	p.Begin(r.gtx.Ops)
+	p.MoveTo(r.pt(tex.X1*dpi, tex.Y1*dpi).Add(r.offset))
+	p.LineTo(r.pt(tex.X2*dpi, tex.Y1*dpi).Add(r.offset))
+	p.LineTo(r.pt(tex.X2*dpi, tex.Y2*dpi).Add(r.offset))
+	p.LineTo(r.pt(tex.X1*dpi, tex.Y2*dpi).Add(r.offset))
+	p.Close()
+	clip.Stroke{
+		Path: p.End(),
+		Style: clip.StrokeStyle{
+			Width: float32(tex.Width * dpi),
+		},
+	}.Op().Add(r.gtx.Ops)
+
+	paint.Fill(r.gtx.Ops, r.color(tex.Color))
+}
+
+func (r *gioRenderer) drawLine(dpi float64, tex drawtex.LineOp) {
+	defer op.Save(r.gtx.Ops).Load()
+
+	var p clip.Path
+	p.Begin(r.gtx.Ops)
+	p.MoveTo(r.pt(tex.X1*dpi, tex.Y1*dpi).Add(r.offset))
+	p.LineTo(r.pt(tex.X2*dpi, tex.Y2*dpi).Add(r.offset))
+	clip.Stroke{
+		Path: p.End(),
+		Style: clip.StrokeStyle{
+			Width: float32(tex.Width * dpi),
+		},
+	}.Op().Add(r.gtx.Ops)
+
+	paint.Fill(r.gtx.Ops, r.color(tex.Color))
+}
+
+// color returns the gio color of a drawtex op, the default color of the
+// renderer when the op has none.
+func (r *gioRenderer) color(c color.Color) color.NRGBA {
+	if c == nil {
+		return r.col
+	}
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
 }
 
 func (*gioRenderer) pt(x, y float64) f32.Point {
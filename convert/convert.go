@@ -0,0 +1,326 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package convert converts LaTeX math syntax trees to other math markup
+// languages: Typst math syntax and Office Math Markup Language (OMML).
+//
+// Converters cover fractions, roots, scripts, big operators with limits,
+// accents, fonts, matrices and delimiters.
+// Constructs that can not be converted are reported as warnings, together
+// with their source position.
+package convert // import "github.com/go-latex/latex/convert"
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/internal/tex2unicode"
+	"github.com/go-latex/latex/token"
+)
+
+// Warning describes a construct that could not be converted.
+type Warning struct {
+	Pos token.Pos // position of the construct in the LaTeX source
+	Msg string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%d: %s", w.Pos, w.Msg)
+}
+
+// atom is a node of a math list, together with its scripts.
+type atom struct {
+	node   ast.Node
+	accent *ast.Macro // accent without argument applied to node, e.g. \hat x
+	fence  *fence     // \left ... \right group, replacing node
+	sub    ast.Node
+	sup    ast.Node
+	limits int // placement of the limits of big operators: 0 (default), +1 (\limits) or -1 (\nolimits)
+}
+
+// fence is a list of nodes enclosed in \left and \right delimiters.
+type fence struct {
+	pos         token.Pos
+	left, right string // delimiters, "." for a null delimiter
+	body        ast.List
+}
+
+// atoms splits a math list into atoms.
+func atoms(list ast.List, warn func(pos token.Pos, format string, args ...interface{})) []atom {
+	var out []atom
+	for i := 0; i < len(list); i++ {
+		var (
+			node = list[i]
+			a    = atom{node: node}
+		)
+		if m, ok := node.(*ast.Macro); ok && len(m.Args) == 0 {
+			switch name := m.Name.Name; {
+			case name == `\left`:
+				f, n := parseFence(m, list[i+1:], warn)
+				a.fence = f
+				i += n
+			case name == `\right`:
+				warn(m.Pos(), `unmatched \right`)
+				if i+1 < len(list) {
+					i++
+				}
+				continue
+			case name == `\middle` && i+1 < len(list):
+				// the delimiter is converted as a regular symbol.
+				continue
+			case isAccent(name) && i+1 < len(list):
+				a.accent = m
+				i++
+				a.node = list[i]
+			}
+		}
+
+	scripts:
+		for i+1 < len(list) {
+			switch next := list[i+1].(type) {
+			case *ast.Sub:
+				if a.sub != nil {
+					break scripts
+				}
+				a.sub = next.Node
+			case *ast.Sup:
+				if a.sup != nil {
+					break scripts
+				}
+				a.sup = next.Node
+			case *ast.Macro:
+				switch next.Name.Name {
+				case `\limits`:
+					a.limits = +1
+				case `\nolimits`:
+					a.limits = -1
+				default:
+					break scripts
+				}
+			default:
+				break scripts
+			}
+			i++
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// parseFence parses the delimiters and the body of a \left ... \right group,
+// and returns the number of consumed nodes.
+func parseFence(left *ast.Macro, list ast.List, warn func(pos token.Pos, format string, args ...interface{})) (*fence, int) {
+	f := &fence{pos: left.Pos(), left: ".", right: "."}
+	if len(list) == 0 {
+		warn(left.Pos(), `missing \left delimiter`)
+		return f, 0
+	}
+	f.left = delimText(list[0])
+
+	depth := 0
+	for i := 1; i < len(list); i++ {
+		m, ok := list[i].(*ast.Macro)
+		if ok {
+			switch m.Name.Name {
+			case `\left`:
+				depth++
+			case `\right`:
+				if depth > 0 {
+					depth--
+					continue
+				}
+				f.body = list[1:i]
+				if i+1 == len(list) {
+					warn(m.Pos(), `missing \right delimiter`)
+					return f, i + 1
+				}
+				f.right = delimText(list[i+1])
+				return f, i + 2
+			}
+		}
+	}
+	warn(left.Pos(), `missing \right`)
+	f.body = list[1:]
+	return f, len(list)
+}
+
+// delimText returns the delimiter represented by a node.
+func delimText(node ast.Node) string {
+	switch node := node.(type) {
+	case *ast.Symbol:
+		return node.Text
+	case *ast.Macro:
+		return node.Name.Name
+	case *ast.Literal:
+		return node.Text
+	case *ast.Word:
+		return node.Text
+	}
+	return "."
+}
+
+// delimRune returns the unicode character of a delimiter, or "" for a null
+// delimiter.
+func delimRune(delim string) string {
+	switch delim {
+	case ".", "":
+		return ""
+	case `\{`:
+		return "{"
+	case `\}`:
+		return "}"
+	case `\|`, `\Vert`:
+		return "‖"
+	case `\vert`:
+		return "|"
+	}
+	if tex2unicode.HasSymbol(delim[1:]) {
+		return string(tex2unicode.Index(delim, true))
+	}
+	return delim
+}
+
+// isAccent returns whether the named macro is a math accent.
+func isAccent(name string) bool {
+	_, ok := accents[name]
+	return ok
+}
+
+// accent describes a math accent.
+type accent struct {
+	typst string // Typst function
+	char  rune   // combining character used by OMML
+}
+
+var accents = map[string]accent{
+	`\hat`:       {"hat", '\u0302'},
+	`\widehat`:   {"hat", '\u0302'},
+	`\check`:     {"caron", '\u030c'},
+	`\tilde`:     {"tilde", '\u0303'},
+	`\widetilde`: {"tilde", '\u0303'},
+	`\bar`:       {"macron", '\u0304'},
+	`\vec`:       {"arrow", '\u20d7'},
+	`\dot`:       {"dot", '\u0307'},
+	`\ddot`:      {"dot.double", '\u0308'},
+	`\acute`:     {"acute", '\u0301'},
+	`\grave`:     {"grave", '\u0300'},
+	`\breve`:     {"breve", '\u0306'},
+	`\mathring`:  {"circle", '\u030a'},
+}
+
+// bigops are the big operators, with their Typst name and unicode
+// character.
+var bigops = map[string]struct {
+	typst  string
+	char   rune
+	limits bool // whether limits are placed above and below in display style
+}{
+	`\sum`:       {"sum", '∑', true},
+	`\prod`:      {"product", '∏', true},
+	`\coprod`:    {"product.co", '∐', true},
+	`\int`:       {"integral", '∫', false},
+	`\iint`:      {"integral.double", '∬', false},
+	`\iiint`:     {"integral.triple", '∭', false},
+	`\oint`:      {"integral.cont", '∮', false},
+	`\bigcup`:    {"union.big", '⋃', true},
+	`\bigcap`:    {"sect.big", '⋂', true},
+	`\bigoplus`:  {"plus.circle.big", '⨁', true},
+	`\bigotimes`: {"times.circle.big", '⨂', true},
+	`\bigodot`:   {"dot.circle.big", '⨀', true},
+	`\biguplus`:  {"union.plus.big", '⨄', true},
+	`\bigsqcup`:  {"union.sq.big", '⨆', true},
+	`\bigvee`:    {"or.big", '⋁', true},
+	`\bigwedge`:  {"and.big", '⋀', true},
+}
+
+// funcs are the function names typeset upright.
+// Functions with limits have their subscript placed below the name.
+var funcs = map[string]bool{
+	`\arccos`: false, `\arcsin`: false, `\arctan`: false, `\arg`: false,
+	`\cos`: false, `\cosh`: false, `\cot`: false, `\coth`: false,
+	`\csc`: false, `\deg`: false, `\dim`: false, `\exp`: false,
+	`\hom`: false, `\ker`: false, `\lg`: false, `\ln`: false,
+	`\log`: false, `\sec`: false, `\sin`: false, `\sinh`: false,
+	`\tan`: false, `\tanh`: false,
+
+	`\det`: true, `\gcd`: true, `\inf`: true, `\lim`: true,
+	`\liminf`: true, `\limsup`: true, `\max`: true, `\min`: true,
+	`\sup`: true, `\Pr`: true,
+}
+
+// matrixDelims are the delimiters of matrix environments.
+var matrixDelims = map[string][2]string{
+	"matrix":      {"", ""},
+	"smallmatrix": {"", ""},
+	"pmatrix":     {"(", ")"},
+	"bmatrix":     {"[", "]"},
+	"Bmatrix":     {"{", "}"},
+	"vmatrix":     {"|", "|"},
+	"Vmatrix":     {"‖", "‖"},
+	"cases":       {"{", ""},
+	"array":       {"", ""},
+}
+
+// spaces are the spacing macros, in mu.
+var spaces = map[string]int{
+	`\,`:     3,
+	`\:`:     4,
+	`\;`:     5,
+	`\ `:     6,
+	`\quad`:  18,
+	`\qquad`: 36,
+	`\!`:     -3,
+}
+
+// text returns the text of the last argument of a text macro, such as
+// \text{...} or \operatorname{...}.
+// Escaped characters, such as \&, are unescaped, and other macros are
+// reported as warnings.
+func text(m *ast.Macro, warn func(pos token.Pos, format string, args ...interface{})) string {
+	if len(m.Args) == 0 {
+		return ""
+	}
+	var o strings.Builder
+	ast.Inspect(m.Args[len(m.Args)-1], func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Word:
+			o.WriteString(node.Text)
+		case *ast.Literal:
+			o.WriteString(node.Text)
+		case *ast.Symbol:
+			o.WriteString(node.Text)
+		case *ast.Macro:
+			switch name := node.Name.Name; name {
+			case `\&`, `\%`, `\$`, `\#`, `\_`:
+				o.WriteString(name[1:])
+			default:
+				warn(node.Pos(), "unsupported macro %s in %s", name, m.Name.Name)
+			}
+		}
+		return true
+	})
+	return o.String()
+}
+
+// isRel returns whether the node is a relation or a punctuation symbol,
+// ending the operand of a big operator.
+func isRel(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.Symbol:
+		return strings.Contains("=<>,;&", node.Text)
+	case *ast.Macro:
+		return rels[node.Name.Name]
+	}
+	return false
+}
+
+var rels = map[string]bool{
+	`\neq`: true, `\leq`: true, `\geq`: true, `\le`: true, `\ge`: true,
+	`\ll`: true, `\gg`: true, `\approx`: true, `\equiv`: true, `\cong`: true,
+	`\sim`: true, `\simeq`: true, `\propto`: true, `\in`: true, `\subset`: true,
+	`\subseteq`: true, `\supset`: true, `\supseteq`: true, `\to`: true,
+	`\rightarrow`: true, `\Rightarrow`: true, `\Leftrightarrow`: true,
+	`\\`: true,
+}
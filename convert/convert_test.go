@@ -0,0 +1,177 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex"
+)
+
+func TestTypst(t *testing.T) {
+	for _, tc := range []struct {
+		expr  string
+		want  string
+		warns []string
+	}{
+		{
+			expr: `$\frac{a}{b} + \sqrt[3]{x^2} - \sqrt{y}$`,
+			want: `frac(a, b) + root(3, x^2) - sqrt(y)`,
+		},
+		{
+			expr: `$\sum_{i=1}^{n} x_i^2 = \int\limits_0^\infty e^{-x} dx$`,
+			want: `sum_(i = 1)^n x_i^2 = limits(integral)_0^oo e^(- x) d x`,
+		},
+		{
+			expr: `$\lim_{x \to 0} \hat x \vec{v} \mathbb{R} \alpha \leq \text{if } y$`,
+			want: `lim_(x -> 0) hat(x) arrow(v) bb(R) alpha <= "if " y`,
+		},
+		{
+			expr: `$\left( \frac{a}{b} \right] \begin{pmatrix} a & b \\ c & d \end{pmatrix}$`,
+			want: `lr(( frac(a, b) ]) mat(delim: "(", a, b; c, d)`,
+		},
+		{
+			expr: `$f(x) = \begin{cases} 1 & x > 0 \\ 0 & \text{otherwise} \end{cases}$`,
+			want: `f ( x ) = cases(1 & x > 0, 0 & "otherwise")`,
+		},
+		{
+			expr: `$\mathbf{x} \operatorname{tr} A \textbf{bold} \sin^2 x$`,
+			want: `bold(x) op("tr") A bold("bold") sin^2 x`,
+		},
+		{
+			expr: `$\hspace{1cm} x \! y$`,
+			want: `1 c m x y`,
+			warns: []string{
+				`1: unsupported macro \hspace`,
+				`16: unsupported space \!`,
+			},
+		},
+		{
+			expr:  `$a \right) b$`,
+			want:  `a b`,
+			warns: []string{`3: unmatched \right`},
+		},
+		{
+			expr: `$\text{for all } x \text{a,b} \text{a \& b, 5\%}$`,
+			want: `"for all " x "a,b" "a & b, 5%"`,
+		},
+		{
+			expr: `$\textbf{x \alpha}$`,
+			want: `bold("x ")`,
+			warns: []string{
+				`11: unsupported macro \alpha in \textbf`,
+			},
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			node, err := latex.ParseExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.expr, err)
+			}
+			got, warns := Typst(node)
+			if got != tc.want {
+				t.Fatalf("invalid typst conversion:\ngot= %q\nwant=%q", got, tc.want)
+			}
+			if got, want := strs(warns), tc.warns; !reflect.DeepEqual(got, want) {
+				t.Fatalf("invalid warnings:\ngot= %q\nwant=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestOMML(t *testing.T) {
+	for _, tc := range []struct {
+		expr  string
+		want  string
+		warns []string
+	}{
+		{
+			expr: `$\frac{a}{b}$`,
+			want: `<m:f><m:num><m:r><m:t>a</m:t></m:r></m:num><m:den><m:r><m:t>b</m:t></m:r></m:den></m:f>`,
+		},
+		{
+			expr: `$\sqrt{y}$`,
+			want: `<m:rad><m:radPr><m:degHide m:val="1"/></m:radPr><m:deg></m:deg><m:e><m:r><m:t>y</m:t></m:r></m:e></m:rad>`,
+		},
+		{
+			expr: `$\sum_{i}^{n} x_i = 0$`,
+			want: `<m:nary><m:naryPr><m:chr m:val="∑"/><m:limLoc m:val="undOvr"/></m:naryPr>` +
+				`<m:sub><m:r><m:t>i</m:t></m:r></m:sub><m:sup><m:r><m:t>n</m:t></m:r></m:sup>` +
+				`<m:e><m:sSub><m:e><m:r><m:t>x</m:t></m:r></m:e><m:sub><m:r><m:t>i</m:t></m:r></m:sub></m:sSub></m:e></m:nary>` +
+				`<m:r><m:t>=</m:t></m:r><m:r><m:t>0</m:t></m:r>`,
+		},
+		{
+			expr: `$\int\nolimits_0 x$`,
+			want: `<m:nary><m:naryPr><m:chr m:val="∫"/><m:limLoc m:val="subSup"/><m:supHide m:val="1"/></m:naryPr>` +
+				`<m:sub><m:r><m:t>0</m:t></m:r></m:sub><m:sup></m:sup><m:e><m:r><m:t>x</m:t></m:r></m:e></m:nary>`,
+		},
+		{
+			expr: `$\lim_{x} \hat x$`,
+			want: `<m:limLow><m:e><m:r><m:rPr><m:sty m:val="p"/></m:rPr><m:t>lim</m:t></m:r></m:e><m:lim><m:r><m:t>x</m:t></m:r></m:lim></m:limLow>` +
+				`<m:acc><m:accPr><m:chr m:val="̂"/></m:accPr><m:e><m:r><m:t>x</m:t></m:r></m:e></m:acc>`,
+		},
+		{
+			expr: `$\left( a \right] \mathbb{R}$`,
+			want: `<m:d><m:dPr><m:begChr m:val="("/><m:endChr m:val="]"/></m:dPr><m:e><m:r><m:t>a</m:t></m:r></m:e></m:d>` +
+				`<m:r><m:rPr><m:scr m:val="double-struck"/><m:sty m:val="p"/></m:rPr><m:t>R</m:t></m:r>`,
+		},
+		{
+			expr: `$\begin{pmatrix} a & b \end{pmatrix}$`,
+			want: `<m:d><m:dPr><m:begChr m:val="("/><m:endChr m:val=")"/></m:dPr><m:e><m:m>` +
+				`<m:mr><m:e><m:r><m:t>a</m:t></m:r></m:e><m:e><m:r><m:t>b</m:t></m:r></m:e></m:mr></m:m></m:e></m:d>`,
+		},
+		{
+			expr: `$\text{a<b} \hspace{1cm}$`,
+			want: `<m:r><m:rPr><m:nor/></m:rPr><m:t>a&lt;b</m:t></m:r><m:r><m:t>1</m:t></m:r><m:r><m:t>cm</m:t></m:r>`,
+			warns: []string{
+				`12: unsupported macro \hspace`,
+			},
+		},
+		{
+			expr: `$\text{for all } x \text{a,b}$`,
+			want: `<m:r><m:rPr><m:nor/></m:rPr><m:t>for all </m:t></m:r><m:r><m:t>x</m:t></m:r>` +
+				`<m:r><m:rPr><m:nor/></m:rPr><m:t>a,b</m:t></m:r>`,
+		},
+		{
+			expr: `$\text{a \& b, 5\% \$ \#1 a\_b \alpha}$`,
+			want: `<m:r><m:rPr><m:nor/></m:rPr><m:t>a &amp; b, 5% $ #1 a_b </m:t></m:r>`,
+			warns: []string{
+				`31: unsupported macro \alpha in \text`,
+			},
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			node, err := latex.ParseExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.expr, err)
+			}
+			got, warns := OMML(node)
+			const (
+				beg = `<m:oMath xmlns:m="` + OMMLNamespace + `">`
+				end = `</m:oMath>`
+			)
+			if !strings.HasPrefix(got, beg) || !strings.HasSuffix(got, end) {
+				t.Fatalf("invalid oMath element: %q", got)
+			}
+			got = strings.TrimSuffix(strings.TrimPrefix(got, beg), end)
+			if got != tc.want {
+				t.Fatalf("invalid OMML conversion:\ngot= %q\nwant=%q", got, tc.want)
+			}
+			if got, want := strs(warns), tc.warns; !reflect.DeepEqual(got, want) {
+				t.Fatalf("invalid warnings:\ngot= %q\nwant=%q", got, want)
+			}
+		})
+	}
+}
+
+func strs(warns []Warning) []string {
+	var o []string
+	for _, w := range warns {
+		o = append(o, w.String())
+	}
+	return o
+}
@@ -0,0 +1,532 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/internal/tex2unicode"
+	"github.com/go-latex/latex/token"
+)
+
+// OMMLNamespace is the XML namespace of Office Math Markup Language.
+const OMMLNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/math"
+
+// OMML converts a LaTeX math expression to an Office Math Markup Language
+// <m:oMath> element.
+func OMML(node ast.Node) (string, []Warning) {
+	c := omml{o: new(strings.Builder)}
+	fmt.Fprintf(c.o, "<m:oMath xmlns:m=%q>", OMMLNamespace)
+	c.node(node)
+	c.o.WriteString("</m:oMath>")
+	return c.o.String(), c.warns
+}
+
+type omml struct {
+	o     *strings.Builder
+	font  font // current font
+	warns []Warning
+}
+
+// font holds the run properties of OMML text.
+type font struct {
+	nor bool   // normal text, as opposed to math
+	scr string // script: roman, script, fraktur, double-struck, sans-serif, monospace
+	sty string // style: p (plain), b (bold), i (italic), bi (bold italic)
+}
+
+func (c *omml) warn(pos token.Pos, format string, args ...interface{}) {
+	c.warns = append(c.warns, Warning{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// elem writes an element, whose content is written by the provided function.
+func (c *omml) elem(name string, content func()) {
+	c.o.WriteString("<m:" + name + ">")
+	if content != nil {
+		content()
+	}
+	c.o.WriteString("</m:" + name + ">")
+}
+
+// prop writes an empty property element with a value.
+func (c *omml) prop(name, val string) {
+	fmt.Fprintf(c.o, "<m:%s m:val=%q/>", name, xmlEscape(val))
+}
+
+// run writes a run of text, with the current font.
+func (c *omml) run(txt string) {
+	c.runWith(c.font, txt)
+}
+
+func (c *omml) runWith(f font, txt string) {
+	c.elem("r", func() {
+		if f != (font{}) {
+			c.elem("rPr", func() {
+				if f.nor {
+					c.o.WriteString("<m:nor/>")
+				}
+				if f.scr != "" {
+					c.prop("scr", f.scr)
+				}
+				if f.sty != "" {
+					c.prop("sty", f.sty)
+				}
+			})
+		}
+		c.elem("t", func() { c.o.WriteString(xmlEscape(txt)) })
+	})
+}
+
+func (c *omml) node(node ast.Node) {
+	switch node := node.(type) {
+	case nil:
+	case ast.List:
+		c.list(node)
+	case *ast.MathExpr:
+		c.list(node.List)
+	case *ast.Arg:
+		c.list(node.List)
+	case *ast.OptArg:
+		c.list(node.List)
+	case *ast.Word:
+		c.run(node.Text)
+	case *ast.Literal:
+		c.run(node.Text)
+	case *ast.Symbol:
+		c.symbol(node)
+	case *ast.Macro:
+		c.macro(node)
+	case *ast.Sub:
+		c.elem("sSub", func() {
+			c.elem("e", nil)
+			c.elem("sub", func() { c.node(node.Node) })
+		})
+	case *ast.Sup:
+		c.elem("sSup", func() {
+			c.elem("e", nil)
+			c.elem("sup", func() { c.node(node.Node) })
+		})
+	case *ast.Table:
+		c.table(node)
+	case *ast.Env:
+		c.env(node)
+	default:
+		c.warn(node.Pos(), "unsupported node %T", node)
+	}
+}
+
+func (c *omml) symbol(sym *ast.Symbol) {
+	switch sym.Text {
+	case "&":
+		c.warn(sym.Pos(), "alignment points are not supported")
+		return
+	}
+	c.run(string(tex2unicode.Index(sym.Text, true)))
+}
+
+func (c *omml) list(list ast.List) {
+	as := atoms(list, c.warn)
+	for i := 0; i < len(as); i++ {
+		a := as[i]
+		if m, ok := a.node.(*ast.Macro); ok && a.fence == nil && a.accent == nil {
+			if op, ok := bigops[m.Name.Name]; ok {
+				// the operand of a big operator extends up to the next relation.
+				j := i + 1
+				for j < len(as) && !isRel(as[j].node) {
+					j++
+				}
+				c.nary(a, op.char, op.limits, as[i+1:j])
+				i = j - 1
+				continue
+			}
+		}
+		c.atom(a)
+	}
+}
+
+func (c *omml) atom(a atom) {
+	base := func() {
+		switch {
+		case a.fence != nil:
+			c.fence(a.fence)
+		case a.accent != nil:
+			c.accent(a.accent.Name.Name, a.node)
+		default:
+			c.node(a.node)
+		}
+	}
+
+	if a.limits != 0 {
+		if m, ok := a.node.(*ast.Macro); !ok || !funcs[m.Name.Name] {
+			c.warn(a.node.Pos(), `%s not applied to a big operator`, limitsName(a.limits))
+		}
+	}
+
+	if m, ok := a.node.(*ast.Macro); ok && funcs[m.Name.Name] && a.sub != nil && a.limits >= 0 {
+		// function with limits, such as \lim_{x \to 0}.
+		lim := func() {
+			c.elem("limLow", func() {
+				c.elem("e", base)
+				c.elem("lim", func() { c.node(a.sub) })
+			})
+		}
+		if a.sup == nil {
+			lim()
+			return
+		}
+		c.elem("sSup", func() {
+			c.elem("e", lim)
+			c.elem("sup", func() { c.node(a.sup) })
+		})
+		return
+	}
+
+	switch {
+	case a.sub != nil && a.sup != nil:
+		c.elem("sSubSup", func() {
+			c.elem("e", base)
+			c.elem("sub", func() { c.node(a.sub) })
+			c.elem("sup", func() { c.node(a.sup) })
+		})
+	case a.sub != nil:
+		c.elem("sSub", func() {
+			c.elem("e", base)
+			c.elem("sub", func() { c.node(a.sub) })
+		})
+	case a.sup != nil:
+		c.elem("sSup", func() {
+			c.elem("e", base)
+			c.elem("sup", func() { c.node(a.sup) })
+		})
+	default:
+		base()
+	}
+}
+
+// nary writes a big operator, with its limits and operand.
+func (c *omml) nary(a atom, char rune, limits bool, operand []atom) {
+	switch a.limits {
+	case +1:
+		limits = true
+	case -1:
+		limits = false
+	}
+	c.elem("nary", func() {
+		c.elem("naryPr", func() {
+			c.prop("chr", string(char))
+			loc := "subSup"
+			if limits {
+				loc = "undOvr"
+			}
+			c.prop("limLoc", loc)
+			if a.sub == nil {
+				c.prop("subHide", "1")
+			}
+			if a.sup == nil {
+				c.prop("supHide", "1")
+			}
+		})
+		c.elem("sub", func() { c.node(a.sub) })
+		c.elem("sup", func() { c.node(a.sup) })
+		c.elem("e", func() {
+			for _, a := range operand {
+				c.atom(a)
+			}
+		})
+	})
+}
+
+func (c *omml) fence(f *fence) {
+	c.delims(f.pos, f.left, f.right, func() { c.list(f.body) })
+}
+
+// delims writes a delimiter element around the provided content.
+func (c *omml) delims(pos token.Pos, left, right string, content func()) {
+	c.elem("d", func() {
+		c.elem("dPr", func() {
+			c.prop("begChr", c.delim(pos, left))
+			c.prop("endChr", c.delim(pos, right))
+		})
+		c.elem("e", content)
+	})
+}
+
+func (c *omml) delim(pos token.Pos, delim string) string {
+	switch delim {
+	case "<":
+		return "⟨"
+	case ">":
+		return "⟩"
+	}
+	r := delimRune(delim)
+	if strings.HasPrefix(r, `\`) {
+		c.warn(pos, "unsupported delimiter %q", delim)
+		return ""
+	}
+	return r
+}
+
+func (c *omml) accent(name string, node ast.Node) {
+	c.elem("acc", func() {
+		c.elem("accPr", func() { c.prop("chr", string(accents[name].char)) })
+		c.elem("e", func() { c.node(node) })
+	})
+}
+
+// withFont writes content with the provided font.
+func (c *omml) withFont(f font, content func()) {
+	old := c.font
+	c.font = f
+	defer func() { c.font = old }()
+	content()
+}
+
+func (c *omml) macro(m *ast.Macro) {
+	name := m.Name.Name
+	switch name {
+	case `\frac`, `\dfrac`, `\tfrac`:
+		c.elem("f", func() {
+			c.elem("num", func() { c.node(m.Args[0]) })
+			c.elem("den", func() { c.node(m.Args[1]) })
+		})
+		return
+	case `\binom`:
+		c.delims(m.Pos(), "(", ")", func() {
+			c.elem("f", func() {
+				c.elem("fPr", func() { c.prop("type", "noBar") })
+				c.elem("num", func() { c.node(m.Args[0]) })
+				c.elem("den", func() { c.node(m.Args[1]) })
+			})
+		})
+		return
+	case `\sqrt`:
+		c.elem("rad", func() {
+			if len(m.Args) == 2 {
+				c.elem("deg", func() { c.node(m.Args[0]) })
+			} else {
+				c.elem("radPr", func() { c.prop("degHide", "1") })
+				c.elem("deg", nil)
+			}
+			c.elem("e", func() { c.node(m.Args[len(m.Args)-1]) })
+		})
+		return
+	case `\overline`, `\underline`:
+		pos := "top"
+		if name == `\underline` {
+			pos = "bot"
+		}
+		c.elem("bar", func() {
+			c.elem("barPr", func() { c.prop("pos", pos) })
+			c.elem("e", func() { c.node(m.Args[0]) })
+		})
+		return
+	case `\text`, `\mbox`, `\textrm`, `\textnormal`, `\textsf`, `\texttt`:
+		c.runWith(font{nor: true}, text(m, c.warn))
+		return
+	case `\textbf`:
+		c.runWith(font{nor: true, sty: "b"}, text(m, c.warn))
+		return
+	case `\textit`:
+		c.runWith(font{nor: true, sty: "i"}, text(m, c.warn))
+		return
+	case `\operatorname`:
+		c.runWith(font{sty: "p"}, text(m, c.warn))
+		return
+	case `\limits`, `\nolimits`:
+		c.warn(m.Pos(), `%s not applied to a big operator`, name)
+		return
+	case `\big`, `\Big`, `\bigg`, `\Bigg`, `\middle`:
+		// the following delimiter is converted as is.
+		return
+	}
+
+	if f, ok := ommlFonts[name]; ok {
+		c.withFont(f, func() { c.node(m.Args[0]) })
+		return
+	}
+	if op, ok := bigops[name]; ok {
+		// big operator in a script or a fence.
+		c.run(string(op.char))
+		return
+	}
+	if _, ok := funcs[name]; ok {
+		c.runWith(font{sty: "p"}, name[1:])
+		return
+	}
+	if _, ok := spaces[name]; ok {
+		if sp := ommlSpaces[name]; sp != "" {
+			c.run(sp)
+			return
+		}
+		c.warn(m.Pos(), "unsupported space %s", name)
+		return
+	}
+	if isAccent(name) {
+		// accent without operand.
+		c.accent(name, nil)
+		return
+	}
+	if r, ok := ommlSymbols[name]; ok {
+		if r != "" {
+			c.run(r)
+		}
+		return
+	}
+	if len(m.Args) == 0 && len(name) > 1 && tex2unicode.HasSymbol(name[1:]) {
+		c.run(string(tex2unicode.Index(name, true)))
+		return
+	}
+
+	c.warn(m.Pos(), "unsupported macro %s", name)
+	for _, arg := range m.Args {
+		c.node(arg)
+	}
+}
+
+func (c *omml) table(tbl *ast.Table) {
+	name := tbl.Name.Name
+	delims, ok := matrixDelims[name]
+	if !ok {
+		c.warn(tbl.Pos(), "unsupported environment %q", name)
+	}
+	if len(tbl.Lines) > 0 {
+		c.warn(tbl.Pos(), "horizontal lines of %q are not supported", name)
+	}
+
+	matrix := func() {
+		c.elem("m", func() {
+			if len(tbl.Spec) > 0 {
+				c.elem("mPr", func() {
+					c.elem("mcs", func() {
+						for _, spec := range tbl.Spec {
+							if !spec.IsColumn() {
+								c.warn(tbl.Pos(), "column specification %q of %q is not supported", spec, name)
+								continue
+							}
+							c.elem("mc", func() {
+								c.elem("mcPr", func() {
+									c.prop("count", "1")
+									jc, ok := ommlJc[spec.Kind]
+									if !ok {
+										jc = "center"
+									}
+									c.prop("mcJc", jc)
+								})
+							})
+						}
+					})
+				})
+			}
+			for _, row := range tbl.Rows {
+				c.elem("mr", func() {
+					for _, cell := range row {
+						c.elem("e", func() { c.list(cell.List) })
+					}
+				})
+			}
+		})
+	}
+
+	if delims[0] == "" && delims[1] == "" {
+		matrix()
+		return
+	}
+	c.delims(tbl.Pos(), delims[0], delims[1], matrix)
+}
+
+func (c *omml) env(env *ast.Env) {
+	switch env.Name.Name {
+	case "equation", "equation*", "displaymath", "math":
+		c.list(env.List)
+		return
+	case "align", "align*", "aligned", "gather", "gather*", "gathered",
+		"split", "multline", "multline*":
+		// one equation per line.
+		var (
+			rows []ast.List
+			row  ast.List
+		)
+		for _, node := range env.List {
+			if m, ok := node.(*ast.Macro); ok && m.Name.Name == `\\` {
+				rows = append(rows, row)
+				row = nil
+				continue
+			}
+			row = append(row, node)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		c.elem("eqArr", func() {
+			for _, row := range rows {
+				c.elem("e", func() { c.list(row) })
+			}
+		})
+		return
+	}
+	c.warn(env.Pos(), "unsupported environment %q", env.Name.Name)
+	c.list(env.List)
+}
+
+func xmlEscape(txt string) string {
+	o := new(strings.Builder)
+	for _, r := range txt {
+		switch r {
+		case '&':
+			o.WriteString("&amp;")
+		case '<':
+			o.WriteString("&lt;")
+		case '>':
+			o.WriteString("&gt;")
+		case '"':
+			o.WriteString("&quot;")
+		default:
+			o.WriteRune(r)
+		}
+	}
+	return o.String()
+}
+
+var ommlFonts = map[string]font{
+	`\mathbf`:   {sty: "b"},
+	`\mathit`:   {sty: "i"},
+	`\mathrm`:   {sty: "p"},
+	`\mathsf`:   {scr: "sans-serif", sty: "p"},
+	`\mathtt`:   {scr: "monospace", sty: "p"},
+	`\mathbb`:   {scr: "double-struck", sty: "p"},
+	`\mathcal`:  {scr: "script", sty: "p"},
+	`\mathscr`:  {scr: "script", sty: "p"},
+	`\mathfrak`: {scr: "fraktur", sty: "p"},
+}
+
+var ommlSpaces = map[string]string{
+	`\,`:     " ",
+	`\:`:     " ",
+	`\;`:     " ",
+	`\ `:     " ",
+	`\quad`:  " ",
+	`\qquad`: "  ",
+}
+
+var ommlJc = map[rune]string{
+	'l': "left",
+	'c': "center",
+	'r': "right",
+}
+
+// ommlSymbols are the characters of TeX symbols unknown to tex2unicode.
+var ommlSymbols = map[string]string{
+	`\{`:     "{",
+	`\}`:     "}",
+	`\|`:     "‖",
+	`\vert`:  "|",
+	`\Vert`:  "‖",
+	`\to`:    "→",
+	`\gets`:  "←",
+	`\colon`: ":",
+	`\\`:     "",
+}
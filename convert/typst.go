@@ -0,0 +1,488 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/internal/tex2unicode"
+	"github.com/go-latex/latex/token"
+)
+
+// Typst converts a LaTeX math expression to Typst math syntax.
+// The returned expression is not enclosed in '$' delimiters.
+func Typst(node ast.Node) (string, []Warning) {
+	var c typst
+	return strings.Join(strings.Fields(c.node(node)), " "), c.warns
+}
+
+type typst struct {
+	warns []Warning
+}
+
+func (c *typst) warn(pos token.Pos, format string, args ...interface{}) {
+	c.warns = append(c.warns, Warning{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *typst) node(node ast.Node) string {
+	switch node := node.(type) {
+	case nil:
+		return ""
+	case ast.List:
+		return c.list(node)
+	case *ast.MathExpr:
+		return c.list(node.List)
+	case *ast.Arg:
+		return c.list(node.List)
+	case *ast.OptArg:
+		return c.list(node.List)
+	case *ast.Word:
+		return c.word(node.Text)
+	case *ast.Literal:
+		return node.Text
+	case *ast.Symbol:
+		return c.symbol(node.Text)
+	case *ast.Macro:
+		return c.macro(node)
+	case *ast.Sub:
+		return `""_` + c.script(node.Node)
+	case *ast.Sup:
+		return `""^` + c.script(node.Node)
+	case *ast.Table:
+		return c.table(node)
+	case *ast.Env:
+		switch node.Name.Name {
+		case "equation", "equation*", "displaymath", "math",
+			"align", "align*", "aligned", "gather", "gather*", "gathered",
+			"split", "multline", "multline*":
+			return c.list(node.List)
+		}
+		c.warn(node.Pos(), "unsupported environment %q", node.Name.Name)
+		return c.list(node.List)
+	default:
+		c.warn(node.Pos(), "unsupported node %T", node)
+		return ""
+	}
+}
+
+func (c *typst) list(list ast.List) string {
+	var o []string
+	for _, a := range atoms(list, c.warn) {
+		o = append(o, c.atom(a))
+	}
+	return strings.Join(o, " ")
+}
+
+func (c *typst) atom(a atom) string {
+	var base string
+	switch {
+	case a.fence != nil:
+		base = c.fence(a.fence)
+	case a.accent != nil:
+		base = accents[a.accent.Name.Name].typst + "(" + c.node(a.node) + ")"
+	default:
+		base = c.node(a.node)
+	}
+
+	if m, ok := a.node.(*ast.Macro); ok && a.limits != 0 && a.fence == nil {
+		_, op := bigops[m.Name.Name]
+		_, fct := funcs[m.Name.Name]
+		switch {
+		case !op && !fct:
+			c.warn(m.Pos(), `%s not applied to a big operator`, limitsName(a.limits))
+		case a.limits > 0:
+			base = "limits(" + base + ")"
+		default:
+			base = "scripts(" + base + ")"
+		}
+	}
+
+	if a.sub != nil {
+		base += "_" + c.script(a.sub)
+	}
+	if a.sup != nil {
+		base += "^" + c.script(a.sup)
+	}
+	return base
+}
+
+func limitsName(v int) string {
+	if v > 0 {
+		return `\limits`
+	}
+	return `\nolimits`
+}
+
+// script converts a subscript or superscript, grouping it with parentheses
+// when needed.
+func (c *typst) script(node ast.Node) string {
+	return group(c.node(node))
+}
+
+// group encloses an expression in parentheses, unless it is a single
+// identifier, number or function call.
+func group(expr string) string {
+	if isTypstAtom(expr) {
+		return expr
+	}
+	return "(" + expr + ")"
+}
+
+func isTypstAtom(expr string) bool {
+	if expr == "" {
+		return false
+	}
+	i := strings.IndexFunc(expr, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.')
+	})
+	switch {
+	case i < 0:
+		return true
+	case i == 0 || expr[i] != '(' || !strings.HasSuffix(expr, ")"):
+		return false
+	}
+	// function call: the parenthesis opened at i must close at the end.
+	depth := 0
+	for j, r := range expr[i:] {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i+j == len(expr)-1
+			}
+		}
+	}
+	return false
+}
+
+// word converts the letters of a word, which are distinct variables.
+func (c *typst) word(txt string) string {
+	var o []string
+	for _, r := range txt {
+		o = append(o, string(r))
+	}
+	return strings.Join(o, " ")
+}
+
+func (c *typst) symbol(sym string) string {
+	switch sym {
+	case "/", "*", "\"", "#", "$", "_", "^", "{", "}":
+		// characters with a special meaning in Typst math.
+		return `\` + sym
+	}
+	return sym
+}
+
+func (c *typst) fence(f *fence) string {
+	var (
+		left  = c.delim(f.pos, f.left)
+		right = c.delim(f.pos, f.right)
+		body  = c.list(f.body)
+	)
+	return "lr(" + strings.TrimSpace(left+" "+body+" "+right) + ")"
+}
+
+func (c *typst) delim(pos token.Pos, delim string) string {
+	switch delim {
+	case ".", "":
+		return ""
+	case "(", ")", "[", "]", "|":
+		return delim
+	case "<":
+		return "angle.l"
+	case ">":
+		return "angle.r"
+	}
+	if v, ok := typstSymbols[delim]; ok {
+		return v
+	}
+	if r := delimRune(delim); r != delim {
+		return r
+	}
+	c.warn(pos, "unsupported delimiter %q", delim)
+	return ""
+}
+
+func (c *typst) macro(m *ast.Macro) string {
+	name := m.Name.Name
+	switch name {
+	case `\frac`, `\dfrac`, `\tfrac`:
+		return "frac(" + c.node(m.Args[0]) + ", " + c.node(m.Args[1]) + ")"
+	case `\binom`:
+		return "binom(" + c.node(m.Args[0]) + ", " + c.node(m.Args[1]) + ")"
+	case `\sqrt`:
+		if len(m.Args) == 2 {
+			return "root(" + c.node(m.Args[0]) + ", " + c.node(m.Args[1]) + ")"
+		}
+		return "sqrt(" + c.node(m.Args[0]) + ")"
+	case `\overline`:
+		return "overline(" + c.node(m.Args[0]) + ")"
+	case `\underline`:
+		return "underline(" + c.node(m.Args[0]) + ")"
+	case `\text`, `\mbox`, `\textrm`, `\textnormal`, `\textsf`, `\texttt`:
+		return typstString(text(m, c.warn))
+	case `\textbf`:
+		return "bold(" + typstString(text(m, c.warn)) + ")"
+	case `\textit`:
+		return "italic(" + typstString(text(m, c.warn)) + ")"
+	case `\operatorname`:
+		return "op(" + typstString(text(m, c.warn)) + ")"
+	case `\limits`, `\nolimits`:
+		c.warn(m.Pos(), `%s not applied to a big operator`, name)
+		return ""
+	case `\big`, `\Big`, `\bigg`, `\Bigg`, `\middle`:
+		// the following delimiter is converted as is.
+		return ""
+	}
+
+	if fct, ok := typstFonts[name]; ok {
+		return fct + "(" + c.node(m.Args[0]) + ")"
+	}
+	if op, ok := bigops[name]; ok {
+		return op.typst
+	}
+	if _, ok := funcs[name]; ok {
+		return name[1:]
+	}
+	if _, ok := spaces[name]; ok {
+		if sp := typstSpaces[name]; sp != "" {
+			return sp
+		}
+		c.warn(m.Pos(), "unsupported space %s", name)
+		return ""
+	}
+	if isAccent(name) {
+		// accent without operand.
+		return accents[name].typst + `("")`
+	}
+	if v, ok := typstSymbols[name]; ok {
+		return v
+	}
+	if greek[name[1:]] {
+		return name[1:]
+	}
+	if len(m.Args) == 0 && len(name) > 1 && tex2unicode.HasSymbol(name[1:]) {
+		return string(tex2unicode.Index(name, true))
+	}
+
+	c.warn(m.Pos(), "unsupported macro %s", name)
+	var o []string
+	for _, arg := range m.Args {
+		o = append(o, c.node(arg))
+	}
+	return strings.Join(o, " ")
+}
+
+func (c *typst) table(tbl *ast.Table) string {
+	name := tbl.Name.Name
+	delims, ok := matrixDelims[name]
+	if !ok {
+		c.warn(tbl.Pos(), "unsupported environment %q", name)
+	}
+	if len(tbl.Lines) > 0 {
+		c.warn(tbl.Pos(), "horizontal lines of %q are not supported", name)
+	}
+	for _, spec := range tbl.Spec {
+		if !spec.IsColumn() || spec.Kind != 'c' {
+			c.warn(tbl.Pos(), "column specification %q of %q is not supported", specString(tbl.Spec), name)
+			break
+		}
+	}
+
+	var rows []string
+	for _, row := range tbl.Rows {
+		var cells []string
+		for _, cell := range row {
+			cells = append(cells, c.list(cell.List))
+		}
+		switch name {
+		case "cases":
+			rows = append(rows, strings.Join(cells, " & "))
+		default:
+			rows = append(rows, strings.Join(cells, ", "))
+		}
+	}
+
+	switch name {
+	case "cases":
+		return "cases(" + strings.Join(rows, ", ") + ")"
+	}
+	delim := "#none"
+	if delims[0] != "" {
+		delim = fmt.Sprintf("%q", delims[0])
+	}
+	return "mat(delim: " + delim + ", " + strings.Join(rows, "; ") + ")"
+}
+
+func specString(spec []ast.ColSpec) string {
+	o := new(strings.Builder)
+	for _, s := range spec {
+		o.WriteString(s.String())
+	}
+	return o.String()
+}
+
+// typstString returns a Typst string literal.
+func typstString(txt string) string {
+	txt = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(txt)
+	return `"` + txt + `"`
+}
+
+var typstFonts = map[string]string{
+	`\mathbf`:   "bold",
+	`\mathit`:   "italic",
+	`\mathrm`:   "upright",
+	`\mathsf`:   "sans",
+	`\mathtt`:   "mono",
+	`\mathbb`:   "bb",
+	`\mathcal`:  "cal",
+	`\mathscr`:  "cal",
+	`\mathfrak`: "frak",
+}
+
+var typstSpaces = map[string]string{
+	`\,`:     "thin",
+	`\:`:     "med",
+	`\;`:     "thick",
+	`\ `:     "space",
+	`\quad`:  "quad",
+	`\qquad`: "wide",
+}
+
+// typstSymbols are the Typst names of TeX symbols.
+// Other symbols are converted to their unicode character.
+var typstSymbols = map[string]string{
+	// greek letters whose Typst name differs from the TeX name
+	`\epsilon`:    "epsilon.alt",
+	`\varepsilon`: "epsilon",
+	`\phi`:        "phi.alt",
+	`\varphi`:     "phi",
+	`\vartheta`:   "theta.alt",
+	`\varpi`:      "pi.alt",
+	`\varrho`:     "rho.alt",
+	`\varsigma`:   "sigma.alt",
+
+	// relations
+	`\leq`:      "<=",
+	`\le`:       "<=",
+	`\geq`:      ">=",
+	`\ge`:       ">=",
+	`\neq`:      "!=",
+	`\ne`:       "!=",
+	`\ll`:       "<<",
+	`\gg`:       ">>",
+	`\approx`:   "approx",
+	`\equiv`:    "equiv",
+	`\sim`:      "tilde.op",
+	`\simeq`:    "tilde.eq",
+	`\cong`:     "tilde.equiv",
+	`\propto`:   "prop",
+	`\in`:       "in",
+	`\notin`:    "in.not",
+	`\ni`:       "in.rev",
+	`\subset`:   "subset",
+	`\subseteq`: "subset.eq",
+	`\supset`:   "supset",
+	`\supseteq`: "supset.eq",
+	`\perp`:     "perp",
+	`\parallel`: "parallel",
+	`\mid`:      "divides",
+
+	// arrows
+	`\to`:                 "->",
+	`\rightarrow`:         "->",
+	`\leftarrow`:          "<-",
+	`\gets`:               "<-",
+	`\leftrightarrow`:     "<->",
+	`\Rightarrow`:         "=>",
+	`\Leftarrow`:          "arrow.l.double",
+	`\Leftrightarrow`:     "<=>",
+	`\mapsto`:             "|->",
+	`\longrightarrow`:     "-->",
+	`\longleftarrow`:      "<--",
+	`\Longrightarrow`:     "==>",
+	`\Longleftrightarrow`: "<==>",
+	`\implies`:            "==>",
+	`\iff`:                "<==>",
+	`\uparrow`:            "arrow.t",
+	`\downarrow`:          "arrow.b",
+
+	// operators
+	`\pm`:       "plus.minus",
+	`\mp`:       "minus.plus",
+	`\times`:    "times",
+	`\cdot`:     "dot.op",
+	`\div`:      "div",
+	`\ast`:      "ast",
+	`\star`:     "star",
+	`\circ`:     "compose",
+	`\bullet`:   "bullet",
+	`\cap`:      "sect",
+	`\cup`:      "union",
+	`\setminus`: "without",
+	`\wedge`:    "and",
+	`\land`:     "and",
+	`\vee`:      "or",
+	`\lor`:      "or",
+	`\oplus`:    "plus.circle",
+	`\otimes`:   "times.circle",
+	`\odot`:     "dot.circle",
+
+	// miscellaneous
+	`\infty`:      "oo",
+	`\partial`:    "diff",
+	`\nabla`:      "nabla",
+	`\forall`:     "forall",
+	`\exists`:     "exists",
+	`\neg`:        "not",
+	`\lnot`:       "not",
+	`\emptyset`:   "emptyset",
+	`\varnothing`: "nothing",
+	`\hbar`:       "planck.reduce",
+	`\ell`:        "ell",
+	`\prime`:      "prime",
+	`\aleph`:      "aleph",
+	`\ldots`:      "dots.h",
+	`\cdots`:      "dots.h.c",
+	`\dots`:       "dots",
+	`\vdots`:      "dots.v",
+	`\ddots`:      "dots.down",
+	`\colon`:      "colon",
+
+	// delimiters
+	`\{`:         `\{`,
+	`\}`:         `\}`,
+	`\|`:         "||",
+	`\vert`:      "|",
+	`\Vert`:      "||",
+	`\langle`:    "angle.l",
+	`\rangle`:    "angle.r",
+	`\lfloor`:    "floor.l",
+	`\rfloor`:    "floor.r",
+	`\lceil`:     "ceil.l",
+	`\rceil`:     "ceil.r",
+	`\backslash`: "backslash",
+	`\\`:         `\`,
+}
+
+// greek are the greek letters with the same name in TeX and Typst.
+var greek = map[string]bool{
+	"alpha": true, "beta": true, "gamma": true, "delta": true, "zeta": true,
+	"eta": true, "theta": true, "iota": true, "kappa": true, "lambda": true,
+	"mu": true, "nu": true, "xi": true, "omicron": true, "pi": true,
+	"rho": true, "sigma": true, "tau": true, "upsilon": true, "chi": true,
+	"psi": true, "omega": true,
+
+	"Alpha": true, "Beta": true, "Gamma": true, "Delta": true, "Epsilon": true,
+	"Zeta": true, "Eta": true, "Theta": true, "Iota": true, "Kappa": true,
+	"Lambda": true, "Mu": true, "Nu": true, "Xi": true, "Omicron": true,
+	"Pi": true, "Rho": true, "Sigma": true, "Tau": true, "Upsilon": true,
+	"Phi": true, "Chi": true, "Psi": true, "Omega": true,
+}
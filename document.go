@@ -0,0 +1,290 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package latex
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/token"
+)
+
+// ParseDocument parses a full LaTeX document.
+//
+// The preamble is scanned for the document class, the loaded packages and
+// the macro definitions.
+// The content of the document environment is organized into a hierarchy of
+// sections.
+// Unknown macros are accepted and parsed without arguments.
+func ParseDocument(x string) (*ast.Document, error) {
+	p := newParser(x)
+	return p.parseDocument()
+}
+
+// ParseDocumentFS parses the full LaTeX document held in the named file
+// of fsys, like ParseDocument.
+//
+// Files included with \input, \include and \subfile are resolved through
+// fsys, relative to the directory of the main document, and parsed in place.
+// Each parsed file is added to fset, so positions of the returned nodes can
+// be located in their source file.
+func ParseDocumentFS(fset *token.FileSet, fsys fs.FS, name string) (*ast.Document, error) {
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("latex: could not read document %q: %w", name, err)
+	}
+
+	f := fset.AddFile(name, -1, len(src))
+	f.SetLinesForContent(src)
+
+	p := newParser("")
+	p.fset = fset
+	p.fsys = fsys
+	p.dir = path.Dir(name)
+	p.s = newScanner(bytes.NewReader(src))
+	p.s.sc.Filename = name
+	p.s.name = name
+	p.s.base = f.Base()
+
+	return p.parseDocument()
+}
+
+func (p *parser) parseDocument() (doc *ast.Document, err error) {
+	p.lax = true
+
+	defer func() {
+		e := recover()
+		if e == nil {
+			return
+		}
+		doc = nil
+		err = &Error{Pos: p.position(), Msg: fmt.Sprint(e)}
+	}()
+
+	nodes, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return newDocument(nodes.(ast.List)), nil
+}
+
+// Error is a syntax error of a LaTeX document.
+type Error struct {
+	Pos token.Position // position of the error
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("latex: could not parse document (%v): %s", e.Pos, e.Msg)
+}
+
+// position returns the position of the current token.
+func (p *parser) position() token.Position {
+	if p.fset == nil {
+		pos := p.s.sc.Position
+		return token.Position{
+			Filename: pos.Filename,
+			Offset:   pos.Offset,
+			Line:     pos.Line,
+			Column:   pos.Column,
+		}
+	}
+	return p.fset.Position(p.s.tok.Pos)
+}
+
+func newDocument(nodes ast.List) *ast.Document {
+	doc := new(ast.Document)
+	for _, node := range nodes {
+		if env, ok := node.(*ast.Env); ok && env.Name.Name == "document" {
+			doc.Body = &ast.Env{
+				Left:  env.Left,
+				Name:  env.Name,
+				Args:  env.Args,
+				List:  sections(env.List),
+				Right: env.Right,
+			}
+			// anything after \end{document} is ignored.
+			break
+		}
+		doc.Preamble = append(doc.Preamble, node)
+
+		macro, ok := node.(*ast.Macro)
+		if !ok {
+			continue
+		}
+		switch macro.Name.Name {
+		case `\documentclass`:
+			opts, names := pkgArgs(macro)
+			doc.Class = &ast.DocumentClass{
+				Name:    strings.Join(names, ","),
+				Options: opts,
+				Macro:   macro,
+			}
+		case `\usepackage`, `\RequirePackage`:
+			opts, names := pkgArgs(macro)
+			for _, name := range names {
+				doc.Packages = append(doc.Packages, &ast.Package{
+					Name:    name,
+					Options: opts,
+					Macro:   macro,
+				})
+			}
+		default:
+			if _, ok := builtinDefs[strings.TrimSuffix(macro.Name.Name, "*")]; ok {
+				doc.Defs = append(doc.Defs, macro)
+			}
+		}
+	}
+	return doc
+}
+
+var builtinDefs = map[string]struct{}{
+	`\newcommand`:          {},
+	`\renewcommand`:        {},
+	`\providecommand`:      {},
+	`\DeclareMathOperator`: {},
+	`\def`:                 {},
+	`\gdef`:                {},
+	`\edef`:                {},
+	`\xdef`:                {},
+	`\newenvironment`:      {},
+	`\renewenvironment`:    {},
+}
+
+// pkgArgs returns the options and the names of a \documentclass or
+// \usepackage command.
+func pkgArgs(macro *ast.Macro) (opts, names []string) {
+	for _, arg := range macro.Args {
+		switch arg := arg.(type) {
+		case *ast.OptArg:
+			opts = splitList(verbatimText(arg.List))
+		case *ast.Arg:
+			names = splitList(verbatimText(arg.List))
+		}
+	}
+	return opts, names
+}
+
+func verbatimText(nodes ast.List) string {
+	if len(nodes) != 1 {
+		return ""
+	}
+	w, ok := nodes[0].(*ast.Word)
+	if !ok {
+		return ""
+	}
+	return w.Text
+}
+
+// splitList splits a comma-separated list of values, dropping empty ones.
+func splitList(v string) []string {
+	var o []string
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		o = append(o, s)
+	}
+	return o
+}
+
+// sectionLevels holds the level of the LaTeX sectioning commands.
+var sectionLevels = map[string]int{
+	`\part`:          -1,
+	`\chapter`:       0,
+	`\section`:       1,
+	`\subsection`:    2,
+	`\subsubsection`: 3,
+	`\paragraph`:     4,
+	`\subparagraph`:  5,
+}
+
+// sections organizes a list of nodes into a hierarchy of sections.
+func sections(nodes ast.List) ast.List {
+	var (
+		root  ast.List
+		stack []*ast.Section
+	)
+	for _, node := range nodes {
+		macro, ok := node.(*ast.Macro)
+		if ok {
+			name := strings.TrimSuffix(macro.Name.Name, "*")
+			if lvl, ok := sectionLevels[name]; ok {
+				sec := newSection(macro, lvl)
+				for len(stack) > 0 && stack[len(stack)-1].Level >= lvl {
+					stack = stack[:len(stack)-1]
+				}
+				switch len(stack) {
+				case 0:
+					root = append(root, sec)
+				default:
+					top := stack[len(stack)-1]
+					top.List = append(top.List, sec)
+				}
+				stack = append(stack, sec)
+				continue
+			}
+		}
+
+		if len(stack) == 0 {
+			root = append(root, node)
+			continue
+		}
+
+		sec := stack[len(stack)-1]
+		if ok && macro.Name.Name == `\label` && sec.Label == "" && isBlank(sec.List) {
+			sec.Label = labelOf(macro)
+		}
+		sec.List = append(sec.List, node)
+	}
+	return root
+}
+
+func newSection(macro *ast.Macro, lvl int) *ast.Section {
+	sec := &ast.Section{
+		Macro: macro,
+		Level: lvl,
+	}
+	if n := len(macro.Args); n > 0 {
+		if arg, ok := macro.Args[n-1].(*ast.Arg); ok {
+			sec.Title = arg.List
+		}
+	}
+	for _, node := range sec.Title {
+		if m, ok := node.(*ast.Macro); ok && m.Name.Name == `\label` {
+			sec.Label = labelOf(m)
+			break
+		}
+	}
+	return sec
+}
+
+func labelOf(macro *ast.Macro) string {
+	if len(macro.Args) != 1 {
+		return ""
+	}
+	arg, ok := macro.Args[0].(*ast.Arg)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(verbatimText(arg.List))
+}
+
+// isBlank returns whether the list only contains spaces.
+func isBlank(nodes ast.List) bool {
+	for _, node := range nodes {
+		sym, ok := node.(*ast.Symbol)
+		if !ok || strings.TrimSpace(sym.Text) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package latex
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex/ast"
+)
+
+func TestParseDocument(t *testing.T) {
+	const src = `\documentclass[a4paper, 12pt]{article}
+\usepackage[utf8]{inputenc}
+\usepackage{amsmath,amssymb}
+\newcommand{\R}{\mathbb{R}}
+\newcommand\norm[2][2]{\|#2\|_{#1}}
+\def\half#1{\frac{#1}{2}}
+\begin{document}
+\maketitle
+\section{Intro}\label{sec:intro}
+Hello $\R \norm{x} \half{y}$.
+\subsection*{Sub}
+\begin{verbatim}a $ \foo{\end{verbatim}
+\section{Two \label{sec:two}}
+\end{document}
+`
+
+	doc, err := ParseDocument(src)
+	if err != nil {
+		t.Fatalf("could not parse document: %+v", err)
+	}
+
+	if got, want := doc.Class.Name, "article"; got != want {
+		t.Fatalf("invalid document class: got=%q, want=%q", got, want)
+	}
+	if got, want := doc.Class.Options, []string{"a4paper", "12pt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid document class options: got=%q, want=%q", got, want)
+	}
+
+	var pkgs []string
+	for _, pkg := range doc.Packages {
+		pkgs = append(pkgs, pkg.Name+"["+strings.Join(pkg.Options, ",")+"]")
+	}
+	if got, want := pkgs, []string{"inputenc[utf8]", "amsmath[]", "amssymb[]"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid packages: got=%q, want=%q", got, want)
+	}
+
+	var defs []string
+	for _, def := range doc.Defs {
+		defs = append(defs, def.Name.Name)
+	}
+	if got, want := defs, []string{`\newcommand`, `\newcommand`, `\def`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid definitions: got=%q, want=%q", got, want)
+	}
+
+	o := new(strings.Builder)
+	ast.Print(o, doc.Body)
+	want := `ast.Env{"document", List:` +
+		`ast.Macro{"\\maketitle"}, ` +
+		`ast.Section{"\\section", Level:1, Title:ast.List{ast.Word{"Intro"}}, Label:"sec:intro", List:` +
+		`ast.Macro{"\\label", Args:{ast.Word{"sec:intro"}}}, ` +
+		`ast.Word{"Hello"}, ast.Symbol{" "}, ` +
+		`ast.MathExpr{List:ast.Macro{"\\R"}, ast.Macro{"\\norm", Args:{ast.Word{"x"}}}, ast.Macro{"\\half", Args:{ast.Word{"y"}}}}, ` +
+		`ast.Symbol{"."}, ` +
+		`ast.Section{"\\subsection*", Level:2, Title:ast.List{ast.Word{"Sub"}}, List:` +
+		`ast.Env{"verbatim", List:ast.Word{"a $ \\foo{"}}}}, ` +
+		`ast.Section{"\\section", Level:1, Title:ast.List{ast.Word{"Two"}, ast.Symbol{" "}, ast.Macro{"\\label", Args:{ast.Word{"sec:two"}}}}, Label:"sec:two"}}`
+	if got := o.String(); got != want {
+		t.Fatalf("invalid document body:\ngot= %s\nwant=%s", got, want)
+	}
+}
+
+func TestParseDefinitions(t *testing.T) {
+	for _, input := range []string{
+		`\newcommand{\R}{x}`,
+		`\newcommand{ \R }{x}`,
+		`\newcommand{\R} {x}`,
+		`\newcommand\R {x}`,
+		`\newcommand{\R} [1] [y] {x#1}`,
+		`\renewcommand*{\R}[1]{x#1}`,
+		`\def\R #1{x#1}`,
+		`\DeclareMathOperator {\R} {x}`,
+		`\newenvironment {R} [1] {x#1} {y}`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			doc, err := ParseDocument(input)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", input, err)
+			}
+			if got, want := len(doc.Defs), 1; got != want {
+				t.Fatalf("invalid number of definitions: got=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestParseDocumentError(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `\begin{document}\begin{itemize}\end{document}`,
+			want:  `\begin{itemize} ended by \end{document}`,
+		},
+		{
+			input: `\begin{document}`,
+			want:  `missing \end{document}`,
+		},
+		{
+			input: `\end{document}`,
+			want:  `unexpected \end{document}`,
+		},
+		{
+			input: `\newcommand{x}{y}`,
+			want:  `invalid macro name "x"`,
+		},
+		{
+			input: `\newcommand{\R x}{y}`,
+			want:  `expected '}', got "x"`,
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseDocument(tc.input)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; !strings.Contains(got, want) {
+				t.Fatalf("invalid error:\ngot= %v\nwant=%v", got, want)
+			}
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("invalid error type %T", err)
+			}
+			if got, want := perr.Msg, tc.want; got != want {
+				t.Fatalf("invalid error message:\ngot= %v\nwant=%v", got, want)
+			}
+		})
+	}
+}
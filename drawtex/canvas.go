@@ -6,24 +6,48 @@
 package drawtex // import "github.com/go-latex/latex/drawtex"
 
 import (
+	"image/color"
+
 	"github.com/go-latex/latex/font"
 	"golang.org/x/image/font/sfnt"
 )
 
 type Canvas struct {
-	ops []Op
+	ops   []Op
+	color color.Color // color of the next ops
 }
 
 func New() *Canvas {
 	return &Canvas{}
 }
 
+// SetColor sets the color of the glyphs and rectangles rendered next.
+// A nil color is black.
+func (c *Canvas) SetColor(col color.Color) {
+	c.color = col
+}
+
 func (c *Canvas) RenderGlyph(x, y float64, infos Glyph) {
-	c.ops = append(c.ops, GlyphOp{x, y, infos})
+	c.ops = append(c.ops, GlyphOp{X: x, Y: y, Glyph: infos, Color: c.col()})
 }
 
 func (c *Canvas) RenderRectFilled(x1, y1, x2, y2 float64) {
-	c.ops = append(c.ops, RectOp{x1, y1, x2, y2})
+	c.ops = append(c.ops, RectOp{X1: x1, Y1: y1, X2: x2, Y2: y2, Color: c.col()})
+}
+
+func (c *Canvas) RenderRectStroked(x1, y1, x2, y2, width float64) {
+	c.ops = append(c.ops, StrokedRectOp{X1: x1, Y1: y1, X2: x2, Y2: y2, Width: width, Color: c.col()})
+}
+
+func (c *Canvas) RenderLine(x1, y1, x2, y2, width float64) {
+	c.ops = append(c.ops, LineOp{X1: x1, Y1: y1, X2: x2, Y2: y2, Width: width, Color: c.col()})
+}
+
+func (c *Canvas) col() color.Color {
+	if c.color == nil {
+		return color.Black
+	}
+	return c.color
 }
 
 func (c *Canvas) Ops() []Op { return c.ops }
@@ -35,6 +59,7 @@ type Op interface {
 type GlyphOp struct {
 	X, Y  float64
 	Glyph Glyph
+	Color color.Color // Color of the glyph.
 }
 
 func (GlyphOp) isOp() {}
@@ -42,10 +67,32 @@ func (GlyphOp) isOp() {}
 type RectOp struct {
 	X1, Y1 float64
 	X2, Y2 float64
+	Color  color.Color // Color of the filled rectangle.
 }
 
 func (RectOp) isOp() {}
 
+// StrokedRectOp is the outline of a rectangle, stroked with a line
+// centered on its edges.
+type StrokedRectOp struct {
+	X1, Y1 float64
+	X2, Y2 float64
+	Width  float64     // Width of the line.
+	Color  color.Color // Color of the line.
+}
+
+func (StrokedRectOp) isOp() {}
+
+// LineOp is a straight line from (X1,Y1) to (X2,Y2).
+type LineOp struct {
+	X1, Y1 float64
+	X2, Y2 float64
+	Width  float64     // Width of the line.
+	Color  color.Color // Color of the line.
+}
+
+func (LineOp) isOp() {}
+
 type Glyph struct {
 	Font       *sfnt.Font
 	Size       float64
@@ -59,4 +106,6 @@ type Glyph struct {
 var (
 	_ Op = (*GlyphOp)(nil)
 	_ Op = (*RectOp)(nil)
+	_ Op = (*StrokedRectOp)(nil)
+	_ Op = (*LineOp)(nil)
 )
@@ -0,0 +1,49 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package drawimg
+
+import (
+	"image/color"
+	"testing"
+
+	"git.sr.ht/~sbinet/gg"
+	"github.com/go-latex/latex/drawtex"
+)
+
+func TestNilColor(t *testing.T) {
+	const dpi = 72
+	for _, tc := range []struct {
+		name string
+		draw func(ctx *gg.Context)
+	}{
+		{
+			name: "rect",
+			draw: func(ctx *gg.Context) {
+				drawRect(ctx, dpi, drawtex.RectOp{X1: 2, Y1: 2, X2: 8, Y2: 8})
+			},
+		},
+		{
+			name: "stroked-rect",
+			draw: func(ctx *gg.Context) {
+				drawStrokedRect(ctx, dpi, drawtex.StrokedRectOp{X1: 2, Y1: 2, X2: 8, Y2: 8, Width: 2})
+			},
+		},
+		{
+			name: "line",
+			draw: func(ctx *gg.Context) {
+				drawLine(ctx, dpi, drawtex.LineOp{X1: 0, Y1: 2, X2: 10, Y2: 2, Width: 2})
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := gg.NewContext(10, 10)
+			tc.draw(ctx)
+			got := color.NRGBAModel.Convert(ctx.Image().At(5, 2)).(color.NRGBA)
+			if want := (color.NRGBA{A: 0xff}); got != want {
+				t.Fatalf("invalid color: got=%v, want=%v", got, want)
+			}
+		})
+	}
+}
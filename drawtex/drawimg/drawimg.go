@@ -41,14 +41,16 @@ func (r *Renderer) Render(width, height, dpi float64, c *drawtex.Canvas) error {
 		draw.Draw(ctx.Image().(draw.Image), ctx.Image().Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
 	}
 
-	ctx.SetColor(color.Black)
-
 	for _, op := range c.Ops() {
 		switch op := op.(type) {
 		case drawtex.GlyphOp:
 			drawGlyph(ctx, dpi, op)
 		case drawtex.RectOp:
 			drawRect(ctx, dpi, op)
+		case drawtex.StrokedRectOp:
+			drawStrokedRect(ctx, dpi, op)
+		case drawtex.LineOp:
+			drawLine(ctx, dpi, op)
 		default:
 			panic(fmt.Errorf("unknown drawtex op %T", op))
 		}
@@ -70,6 +72,7 @@ func drawGlyph(ctx *gg.Context, dpi float64, op drawtex.GlyphOp) {
 	}
 	defer face.Close()
 	ctx.SetFontFace(face)
+	ctx.SetColor(colorOf(op.Color))
 
 	dpi /= 72
 
@@ -84,6 +87,7 @@ func drawGlyph(ctx *gg.Context, dpi float64, op drawtex.GlyphOp) {
 
 func drawRect(ctx *gg.Context, dpi float64, op drawtex.RectOp) {
 	dpi /= 72
+	ctx.SetColor(colorOf(op.Color))
 	ctx.NewSubPath()
 	ctx.MoveTo(op.X1*dpi, op.Y1*dpi)
 	ctx.LineTo(op.X2*dpi, op.Y1*dpi)
@@ -95,6 +99,30 @@ func drawRect(ctx *gg.Context, dpi float64, op drawtex.RectOp) {
 	//	log.Printf("draw-rect: pt1=(%g, %g) -> (%g, %g)", op.X1, op.Y1, op.X2, op.Y2)
 }
 
+func drawStrokedRect(ctx *gg.Context, dpi float64, op drawtex.StrokedRectOp) {
+	dpi /= 72
+	ctx.SetColor(colorOf(op.Color))
+	ctx.SetLineWidth(op.Width * dpi)
+	ctx.DrawRectangle(op.X1*dpi, op.Y1*dpi, (op.X2-op.X1)*dpi, (op.Y2-op.Y1)*dpi)
+	ctx.Stroke()
+}
+
+func drawLine(ctx *gg.Context, dpi float64, op drawtex.LineOp) {
+	dpi /= 72
+	ctx.SetColor(colorOf(op.Color))
+	ctx.SetLineWidth(op.Width * dpi)
+	ctx.DrawLine(op.X1*dpi, op.Y1*dpi, op.X2*dpi, op.Y2*dpi)
+	ctx.Stroke()
+}
+
+// colorOf returns the color to draw with: a nil color is black.
+func colorOf(c color.Color) color.Color {
+	if c == nil {
+		return color.Black
+	}
+	return c
+}
+
 var (
 	_ mtex.Renderer = (*Renderer)(nil)
 )
@@ -6,6 +6,7 @@
 package drawpdf // import "github.com/go-latex/latex/drawtex/drawpdf"
 
 import (
+	"image/color"
 	"log"
 
 	"github.com/go-latex/latex/drawtex"
@@ -27,6 +28,10 @@ func Write(fname string, w, h float64, c *drawtex.Canvas) error {
 		case drawtex.RectOp:
 			log.Printf(">>> %T: %#v", op, op)
 			drawRect(doc, op)
+		case drawtex.StrokedRectOp:
+			drawStrokedRect(doc, op)
+		case drawtex.LineOp:
+			drawLine(doc, op)
 		default:
 			log.Panicf("unknown drawtex op %T", op)
 		}
@@ -34,5 +39,32 @@ func Write(fname string, w, h float64, c *drawtex.Canvas) error {
 	return doc.OutputFileAndClose(fname)
 }
 
-func drawGlyph(doc *pdf.Fpdf, op drawtex.GlyphOp) {}
-func drawRect(doc *pdf.Fpdf, op drawtex.RectOp)   {}
+func drawGlyph(doc *pdf.Fpdf, op drawtex.GlyphOp) {
+	doc.SetTextColor(rgb(op.Color))
+}
+
+func drawRect(doc *pdf.Fpdf, op drawtex.RectOp) {
+	doc.SetFillColor(rgb(op.Color))
+	doc.Rect(op.X1, op.Y1, op.X2-op.X1, op.Y2-op.Y1, "F")
+}
+
+func drawStrokedRect(doc *pdf.Fpdf, op drawtex.StrokedRectOp) {
+	doc.SetDrawColor(rgb(op.Color))
+	doc.SetLineWidth(op.Width)
+	doc.Rect(op.X1, op.Y1, op.X2-op.X1, op.Y2-op.Y1, "D")
+}
+
+func drawLine(doc *pdf.Fpdf, op drawtex.LineOp) {
+	doc.SetDrawColor(rgb(op.Color))
+	doc.SetLineWidth(op.Width)
+	doc.Line(op.X1, op.Y1, op.X2, op.Y2)
+}
+
+// rgb returns the 8-bit components of a color, black when nil.
+func rgb(c color.Color) (r, g, b int) {
+	if c == nil {
+		return 0, 0, 0
+	}
+	v := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return int(v.R), int(v.G), int(v.B)
+}
@@ -5,6 +5,8 @@
 // Package font holds types to handle and abstract away font management.
 package font
 
+import "image/color"
+
 // Font represents a font.
 type Font struct {
 	Name string  // Name is the LaTeX name of the font (regular, default, it, ...)
@@ -17,9 +19,20 @@ type Backend interface {
 	// RenderGlyphs renders the glyph g at the reference point (x,y).
 	RenderGlyph(x, y float64, font Font, symbol string, dpi float64)
 
-	// RenderRectFilled draws a filled black rectangle from (x1,y1) to (x2,y2).
+	// RenderRectFilled draws a filled rectangle from (x1,y1) to (x2,y2).
 	RenderRectFilled(x1, y1, x2, y2 float64)
 
+	// RenderRectStroked draws the outline of the rectangle from (x1,y1) to
+	// (x2,y2), with a line of the provided width centered on its edges.
+	RenderRectStroked(x1, y1, x2, y2, width float64)
+
+	// RenderLine draws a line of the provided width from (x1,y1) to (x2,y2).
+	RenderLine(x1, y1, x2, y2, width float64)
+
+	// SetColor sets the color of the glyphs and rectangles rendered next.
+	// A nil color is black.
+	SetColor(c color.Color)
+
 	// Kern returns the kerning distance between two symbols.
 	Kern(ft1 Font, sym1 string, ft2 Font, sym2 string, dpi float64) float64
 
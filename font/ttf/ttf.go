@@ -8,6 +8,8 @@ package ttf // import "github.com/go-latex/latex/font/ttf"
 import (
 	"errors"
 	"fmt"
+	"image/color"
+	"math"
 	"unicode"
 
 	"github.com/go-latex/latex/drawtex"
@@ -30,6 +32,17 @@ type Fonts struct {
 	It   *sfnt.Font
 	Bf   *sfnt.Font
 	BfIt *sfnt.Font
+
+	// Fonts of the math alphabets, such as \mathbb or \mathfrak.
+	// They are used for the characters the Rm font has no glyph for in the
+	// Mathematical Alphanumeric Symbols block.
+	// The Rm font is used in their stead when they are nil.
+	Sf   *sfnt.Font
+	Tt   *sfnt.Font
+	Cal  *sfnt.Font
+	Scr  *sfnt.Font
+	Frak *sfnt.Font
+	Bb   *sfnt.Font
 }
 
 type Backend struct {
@@ -55,6 +68,19 @@ func NewFrom(cnv *drawtex.Canvas, fnts *Fonts) *Backend {
 	be.fonts["it"] = fnts.It
 	be.fonts["bf"] = fnts.Bf
 
+	for k, ft := range map[string]*sfnt.Font{
+		"sf":   fnts.Sf,
+		"tt":   fnts.Tt,
+		"cal":  fnts.Cal,
+		"scr":  fnts.Scr,
+		"frak": fnts.Frak,
+		"bb":   fnts.Bb,
+	} {
+		if ft != nil {
+			be.fonts[k] = ft
+		}
+	}
+
 	return be
 }
 
@@ -72,11 +98,27 @@ func (be *Backend) RenderGlyph(x, y float64, font font.Font, symbol string, dpi
 	})
 }
 
-// RenderRectFilled draws a filled black rectangle from (x1,y1) to (x2,y2).
+// RenderRectFilled draws a filled rectangle from (x1,y1) to (x2,y2).
 func (be *Backend) RenderRectFilled(x1, y1, x2, y2 float64) {
 	be.canvas.RenderRectFilled(x1, y1, x2, y2)
 }
 
+// RenderRectStroked draws the outline of the rectangle from (x1,y1) to
+// (x2,y2), with a line of the provided width centered on its edges.
+func (be *Backend) RenderRectStroked(x1, y1, x2, y2, width float64) {
+	be.canvas.RenderRectStroked(x1, y1, x2, y2, width)
+}
+
+// RenderLine draws a line of the provided width from (x1,y1) to (x2,y2).
+func (be *Backend) RenderLine(x1, y1, x2, y2, width float64) {
+	be.canvas.RenderLine(x1, y1, x2, y2, width)
+}
+
+// SetColor sets the color of the glyphs and rectangles rendered next.
+func (be *Backend) SetColor(c color.Color) {
+	be.canvas.SetColor(c)
+}
+
 // Metrics returns the metrics.
 func (be *Backend) Metrics(symbol string, fnt font.Font, dpi float64, math bool) font.Metrics {
 	return be.getInfo(symbol, fnt, dpi, math).metrics
@@ -188,7 +230,10 @@ func (be *Backend) XHeight(fnt font.Font, dpi float64) float64 {
 	}
 	defer face.Close()
 
-	return float64(-face.Metrics().XHeight) / 64
+	// the x-height is derived from the bounds of the 'x' glyph, in a
+	// downward y-axis, for fonts without that information in their OS/2
+	// table: ensure it is positive for all fonts.
+	return math.Abs(float64(face.Metrics().XHeight)) / 64
 }
 
 const (
@@ -202,6 +247,20 @@ func (be *Backend) getGlyph(symbol string, font font.Font, math bool) (*sfnt.Fon
 		idx      = tex2unicode.Index(symbol, math)
 	)
 
+	// math alphabets, such as \mathbb, use the Mathematical Alphanumeric
+	// Symbols block when the font has such glyphs, and the font of their
+	// family otherwise, or the Rm font for text, as in \textsf.
+	// the "it" alphabet is the default math font: it is left to the It font.
+	if fontType != "it" && tex2unicode.IsMathAlphabet(fontType) {
+		rm := be.getFont("rm")
+		if r, ok := tex2unicode.MathAlphanumeric(idx, fontType); ok && math && hasGlyph(rm, r) {
+			return rm, r, symbol, font.Size, false
+		}
+		if be.getFont(fontType) == nil {
+			fontType = "rm"
+		}
+	}
+
 	// only characters in the "Letter" class should be italicized in "it" mode.
 	// Greek capital letters should be roman.
 	if font.Type == "it" && idx < 0x10000 {
@@ -222,6 +281,16 @@ func (be *Backend) getGlyph(symbol string, font font.Font, math bool) (*sfnt.Fon
 	return ft, idx, symbolName, font.Size, slanted
 }
 
+// hasGlyph returns whether the font has a glyph for the rune.
+func hasGlyph(ft *sfnt.Font, r rune) bool {
+	if ft == nil {
+		return false
+	}
+	var buf sfnt.Buffer
+	idx, err := ft.GlyphIndex(&buf, r)
+	return err == nil && idx != 0
+}
+
 func (*Backend) isSlanted(symbol string) bool {
 	switch symbol {
 	case `\int`, `\oint`:
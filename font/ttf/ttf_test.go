@@ -103,3 +103,57 @@ func TestGofontBackend(t *testing.T) {
 		}
 	}
 }
+
+func TestMathAlphabets(t *testing.T) {
+	var (
+		dejavu = newBackend()
+		gofont = New(nil)
+	)
+	for _, tc := range []struct {
+		name string
+		be   *Backend
+		sym  string
+		typ  string
+		want rune
+		font *sfnt.Font
+	}{
+		{name: "dejavu-bb", be: dejavu, sym: "R", typ: "bb", want: 'ℝ', font: dejavu.fonts["rm"]},
+		{name: "dejavu-sf", be: dejavu, sym: "a", typ: "sf", want: '𝖺', font: dejavu.fonts["rm"]},
+		{name: "dejavu-frak", be: dejavu, sym: "g", typ: "frak", want: 'g', font: dejavu.fonts["rm"]},
+		{name: "dejavu-it", be: dejavu, sym: "x", typ: "it", want: 'x', font: dejavu.fonts["it"]},
+		{name: "gofont-bf", be: gofont, sym: "x", typ: "bf", want: 'x', font: gofont.fonts["bf"]},
+		{name: "gofont-bb", be: gofont, sym: "R", typ: "bb", want: 'R', font: gofont.fonts["rm"]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fnt := font.Font{Name: "default", Size: 12, Type: tc.typ}
+			ft, r, _, _, _ := tc.be.getGlyph(tc.sym, fnt, true)
+			if r != tc.want {
+				t.Fatalf("invalid rune: got=%q, want=%q", r, tc.want)
+			}
+			if ft != tc.font {
+				t.Fatalf("invalid font")
+			}
+		})
+	}
+}
+
+func TestXHeight(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		be   *Backend
+		want float64
+	}{
+		// the Go fonts provide the x-height in their OS/2 table.
+		{name: "gofont", be: New(nil), want: 6.359375},
+		// DejaVu Sans does not: it is derived from the 'x' glyph.
+		{name: "dejavu", be: newBackend(), want: 6.5625},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fnt := font.Font{Name: "default", Size: 12, Type: "rm"}
+			got := tc.be.XHeight(fnt, 72)
+			if got != tc.want {
+				t.Fatalf("invalid x-height: got=%g, want=%g", got, tc.want)
+			}
+		})
+	}
+}
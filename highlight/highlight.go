@@ -0,0 +1,360 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package highlight performs syntax highlighting of LaTeX source, as HTML
+// markup or ANSI terminal colors.
+//
+// Tokens are classified by their token.Kind and by their role in the
+// document: math delimiters, environment names and macros unknown to the
+// latex package are highlighted differently from the surrounding source.
+// Highlighting does not parse the source: it works on incomplete or
+// invalid documents.
+package highlight // import "github.com/go-latex/latex/highlight"
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/go-latex/latex"
+	"github.com/go-latex/latex/internal/tex2unicode"
+	"github.com/go-latex/latex/token"
+)
+
+// Class is the highlighting class of a token.
+type Class int
+
+const (
+	Plain    Class = iota // whitespace and unclassified text
+	Word                  // words
+	Number                // numbers
+	Symbol                // symbols and punctuation
+	Brace                 // '{' and '}'
+	Bracket               // '[', ']', '(' and ')'
+	Comment               // comments
+	Macro                 // known macros
+	Unknown               // unknown macros
+	Delim                 // math delimiters
+	Env                   // environment names
+	Verbatim              // verbatim text
+)
+
+var classNames = [...]string{
+	Plain:    "plain",
+	Word:     "word",
+	Number:   "number",
+	Symbol:   "symbol",
+	Brace:    "brace",
+	Bracket:  "bracket",
+	Comment:  "comment",
+	Macro:    "macro",
+	Unknown:  "unknown",
+	Delim:    "delim",
+	Env:      "env",
+	Verbatim: "verbatim",
+}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return fmt.Sprintf("Class(%d)", int(c))
+	}
+	return classNames[c]
+}
+
+// Span is a classified token.
+type Span struct {
+	token.Token
+	Class Class
+	Math  bool // whether the token is part of a math expression
+}
+
+// Highlighter highlights LaTeX source.
+type Highlighter struct {
+	// Prefix is prepended to the HTML class names.
+	Prefix string
+
+	// Colors holds the ANSI SGR parameters of each class.
+	// Classes without parameters are not colored.
+	Colors map[Class]string
+
+	// Macros holds additional known macros, on top of the macros of the
+	// latex package, the math symbols and the macros defined by the
+	// highlighted source.
+	Macros map[string]bool
+}
+
+// New returns a new highlighter with the default HTML class prefix
+// and ANSI colors.
+func New() *Highlighter {
+	return &Highlighter{
+		Prefix: "tex-",
+		Colors: map[Class]string{
+			Number:   "36",
+			Comment:  "3;90",
+			Macro:    "34",
+			Unknown:  "1;31",
+			Delim:    "1;35",
+			Env:      "32",
+			Verbatim: "33",
+		},
+		Macros: make(map[string]bool),
+	}
+}
+
+// Spans returns the classified tokens of src, using the default highlighter.
+func Spans(src string) []Span {
+	return New().Spans(src)
+}
+
+// HTML writes src to w as HTML, using the default highlighter.
+func HTML(w io.Writer, src string) error {
+	return New().HTML(w, src)
+}
+
+// ANSI writes src to w with ANSI colors, using the default highlighter.
+func ANSI(w io.Writer, src string) error {
+	return New().ANSI(w, src)
+}
+
+// HTML writes src to w as HTML.
+//
+// Each token is wrapped in a <span> element whose class attribute holds the
+// prefixed name of its class, e.g. "tex-macro", followed by the prefixed
+// "math" class for tokens of math expressions.
+// Whitespace is written as is, so the output is meant to be embedded in a
+// <pre> element.
+func (h *Highlighter) HTML(w io.Writer, src string) error {
+	o := new(strings.Builder)
+	for _, span := range h.Spans(src) {
+		txt := html.EscapeString(span.Text)
+		if span.Class == Plain && (!span.Math || isBlank(span.Kind)) {
+			o.WriteString(txt)
+			continue
+		}
+		class := h.Prefix + span.Class.String()
+		if span.Math {
+			class += " " + h.Prefix + "math"
+		}
+		fmt.Fprintf(o, "<span class=%q>%s</span>", class, txt)
+	}
+	_, err := io.WriteString(w, o.String())
+	if err != nil {
+		return fmt.Errorf("highlight: could not write HTML: %w", err)
+	}
+	return nil
+}
+
+// ANSI writes src to w, coloring tokens with ANSI escape sequences.
+func (h *Highlighter) ANSI(w io.Writer, src string) error {
+	o := new(strings.Builder)
+	for _, span := range h.Spans(src) {
+		sgr := h.Colors[span.Class]
+		if sgr == "" {
+			o.WriteString(span.Text)
+			continue
+		}
+		fmt.Fprintf(o, "\x1b[%sm%s\x1b[0m", sgr, span.Text)
+	}
+	_, err := io.WriteString(w, o.String())
+	if err != nil {
+		return fmt.Errorf("highlight: could not write ANSI text: %w", err)
+	}
+	return nil
+}
+
+// Spans returns the classified tokens of src.
+// The texts of the returned tokens concatenate to src.
+func (h *Highlighter) Spans(src string) []Span {
+	var (
+		toks  = lex(src)
+		spans = make([]Span, len(toks))
+		defs  = defined(toks)
+		math  []string // stack of closing math delimiters
+	)
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		spans[i] = Span{Token: tok, Class: classOf(tok.Kind), Math: len(math) > 0}
+		if tok.Kind != token.Macro && !(tok.Kind == token.Symbol && tok.Text[0] == '$') {
+			continue
+		}
+
+		switch tok.Text {
+		case "$", "$$":
+			if n := len(math); n > 0 && math[n-1] == tok.Text {
+				math = math[:n-1]
+			} else {
+				math = append(math, tok.Text)
+			}
+			spans[i].Class = Delim
+			spans[i].Math = false
+
+		case `\(`, `\[`:
+			math = append(math, closers[tok.Text])
+			spans[i].Class = Delim
+			spans[i].Math = false
+
+		case `\)`, `\]`:
+			if n := len(math); n > 0 && math[n-1] == tok.Text {
+				math = math[:n-1]
+			}
+			spans[i].Class = Delim
+			spans[i].Math = false
+
+		case `\begin`, `\end`:
+			spans[i].Class = Macro
+			name, beg, end := envName(toks, i+1)
+			if beg == end {
+				continue
+			}
+			for j := i + 1; j < end; j++ {
+				spans[j] = Span{Token: toks[j], Class: classOf(toks[j].Kind), Math: len(math) > 0}
+				if j >= beg && toks[j].Kind != token.Rbrace {
+					spans[j].Class = Env
+				}
+			}
+			if latex.IsMathEnv(name) {
+				closer := `\end{` + name + `}`
+				switch tok.Text {
+				case `\begin`:
+					math = append(math, closer)
+				default:
+					if n := len(math); n > 0 && math[n-1] == closer {
+						math = math[:n-1]
+					}
+				}
+				// only the outermost math environment is delimited: the
+				// ones nested in math, such as pmatrix, are math.
+				if len(math) == 0 || tok.Text == `\begin` && len(math) == 1 {
+					for j := i; j < end; j++ {
+						spans[j].Math = false
+					}
+				}
+			}
+			i = end - 1
+
+		default:
+			if !h.known(tok.Text) && !defs[tok.Text] {
+				spans[i].Class = Unknown
+			}
+		}
+	}
+	return spans
+}
+
+// known returns whether the named macro is known to the highlighter.
+func (h *Highlighter) known(name string) bool {
+	if len(name) < 2 {
+		return false
+	}
+	if !isLetter(name[1]) {
+		// control symbols, such as \\, \{ or \,
+		return true
+	}
+	return knownMacros[name] || tex2unicode.HasSymbol(name[1:]) || h.Macros[name]
+}
+
+func classOf(kind token.Kind) Class {
+	switch kind {
+	case token.Word:
+		return Word
+	case token.Number:
+		return Number
+	case token.Symbol:
+		return Symbol
+	case token.Lbrace, token.Rbrace:
+		return Brace
+	case token.Lbrack, token.Rbrack, token.Lparen, token.Rparen:
+		return Bracket
+	case token.Comment:
+		return Comment
+	case token.Macro:
+		return Macro
+	case token.Verbatim:
+		return Verbatim
+	default:
+		return Plain
+	}
+}
+
+// envName returns the name of the environment in the group starting at the
+// i-th token, and the indices of the first and last tokens of the name.
+// The returned indices are equal if there is no such group.
+func envName(toks []token.Token, i int) (name string, beg, end int) {
+	if i >= len(toks) || toks[i].Kind != token.Lbrace {
+		return "", i, i
+	}
+	for j := i + 1; j < len(toks); j++ {
+		switch toks[j].Kind {
+		case token.Rbrace:
+			return name, i + 1, j + 1
+		case token.Word, token.Symbol, token.Number:
+			name += toks[j].Text
+		default:
+			return "", i, i
+		}
+	}
+	return "", i, i
+}
+
+// defined returns the macros defined with \newcommand, \def and similar
+// commands.
+func defined(toks []token.Token) map[string]bool {
+	defs := make(map[string]bool)
+	for i, tok := range toks {
+		if tok.Kind != token.Macro || !defCmds[tok.Text] {
+			continue
+		}
+	loop:
+		for _, tok := range toks[i+1:] {
+			switch tok.Kind {
+			case token.Macro:
+				defs[tok.Text] = true
+				break loop
+			case token.Space, token.Lbrace, token.Symbol:
+				// e.g. \newcommand*{\foo}
+			default:
+				break loop
+			}
+		}
+	}
+	return defs
+}
+
+func isBlank(kind token.Kind) bool {
+	return kind == token.Space || kind == token.EmptyLine
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+var (
+	knownMacros = func() map[string]bool {
+		names := make(map[string]bool)
+		for _, name := range latex.Macros() {
+			names[name] = true
+		}
+		names[`\verb`] = true // handled by the lexer
+		return names
+	}()
+
+	closers = map[string]string{
+		`\(`: `\)`,
+		`\[`: `\]`,
+	}
+
+	defCmds = map[string]bool{
+		`\newcommand`:           true,
+		`\renewcommand`:         true,
+		`\providecommand`:       true,
+		`\DeclareMathOperator`:  true,
+		`\DeclareRobustCommand`: true,
+		`\def`:                  true,
+		`\gdef`:                 true,
+		`\edef`:                 true,
+		`\xdef`:                 true,
+		`\let`:                  true,
+	}
+)
@@ -0,0 +1,121 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package highlight
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSpans(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `Let $x^2$.`,
+			want:  `word:"Let" plain:" " delim:"$" word*:"x" symbol*:"^" number*:"2" delim:"$" symbol:"."`,
+		},
+		{
+			input: `\[ \frac{1}{2.5} \]`,
+			want:  `delim:"\\[" plain*:" " macro*:"\\frac" brace*:"{" number*:"1" brace*:"}" brace*:"{" number*:"2.5" brace*:"}" plain*:" " delim:"\\]"`,
+		},
+		{
+			input: "\\begin{equation*}\\alpha\\end{equation*}",
+			want:  `macro:"\\begin" brace:"{" env:"equation" env:"*" brace:"}" macro*:"\\alpha" macro:"\\end" brace:"{" env:"equation" env:"*" brace:"}"`,
+		},
+		{
+			input: "\\begin{aligned}x\\end{aligned}",
+			want:  `macro:"\\begin" brace:"{" env:"aligned" brace:"}" word*:"x" macro:"\\end" brace:"{" env:"aligned" brace:"}"`,
+		},
+		{
+			input: "$\\begin{pmatrix}x\\end{pmatrix}$",
+			want:  `delim:"$" macro*:"\\begin" brace*:"{" env*:"pmatrix" brace*:"}" word*:"x" macro*:"\\end" brace*:"{" env*:"pmatrix" brace*:"}" delim:"$"`,
+		},
+		{
+			input: "$$a$$ \\(b\\)",
+			want:  `delim:"$$" word*:"a" delim:"$$" plain:" " delim:"\\(" word*:"b" delim:"\\)"`,
+		},
+		{
+			input: `\newcommand{\R}{\mathbb{R}}\R\foo\\`,
+			want:  `macro:"\\newcommand" brace:"{" macro:"\\R" brace:"}" brace:"{" macro:"\\mathbb" brace:"{" word:"R" brace:"}" brace:"}" macro:"\\R" unknown:"\\foo" macro:"\\\\"`,
+		},
+		{
+			input: "a % comment\r\n\n[b]",
+			want:  `word:"a" plain:" " comment:"% comment" plain:"\r\n\n" bracket:"[" word:"b" bracket:"]"`,
+		},
+		{
+			input: `\verb|$x|, \verb+\y`,
+			want:  `macro:"\\verb" symbol:"|" verbatim:"$x" symbol:"|" symbol:"," plain:" " macro:"\\verb" symbol:"+" unknown:"\\y"`,
+		},
+		{
+			input: "\\begin{verbatim}\n$\\x\n\\end{verbatim}",
+			want:  `macro:"\\begin" brace:"{" env:"verbatim" brace:"}" verbatim:"\n$\\x\n" macro:"\\end" brace:"{" env:"verbatim" brace:"}"`,
+		},
+		{
+			// rejected by the parser.
+			input: "\\begin{document}$x \"é\\",
+			want:  `macro:"\\begin" brace:"{" env:"document" brace:"}" delim:"$" word*:"x" plain*:" " plain*:"\"" word*:"é" plain*:"\\"`,
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			spans := Spans(tc.input)
+			var (
+				o   = new(strings.Builder)
+				src = new(strings.Builder)
+			)
+			for i, span := range spans {
+				if i > 0 {
+					o.WriteString(" ")
+				}
+				math := ""
+				if span.Math {
+					math = "*"
+				}
+				fmt.Fprintf(o, "%v%s:%q", span.Class, math, span.Text)
+				if got, want := int(span.Pos), src.Len(); got != want {
+					t.Fatalf("invalid position for %q: got=%d, want=%d", span.Text, got, want)
+				}
+				src.WriteString(span.Text)
+			}
+			if got, want := o.String(), tc.want; got != want {
+				t.Fatalf("invalid spans:\ngot= %s\nwant=%s", got, want)
+			}
+			if got, want := src.String(), tc.input; got != want {
+				t.Fatalf("invalid round-trip:\ngot= %q\nwant=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	o := new(strings.Builder)
+	err := HTML(o, `a<b $\foo$`)
+	if err != nil {
+		t.Fatalf("could not highlight: %+v", err)
+	}
+	want := `<span class="tex-word">a</span><span class="tex-symbol">&lt;</span><span class="tex-word">b</span> ` +
+		`<span class="tex-delim">$</span><span class="tex-unknown tex-math">\foo</span><span class="tex-delim">$</span>`
+	if got := o.String(); got != want {
+		t.Fatalf("invalid HTML:\ngot= %s\nwant=%s", got, want)
+	}
+}
+
+func TestANSI(t *testing.T) {
+	h := New()
+	h.Macros[`\foo`] = true
+	h.Colors = map[Class]string{Macro: "34", Delim: "35"}
+
+	o := new(strings.Builder)
+	err := h.ANSI(o, `x $\foo$ % c`)
+	if err != nil {
+		t.Fatalf("could not highlight: %+v", err)
+	}
+	want := "x \x1b[35m$\x1b[0m\x1b[34m\\foo\x1b[0m\x1b[35m$\x1b[0m % c"
+	if got := o.String(); got != want {
+		t.Fatalf("invalid ANSI output:\ngot= %q\nwant=%q", got, want)
+	}
+}
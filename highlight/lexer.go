@@ -0,0 +1,226 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-latex/latex"
+	"github.com/go-latex/latex/token"
+)
+
+// lexer splits LaTeX source into tokens.
+//
+// Contrary to the scanner of the latex package, the lexer keeps every byte
+// of its input (including newlines) and never fails: runes it does not
+// recognize are returned as token.Other.
+type lexer struct {
+	src  string
+	off  int
+	toks []token.Token
+}
+
+func lex(src string) []token.Token {
+	lx := &lexer{src: src}
+	for lx.off < len(lx.src) {
+		lx.next()
+	}
+	return lx.toks
+}
+
+func (lx *lexer) emit(kind token.Kind, end int) {
+	lx.toks = append(lx.toks, token.Token{
+		Kind: kind,
+		Pos:  token.Pos(lx.off),
+		Text: lx.src[lx.off:end],
+	})
+	lx.off = end
+}
+
+func (lx *lexer) peek(off int) rune {
+	if off >= len(lx.src) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(lx.src[off:])
+	return r
+}
+
+func (lx *lexer) next() {
+	r, n := utf8.DecodeRuneInString(lx.src[lx.off:])
+	end := lx.off + n
+	switch {
+	case r == '\\':
+		lx.macro()
+
+	case r == '%':
+		i := strings.IndexByte(lx.src[lx.off:], '\n')
+		if i < 0 {
+			i = len(lx.src) - lx.off
+		}
+		lx.emit(token.Comment, lx.off+len(strings.TrimRight(lx.src[lx.off:lx.off+i], "\r")))
+
+	case isSpace(r):
+		nl := 0
+		for end = lx.off; end < len(lx.src) && isSpace(rune(lx.src[end])); end++ {
+			if lx.src[end] == '\n' {
+				nl++
+			}
+		}
+		kind := token.Space
+		if nl > 1 {
+			kind = token.EmptyLine
+		}
+		lx.emit(kind, end)
+
+	case unicode.IsLetter(r):
+		for end < len(lx.src) {
+			r, n := utf8.DecodeRuneInString(lx.src[end:])
+			if !unicode.IsLetter(r) {
+				break
+			}
+			end += n
+		}
+		lx.emit(token.Word, end)
+
+	case isDigit(r):
+		end = lx.digits(end)
+		if end+1 < len(lx.src) && lx.src[end] == '.' && isDigit(rune(lx.src[end+1])) {
+			end = lx.digits(end + 1)
+		}
+		lx.emit(token.Number, end)
+
+	case r == '$':
+		if lx.peek(end) == '$' {
+			end++
+		}
+		lx.emit(token.Symbol, end)
+
+	case strings.ContainsRune(symbols, r):
+		lx.emit(token.Symbol, end)
+
+	default:
+		kind, ok := delims[r]
+		if !ok {
+			kind = token.Other
+		}
+		lx.emit(kind, end)
+	}
+}
+
+// macro lexes a control sequence starting at the current offset.
+func (lx *lexer) macro() {
+	beg := lx.off + 1
+	if beg == len(lx.src) {
+		lx.emit(token.Other, beg)
+		return
+	}
+
+	r, n := utf8.DecodeRuneInString(lx.src[beg:])
+	if !unicode.IsLetter(r) {
+		kind := token.Macro
+		if r == ' ' {
+			kind = token.Space
+		}
+		lx.emit(kind, beg+n)
+		return
+	}
+
+	end := beg
+	for end < len(lx.src) {
+		r, n := utf8.DecodeRuneInString(lx.src[end:])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		end += n
+	}
+	name := lx.src[lx.off:end]
+	lx.emit(token.Macro, end)
+
+	switch name {
+	case `\verb`:
+		lx.verb()
+	case `\begin`:
+		lx.verbatimEnv()
+	}
+}
+
+// verb lexes the argument of a \verb command.
+func (lx *lexer) verb() {
+	if lx.peek(lx.off) == '*' {
+		lx.emit(token.Symbol, lx.off+1)
+	}
+	if lx.off == len(lx.src) {
+		return
+	}
+	delim, n := utf8.DecodeRuneInString(lx.src[lx.off:])
+	if isSpace(delim) || unicode.IsLetter(delim) {
+		return
+	}
+	lx.emit(token.Symbol, lx.off+n)
+
+	end := strings.IndexRune(lx.src[lx.off:], delim)
+	if nl := strings.IndexByte(lx.src[lx.off:], '\n'); end < 0 || (nl >= 0 && nl < end) {
+		// unterminated \verb.
+		return
+	}
+	if end > 0 {
+		lx.emit(token.Verbatim, lx.off+end)
+	}
+	lx.emit(token.Symbol, lx.off+n)
+}
+
+// verbatimEnv lexes the name and the content of a verbatim environment,
+// right after its \begin command.
+func (lx *lexer) verbatimEnv() {
+	src := lx.src[lx.off:]
+	if !strings.HasPrefix(src, "{") {
+		return
+	}
+	end := strings.IndexByte(src, '}')
+	if end < 0 || !latex.IsVerbatimEnv(src[1:end]) {
+		return
+	}
+	name := src[1:end]
+	lx.emit(token.Lbrace, lx.off+1)
+	lx.emit(token.Word, lx.off+len(name))
+	lx.emit(token.Rbrace, lx.off+1)
+
+	end = strings.Index(lx.src[lx.off:], `\end{`+name+`}`)
+	if end < 0 {
+		end = len(lx.src) - lx.off
+	}
+	if end > 0 {
+		lx.emit(token.Verbatim, lx.off+end)
+	}
+}
+
+func (lx *lexer) digits(end int) int {
+	for end < len(lx.src) && isDigit(rune(lx.src[end])) {
+		end++
+	}
+	return end
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// symbols are the runes lexed as token.Symbol.
+const symbols = "_=<>^/*-+!?':,;.&|~#@`"
+
+var delims = map[rune]token.Kind{
+	'{': token.Lbrace,
+	'}': token.Rbrace,
+	'[': token.Lbrack,
+	']': token.Rbrack,
+	'(': token.Lparen,
+	')': token.Rparen,
+}
@@ -0,0 +1,111 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package latex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+)
+
+// include starts scanning the file named by an \input, \include or
+// \subfile command.
+//
+// File names are resolved relative to the directory of the main document,
+// following TeX rules:
+//   - \include{name} reads name.tex,
+//   - \input{name} and \subfile{name} read name.tex if it exists, or name
+//     otherwise.
+//
+// Only the body of the document environment of a subfile is scanned.
+func (p *parser) include(macro *ast.Macro) {
+	var (
+		kind = macro.Name.Name
+		name = strings.TrimSpace(verbatimText(macro.Args[0].(*ast.Arg).List))
+	)
+	if name == "" {
+		panic(fmt.Errorf("%s: empty file name", kind))
+	}
+
+	var cands []string
+	switch {
+	case kind == `\include`:
+		cands = []string{name + ".tex"}
+	case strings.HasSuffix(name, ".tex"):
+		cands = []string{name}
+	default:
+		cands = []string{name + ".tex", name}
+	}
+
+	var (
+		fname string
+		src   []byte
+	)
+	for _, cand := range cands {
+		cand = path.Join(p.dir, cand)
+		if !fs.ValidPath(cand) {
+			panic(fmt.Errorf("%s: invalid file name %q", kind, name))
+		}
+		raw, err := fs.ReadFile(p.fsys, cand)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			panic(fmt.Errorf("%s: could not read %q: %w", kind, cand, err))
+		}
+		fname = cand
+		src = raw
+		break
+	}
+	if fname == "" {
+		panic(fmt.Errorf("%s: could not find file %q", kind, name))
+	}
+
+	if p.s.opened(fname) {
+		var chain []string
+		for _, src := range p.s.srcs {
+			chain = append(chain, src.name)
+		}
+		chain = append(chain, p.s.name, fname)
+		for chain[0] != fname {
+			chain = chain[1:]
+		}
+		panic(fmt.Errorf("%s: inclusion cycle: %s", kind, strings.Join(chain, " -> ")))
+	}
+
+	f := p.fset.AddFile(fname, -1, len(src))
+	f.SetLinesForContent(src)
+
+	beg, end := 0, len(src)
+	if kind == `\subfile` {
+		beg, end = documentBody(src)
+	}
+	p.s.push(fname, bytes.NewReader(src[beg:end]), f.Base()+beg)
+}
+
+// documentBody returns the boundaries of the content of the document
+// environment of src.
+// The whole source is returned if it has no document environment.
+func documentBody(src []byte) (beg, end int) {
+	const (
+		begin = `\begin{document}`
+		close = `\end{document}`
+	)
+	beg = bytes.Index(src, []byte(begin))
+	if beg < 0 {
+		return 0, len(src)
+	}
+	beg += len(begin)
+	end = bytes.LastIndex(src, []byte(close))
+	if end < beg {
+		end = len(src)
+	}
+	return beg, end
+}
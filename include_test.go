@@ -0,0 +1,134 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package latex
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/token"
+)
+
+func TestParseDocumentFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"thesis/main.tex": {Data: []byte(`\documentclass{report}
+\input{defs}
+\begin{document}
+\include{chapters/intro}
+\subfile{chapters/results.tex}
+\end{document}
+`)},
+		"thesis/defs.tex": {Data: []byte(`\newcommand{\R}{\mathbb{R}}`)},
+		"thesis/chapters/intro.tex": {Data: []byte(`\chapter{Intro}\label{ch:intro}
+See $\R$ and \input{chapters/data}.`)},
+		"thesis/chapters/data": {Data: []byte(`\begin{tabular}{ll}a & b\end{tabular}`)},
+		"thesis/chapters/results.tex": {Data: []byte(`\documentclass[../main]{subfiles}
+\begin{document}
+\chapter{Results}
+Done.
+\end{document}
+`)},
+	}
+
+	fset := token.NewFileSet()
+	doc, err := ParseDocumentFS(fset, fsys, "thesis/main.tex")
+	if err != nil {
+		t.Fatalf("could not parse document: %+v", err)
+	}
+
+	if got, want := len(doc.Defs), 1; got != want {
+		t.Fatalf("invalid number of definitions: got=%d, want=%d", got, want)
+	}
+
+	o := new(strings.Builder)
+	ast.Print(o, doc.Body)
+	want := `ast.Env{"document", List:` +
+		`ast.Macro{"\\include", Args:{ast.Word{"chapters/intro"}}}, ` +
+		`ast.Section{"\\chapter", Level:0, Title:ast.List{ast.Word{"Intro"}}, Label:"ch:intro", List:` +
+		`ast.Macro{"\\label", Args:{ast.Word{"ch:intro"}}}, ` +
+		`ast.Word{"See"}, ast.Symbol{" "}, ast.MathExpr{List:ast.Macro{"\\R"}}, ast.Symbol{" "}, ast.Word{"and"}, ast.Symbol{" "}, ` +
+		`ast.Macro{"\\input", Args:{ast.Word{"chapters/data"}}}, ` +
+		`ast.Table{"tabular", Spec:"ll", Rows:[[ast.List{ast.Word{"a"}}, ast.List{ast.Word{"b"}}]]}, ` +
+		`ast.Symbol{"."}, ` +
+		`ast.Macro{"\\subfile", Args:{ast.Word{"chapters/results.tex"}}}}, ` +
+		`ast.Section{"\\chapter", Level:0, Title:ast.List{ast.Word{"Results"}}, List:ast.Word{"Done"}, ast.Symbol{"."}}}`
+	if got := o.String(); got != want {
+		t.Fatalf("invalid document body:\ngot= %s\nwant=%s", got, want)
+	}
+
+	for _, tc := range []struct {
+		node ast.Node
+		want string
+	}{
+		{doc.Body, "thesis/main.tex:3:1"},
+		{doc.Body.List[1].(*ast.Section), "thesis/chapters/intro.tex:1:1"},
+		{doc.Body.List[1].(*ast.Section).List[8], "thesis/chapters/data:1:1"},
+		{doc.Body.List[2].(*ast.Section), "thesis/chapters/results.tex:3:1"},
+	} {
+		if got, want := fset.Position(tc.node.Pos()).String(), tc.want; got != want {
+			t.Errorf("invalid position: got=%q, want=%q", got, want)
+		}
+	}
+}
+
+func TestParseDocumentFSError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{
+			name: "cycle",
+			fsys: fstest.MapFS{
+				"main.tex": {Data: []byte(`\input{a}`)},
+				"a.tex":    {Data: []byte(`\input{b}`)},
+				"b.tex":    {Data: []byte("x\n\\input{a.tex}")},
+			},
+			want: `latex: could not parse document (b.tex:2:7): \input: inclusion cycle: a.tex -> b.tex -> a.tex`,
+		},
+		{
+			name: "self",
+			fsys: fstest.MapFS{
+				"main.tex": {Data: []byte(`\input{main}`)},
+			},
+			want: `\input: inclusion cycle: main.tex -> main.tex`,
+		},
+		{
+			name: "include-ext",
+			fsys: fstest.MapFS{
+				"main.tex": {Data: []byte(`\include{data}`)},
+				"data":     {Data: []byte(`x`)},
+			},
+			want: `\include: could not find file "data"`,
+		},
+		{
+			name: "missing",
+			fsys: fstest.MapFS{
+				"main.tex": {Data: []byte(`\subfile{missing}`)},
+			},
+			want: `\subfile: could not find file "missing"`,
+		},
+		{
+			name: "parse-error",
+			fsys: fstest.MapFS{
+				"main.tex": {Data: []byte(`\begin{document}\input{a}\end{document}`)},
+				"a.tex":    {Data: []byte("a\n\\end{itemize}")},
+			},
+			want: `(a.tex:2:5): \begin{document} ended by \end{itemize}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseDocumentFS(token.NewFileSet(), tc.fsys, "main.tex")
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; !strings.Contains(got, want) {
+				t.Fatalf("invalid error:\ngot= %v\nwant=%v", got, want)
+			}
+		})
+	}
+}
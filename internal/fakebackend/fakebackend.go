@@ -9,6 +9,7 @@ package fakebackend // import "github.com/go-latex/latex/internal/fakebackend"
 
 import (
 	"fmt"
+	"image/color"
 
 	"github.com/go-latex/latex/font"
 )
@@ -42,11 +43,25 @@ func (be *Backend) RenderGlyph(x, y float64, font font.Font, symbol string, dpi
 	//panic("not implemented")
 }
 
-// RenderRectFilled draws a filled black rectangle from (x1,y1) to (x2,y2).
+// RenderRectFilled draws a filled rectangle from (x1,y1) to (x2,y2).
 func (be *Backend) RenderRectFilled(x1, y1, x2, y2 float64) {
 	//panic("not implemented")
 }
 
+// RenderRectStroked draws the outline of the rectangle from (x1,y1) to
+// (x2,y2), with a line of the provided width centered on its edges.
+func (be *Backend) RenderRectStroked(x1, y1, x2, y2, width float64) {
+	//panic("not implemented")
+}
+
+// RenderLine draws a line of the provided width from (x1,y1) to (x2,y2).
+func (be *Backend) RenderLine(x1, y1, x2, y2, width float64) {
+	//panic("not implemented")
+}
+
+// SetColor sets the color of the glyphs and rectangles rendered next.
+func (be *Backend) SetColor(c color.Color) {}
+
 // Metrics returns the metrics.
 func (be *Backend) Metrics(symbol string, font font.Font, dpi float64, math bool) font.Metrics {
 	if dpi != 72 {
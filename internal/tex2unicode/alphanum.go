@@ -0,0 +1,82 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tex2unicode
+
+// alphabet describes a math alphabet of the Mathematical Alphanumeric
+// Symbols block (U+1D400–U+1D7FF).
+type alphabet struct {
+	upper rune // code point of 'A', or 0
+	lower rune // code point of 'a', or 0
+	digit rune // code point of '0', or 0
+
+	// holes are the letters that were encoded in the Letterlike Symbols
+	// block before the Mathematical Alphanumeric Symbols block existed.
+	holes map[rune]rune
+}
+
+var script = alphabet{
+	upper: 0x1D49C,
+	lower: 0x1D4B6,
+	holes: map[rune]rune{
+		'B': 0x212C, 'E': 0x2130, 'F': 0x2131, 'H': 0x210B, 'I': 0x2110,
+		'L': 0x2112, 'M': 0x2133, 'R': 0x211B,
+		'e': 0x212F, 'g': 0x210A, 'o': 0x2134,
+	},
+}
+
+// alphabets are the math alphabets, indexed by their LaTeX font name.
+var alphabets = map[string]alphabet{
+	"bf":  {upper: 0x1D400, lower: 0x1D41A, digit: 0x1D7CE},
+	"it":  {upper: 0x1D434, lower: 0x1D44E, holes: map[rune]rune{'h': 0x210E}},
+	"cal": script,
+	"scr": script,
+	"frak": {
+		upper: 0x1D504,
+		lower: 0x1D51E,
+		holes: map[rune]rune{
+			'C': 0x212D, 'H': 0x210C, 'I': 0x2111, 'R': 0x211C, 'Z': 0x2128,
+		},
+	},
+	"bb": {
+		upper: 0x1D538,
+		lower: 0x1D552,
+		digit: 0x1D7D8,
+		holes: map[rune]rune{
+			'C': 0x2102, 'H': 0x210D, 'N': 0x2115, 'P': 0x2119, 'Q': 0x211A,
+			'R': 0x211D, 'Z': 0x2124,
+		},
+	},
+	"sf": {upper: 0x1D5A0, lower: 0x1D5BA, digit: 0x1D7E2},
+	"tt": {upper: 0x1D670, lower: 0x1D68A, digit: 0x1D7F6},
+}
+
+// IsMathAlphabet returns whether the named font is a math alphabet of the
+// Mathematical Alphanumeric Symbols block.
+func IsMathAlphabet(font string) bool {
+	_, ok := alphabets[font]
+	return ok
+}
+
+// MathAlphanumeric returns the code point of the ASCII letter or digit r in
+// the named math alphabet (bf, it, cal, scr, frak, bb, sf or tt), and
+// whether the alphabet has such a character.
+func MathAlphanumeric(r rune, font string) (rune, bool) {
+	alpha, ok := alphabets[font]
+	if !ok {
+		return r, false
+	}
+	if v, ok := alpha.holes[r]; ok {
+		return v, true
+	}
+	switch {
+	case 'A' <= r && r <= 'Z' && alpha.upper != 0:
+		return alpha.upper + r - 'A', true
+	case 'a' <= r && r <= 'z' && alpha.lower != 0:
+		return alpha.lower + r - 'a', true
+	case '0' <= r && r <= '9' && alpha.digit != 0:
+		return alpha.digit + r - '0', true
+	}
+	return r, false
+}
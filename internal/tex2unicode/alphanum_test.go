@@ -0,0 +1,41 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tex2unicode
+
+import "testing"
+
+func TestMathAlphanumeric(t *testing.T) {
+	for _, tc := range []struct {
+		r    rune
+		font string
+		want rune
+		ok   bool
+	}{
+		{r: 'A', font: "bf", want: '𝐀', ok: true},
+		{r: 'z', font: "bf", want: '𝐳', ok: true},
+		{r: '7', font: "bf", want: '𝟕', ok: true},
+		{r: 'x', font: "it", want: '𝑥', ok: true},
+		{r: 'h', font: "it", want: 'ℎ', ok: true},
+		{r: 'L', font: "cal", want: 'ℒ', ok: true},
+		{r: 'A', font: "scr", want: '𝒜', ok: true},
+		{r: 'g', font: "frak", want: '𝔤', ok: true},
+		{r: 'R', font: "frak", want: 'ℜ', ok: true},
+		{r: 'R', font: "bb", want: 'ℝ', ok: true},
+		{r: 'A', font: "bb", want: '𝔸', ok: true},
+		{r: '1', font: "bb", want: '𝟙', ok: true},
+		{r: 'a', font: "sf", want: '𝖺', ok: true},
+		{r: '0', font: "tt", want: '𝟶', ok: true},
+		{r: '1', font: "cal", want: '1', ok: false},
+		{r: '+', font: "bf", want: '+', ok: false},
+		{r: 'a', font: "rm", want: 'a', ok: false},
+	} {
+		t.Run(string(tc.r)+"-"+tc.font, func(t *testing.T) {
+			got, ok := MathAlphanumeric(tc.r, tc.font)
+			if got != tc.want || ok != tc.ok {
+				t.Fatalf("error: got=(%q, %v), want=(%q, %v)", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
@@ -29,7 +29,7 @@ func Index(v string, math bool) rune {
 		return 0x2212
 	}
 
-	if len(v) == 1 {
+	if utf8.RuneCountInString(v) == 1 {
 		r, _ := utf8.DecodeRune([]byte(v))
 		if r != utf8.RuneError {
 			return r
@@ -25,6 +25,7 @@ func TestIndex(t *testing.T) {
 		{v: `-`, want: '-'},
 		{v: `-`, want: '−', math: true},
 		{v: `\alpha`, want: 'α', math: true},
+		{v: `ˆ`, want: 'ˆ', math: true},
 		{v: `\t`, want: 865, math: true},
 		{v: `\aleph`, want: 'ℵ', math: true},
 		{v: `\flat`, want: '♭', math: true},
@@ -5,12 +5,40 @@
 package latex
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-latex/latex/ast"
 	"github.com/go-latex/latex/internal/tex2unicode"
+	"github.com/go-latex/latex/token"
 )
 
+// Macros returns the sorted list of the names of the macros known to
+// the parser.
+func Macros() []string {
+	p := newParser("")
+	names := make([]string, 0, len(p.macros))
+	for name := range p.macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsMathEnv reports whether the content of the named environment is
+// parsed in math mode, as with equation or align.
+func IsMathEnv(name string) bool {
+	return mathEnvs[name]
+}
+
+// IsVerbatimEnv reports whether the content of the named environment is
+// kept verbatim, as with verbatim or lstlisting.
+func IsVerbatimEnv(name string) bool {
+	return verbatimEnvs[name]
+}
+
 type macroParser interface {
 	parseMacro(p *parser) ast.Node
 }
@@ -63,6 +91,29 @@ func (p *parser) addBuiltinMacros() {
 		`\tfrac`:    builtinMacro("AA"),
 		`\genfrac`:  nil, // FIXME(sbinet)
 
+		// stacking
+		`\overset`:    builtinMacro("AA"),
+		`\underset`:   builtinMacro("AA"),
+		`\overbrace`:  builtinMacro("A"),
+		`\underbrace`: builtinMacro("A"),
+
+		// phantoms, smashing and overlaps
+		`\phantom`:  builtinMacro("A"),
+		`\hphantom`: builtinMacro("A"),
+		`\vphantom`: builtinMacro("A"),
+		`\smash`:    builtinMacro("OA"),
+		`\mathllap`: builtinMacro("OA"),
+		`\mathrlap`: builtinMacro("OA"),
+		`\mathclap`: builtinMacro("OA"),
+
+		// frames and cancellations
+		`\boxed`:    builtinMacro("A"),
+		`\fbox`:     builtinMacro("T"),
+		`\framebox`: builtinMacro("OOT"),
+		`\cancel`:   builtinMacro("A"),
+		`\bcancel`:  builtinMacro("A"),
+		`\xcancel`:  builtinMacro("A"),
+
 		// relation symbols
 		`\approx`:     builtinMacro(""),
 		`\asymp`:      builtinMacro(""),
@@ -139,6 +190,18 @@ func (p *parser) addBuiltinMacros() {
 		`\Uparrow`:            builtinMacro(""),
 		`\Updownarrow`:        builtinMacro(""),
 
+		// extensible arrows
+		`\xrightarrow`:         builtinMacro("OA"),
+		`\xleftarrow`:          builtinMacro("OA"),
+		`\xleftrightarrow`:     builtinMacro("OA"),
+		`\xmapsto`:             builtinMacro("OA"),
+		`\overrightarrow`:      builtinMacro("A"),
+		`\overleftarrow`:       builtinMacro("A"),
+		`\overleftrightarrow`:  builtinMacro("A"),
+		`\underrightarrow`:     builtinMacro("A"),
+		`\underleftarrow`:      builtinMacro("A"),
+		`\underleftrightarrow`: builtinMacro("A"),
+
 		// punctuation symbols
 		`\ldotp`: builtinMacro(""),
 		`\cdotp`: builtinMacro(""),
@@ -169,6 +232,17 @@ func (p *parser) addBuiltinMacros() {
 		`\int`:  builtinMacro(""),
 		`\oint`: builtinMacro(""),
 
+		// limits placement
+		`\limits`:   builtinMacro(""),
+		`\nolimits`: builtinMacro(""),
+
+		// math style
+		`\displaystyle`:      builtinMacro(""),
+		`\textstyle`:         builtinMacro(""),
+		`\scriptstyle`:       builtinMacro(""),
+		`\scriptscriptstyle`: builtinMacro(""),
+		`\mathchoice`:        builtinMacro("AAAA"),
+
 		// font names
 		`\rm`:      builtinMacro(""),
 		`\cal`:     builtinMacro(""),
@@ -218,11 +292,21 @@ func (p *parser) addBuiltinMacros() {
 
 		// left delim
 		`\{`:      builtinMacro(""),
-		`\(`:      builtinMacro(""),
+		`\(`:      mathDelimMacro(""),
+		`\[`:      mathDelimMacro(""),
 		`\langle`: builtinMacro(""),
 		`\lceil`:  builtinMacro(""),
 		`\lfloor`: builtinMacro(""),
 
+		// sized delim
+		`\left`:   builtinMacro(""),
+		`\middle`: builtinMacro(""),
+		`\right`:  builtinMacro(""),
+		`\big`:    builtinMacro(""),
+		`\Big`:    builtinMacro(""),
+		`\bigg`:   builtinMacro(""),
+		`\Bigg`:   builtinMacro(""),
+
 		// right delim
 		`\}`:      builtinMacro(""),
 		`\)`:      builtinMacro(""),
@@ -293,18 +377,25 @@ func (p *parser) addBuiltinMacros() {
 		`\mathfrak`:    builtinMacro("A"),
 		`\mathscr`:     builtinMacro("A"),
 		`\mathregular`: builtinMacro("A"),
+		`\mathrm`:      builtinMacro("A"),
 
 		// text
-		`\textbf`:      builtinMacro("A"),
-		`\textit`:      builtinMacro("A"),
-		`\textsf`:      builtinMacro("A"),
-		`\texttt`:      builtinMacro("A"),
-		`\textcal`:     builtinMacro("A"),
-		`\textdefault`: builtinMacro("A"),
-		`\textbb`:      builtinMacro("A"),
-		`\textfrak`:    builtinMacro("A"),
-		`\textscr`:     builtinMacro("A"),
-		`\textregular`: builtinMacro("A"),
+		`\textbf`:      builtinMacro("T"),
+		`\textit`:      builtinMacro("T"),
+		`\textsf`:      builtinMacro("T"),
+		`\texttt`:      builtinMacro("T"),
+		`\textcal`:     builtinMacro("T"),
+		`\textdefault`: builtinMacro("T"),
+		`\textbb`:      builtinMacro("T"),
+		`\textfrak`:    builtinMacro("T"),
+		`\textscr`:     builtinMacro("T"),
+		`\textregular`: builtinMacro("T"),
+
+		// colors
+		`\color`:       builtinMacro("OV"),
+		`\textcolor`:   builtinMacro("OVA"),
+		`\colorbox`:    builtinMacro("OVT"),
+		`\definecolor`: builtinMacro("VVV"),
 
 		// space, symbols
 		`\ `:      builtinMacro(""),
@@ -320,10 +411,112 @@ func (p *parser) addBuiltinMacros() {
 		`\vdots`:  builtinMacro(""),
 		`\hspace`: builtinMacro("A"),
 
+		// lengths
+		`\baselineskip`: builtinMacro(""),
+		`\jot`:          builtinMacro(""),
+		`\lineskip`:     builtinMacro(""),
+
 		// catch-all
 		//
 		`\overline`:     builtinMacro("A"),
 		`\operatorname`: builtinMacro("A"),
+
+		// environments
+		`\begin`: envMacro{},
+		`\end`:   endMacro{},
+
+		// preamble
+		`\documentclass`:       pkgMacro{},
+		`\usepackage`:          pkgMacro{},
+		`\RequirePackage`:      pkgMacro{},
+		`\newcommand`:          defMacro{},
+		`\renewcommand`:        defMacro{},
+		`\providecommand`:      defMacro{},
+		`\DeclareMathOperator`: defMacro{},
+		`\def`:                 defMacro{},
+		`\gdef`:                defMacro{},
+		`\edef`:                defMacro{},
+		`\xdef`:                defMacro{},
+		`\newenvironment`:      defMacro{},
+		`\renewenvironment`:    defMacro{},
+		`\title`:               builtinMacro("OA"),
+		`\author`:              builtinMacro("OA"),
+		`\date`:                builtinMacro("OA"),
+
+		// sectioning
+		`\part`:          builtinMacro("SOA"),
+		`\chapter`:       builtinMacro("SOA"),
+		`\section`:       builtinMacro("SOA"),
+		`\subsection`:    builtinMacro("SOA"),
+		`\subsubsection`: builtinMacro("SOA"),
+		`\paragraph`:     builtinMacro("SOA"),
+		`\subparagraph`:  builtinMacro("SOA"),
+
+		// tables
+		`\\`:              builtinMacro("SO"),
+		`\tabularnewline`: builtinMacro("O"),
+		`\hline`:          builtinMacro(""),
+		`\cline`:          builtinMacro("V"),
+		`\multicolumn`:    builtinMacro("VVA"),
+
+		// equation numbering
+		`\tag`:      builtinMacro("ST"),
+		`\notag`:    builtinMacro(""),
+		`\nonumber`: builtinMacro(""),
+
+		// escaped characters
+		`\%`: builtinMacro(""),
+		`\&`: builtinMacro(""),
+		`\#`: builtinMacro(""),
+		`\_`: builtinMacro(""),
+		`\$`: builtinMacro(""),
+
+		// inclusion
+		`\input`:   inputMacro{},
+		`\include`: inputMacro{},
+		`\subfile`: inputMacro{},
+
+		// document body
+		`\label`:             builtinMacro("V"),
+		`\ref`:               builtinMacro("SV"),
+		`\eqref`:             builtinMacro("V"),
+		`\pageref`:           builtinMacro("SV"),
+		`\autoref`:           builtinMacro("SV"),
+		`\nameref`:           builtinMacro("SV"),
+		`\vref`:              builtinMacro("SV"),
+		`\cref`:              builtinMacro("SV"),
+		`\Cref`:              builtinMacro("SV"),
+		`\cite`:              builtinMacro("SOOV"),
+		`\citep`:             builtinMacro("SOOV"),
+		`\citet`:             builtinMacro("SOOV"),
+		`\citealp`:           builtinMacro("SOOV"),
+		`\citealt`:           builtinMacro("SOOV"),
+		`\citeauthor`:        builtinMacro("SOOV"),
+		`\citeyear`:          builtinMacro("SOOV"),
+		`\parencite`:         builtinMacro("SOOV"),
+		`\textcite`:          builtinMacro("SOOV"),
+		`\autocite`:          builtinMacro("SOOV"),
+		`\footcite`:          builtinMacro("SOOV"),
+		`\nocite`:            builtinMacro("V"),
+		`\caption`:           builtinMacro("OA"),
+		`\footnote`:          builtinMacro("OA"),
+		`\emph`:              builtinMacro("A"),
+		`\textsc`:            builtinMacro("T"),
+		`\textsl`:            builtinMacro("T"),
+		`\textup`:            builtinMacro("T"),
+		`\textmd`:            builtinMacro("T"),
+		`\textrm`:            builtinMacro("T"),
+		`\textnormal`:        builtinMacro("T"),
+		`\underline`:         builtinMacro("A"),
+		`\text`:              builtinMacro("T"),
+		`\mbox`:              builtinMacro("T"),
+		`\vspace`:            builtinMacro("SA"),
+		`\item`:              builtinMacro("O"),
+		`\url`:               builtinMacro("V"),
+		`\href`:              builtinMacro("VA"),
+		`\includegraphics`:   builtinMacro("SOV"),
+		`\bibliography`:      builtinMacro("V"),
+		`\bibliographystyle`: builtinMacro("V"),
 	}
 
 	// add all known UTF-8 symbols
@@ -336,6 +529,88 @@ func (p *parser) addBuiltinMacros() {
 	}
 }
 
+func (p *parser) addBuiltinEnvs() {
+	p.envs = map[string]string{
+		"array":     "OV",
+		"tabular":   "OV",
+		"tabular*":  "AOV",
+		"tabularx":  "AV",
+		"alignat":   "A",
+		"alignat*":  "A",
+		"figure":    "O",
+		"figure*":   "O",
+		"table":     "O",
+		"table*":    "O",
+		"minipage":  "OA",
+		"subfigure": "OA",
+
+		"thebibliography": "A",
+	}
+}
+
+var (
+	// mathEnvs are the environments whose content is parsed in math mode.
+	mathEnvs = map[string]bool{
+		"math":         true,
+		"displaymath":  true,
+		"equation":     true,
+		"equation*":    true,
+		"eqnarray":     true,
+		"eqnarray*":    true,
+		"align":        true,
+		"align*":       true,
+		"alignat":      true,
+		"alignat*":     true,
+		"flalign":      true,
+		"flalign*":     true,
+		"gather":       true,
+		"gather*":      true,
+		"multline":     true,
+		"multline*":    true,
+		"aligned":      true,
+		"gathered":     true,
+		"split":        true,
+		"cases":        true,
+		"array":        true,
+		"matrix":       true,
+		"pmatrix":      true,
+		"bmatrix":      true,
+		"Bmatrix":      true,
+		"vmatrix":      true,
+		"Vmatrix":      true,
+		"smallmatrix":  true,
+		"subequations": false,
+	}
+
+	// tableEnvs are the environments parsed as tables.
+	// Their last argument, if any, is the column specification.
+	tableEnvs = map[string]bool{
+		"array":    true,
+		"tabular":  true,
+		"tabular*": true,
+		"tabularx": true,
+
+		"matrix":      true,
+		"pmatrix":     true,
+		"bmatrix":     true,
+		"Bmatrix":     true,
+		"vmatrix":     true,
+		"Vmatrix":     true,
+		"smallmatrix": true,
+		"cases":       true,
+	}
+
+	// verbatimEnvs are the environments whose content is not parsed.
+	verbatimEnvs = map[string]bool{
+		"verbatim":     true,
+		"verbatim*":    true,
+		"lstlisting":   true,
+		"minted":       true,
+		"comment":      true,
+		"filecontents": true,
+	}
+)
+
 type builtinMacro string
 
 func (m builtinMacro) parseMacro(p *parser) ast.Node {
@@ -346,16 +621,221 @@ func (m builtinMacro) parseMacro(p *parser) ast.Node {
 		},
 	}
 
-	for _, typ := range strings.ToLower(string(m)) {
+	p.parseMacroArgs(node, string(m))
+	return node
+}
+
+// parseMacroArgs parses the arguments of a macro, following the provided
+// signature:
+//   - 'a': argument,
+//   - 'o': optional argument,
+//   - 't': argument typeset in text mode,
+//   - 'v': verbatim argument,
+//   - 's': optional star.
+func (p *parser) parseMacroArgs(node *ast.Macro, sig string) {
+	for _, typ := range strings.ToLower(sig) {
 		switch typ {
 		case 'a':
 			p.parseMacroArg(node)
 		case 'o':
 			p.parseOptMacroArg(node)
+		case 't':
+			p.parseTextMacroArg(node)
 		case 'v':
 			p.parseVerbatimMacroArg(node)
+		case 's':
+			p.parseStar(node)
+		}
+	}
+}
+
+// mathDelimMacro parses \( and \[ math expressions.
+// In math mode, the delimiter is parsed as a builtin macro.
+type mathDelimMacro string
+
+func (m mathDelimMacro) parseMacro(p *parser) ast.Node {
+	if p.state == mathState {
+		return builtinMacro(m).parseMacro(p)
+	}
+	return p.parseMathExpr(p.s.tok)
+}
+
+// inputMacro parses \input, \include and \subfile commands.
+// When the parser reads from a file system, the named file is scanned
+// right after the command.
+type inputMacro struct{}
+
+func (inputMacro) parseMacro(p *parser) ast.Node {
+	node := builtinMacro("V").parseMacro(p).(*ast.Macro)
+	if p.fsys != nil {
+		p.include(node)
+	}
+	return node
+}
+
+// envMacro parses \begin{env} ... \end{env} environments.
+type envMacro struct{}
+
+func (envMacro) parseMacro(p *parser) ast.Node {
+	return p.parseEnv(p.s.tok)
+}
+
+type endMacro struct{}
+
+func (endMacro) parseMacro(p *parser) ast.Node {
+	env := p.parseEnvName()
+	panic(fmt.Errorf(`unexpected \end{%s}`, env.Name))
+}
+
+// pkgMacro parses \documentclass and \usepackage commands.
+type pkgMacro struct{}
+
+func (pkgMacro) parseMacro(p *parser) ast.Node {
+	node := &ast.Macro{
+		Name: &ast.Ident{
+			NamePos: p.s.tok.Pos,
+			Name:    p.s.tok.Text,
+		},
+	}
+	p.parseOptVerbatimMacroArg(node)
+	p.parseVerbatimMacroArg(node)
+	return node
+}
+
+// defMacro parses macro and environment definitions, and registers the
+// defined macros with the parser.
+//
+// ex:
+//
+//	\newcommand{\R}{\mathbb{R}}
+//	\newcommand\norm[2][2]{\|#2\|_{#1}}
+//	\def\half#1{\frac{#1}{2}}
+//	\DeclareMathOperator*{\argmax}{arg\,max}
+//	\newenvironment{proof}[1][Proof]{\textit{#1.}}{\qed}
+type defMacro struct{}
+
+func (defMacro) parseMacro(p *parser) ast.Node {
+	node := &ast.Macro{
+		Name: &ast.Ident{
+			NamePos: p.s.tok.Pos,
+			Name:    p.s.tok.Text,
+		},
+	}
+	p.parseStar(node)
+
+	var (
+		kind = strings.TrimSuffix(node.Name.Name, "*")
+		name string
+		sig  string
+	)
+	switch kind {
+	case `\newenvironment`, `\renewenvironment`:
+		p.skipSpaces()
+		p.parseVerbatimMacroArg(node)
+		name = node.Args[0].(*ast.Arg).List[0].(*ast.Word).Text
+		sig = p.parseDefParams(node)
+		p.skipSpaces()
+		p.parseMacroArg(node) // begin code
+		p.skipSpaces()
+		p.parseMacroArg(node) // end code
+		p.envs[name] = sig
+		return node
+
+	case `\def`, `\gdef`, `\edef`, `\xdef`:
+		name = p.parseDefName(node)
+		n := 0
+		for p.s.sc.Peek() != '{' {
+			tok := p.next()
+			switch tok.Kind {
+			case token.EOF:
+				panic(fmt.Errorf("invalid definition of %s", name))
+			case token.Symbol:
+				if tok.Text == "#" {
+					n++
+				}
+			}
 		}
+		sig = strings.Repeat("A", n)
+
+	case `\DeclareMathOperator`:
+		name = p.parseDefName(node)
+
+	default:
+		name = p.parseDefName(node)
+		sig = p.parseDefParams(node)
 	}
 
+	p.skipSpaces()
+	p.parseMacroArg(node) // body
+	p.macros[name] = builtinMacro(sig)
 	return node
 }
+
+// parseDefName parses the name of the macro being defined, with or
+// without braces.
+func (p *parser) parseDefName(macro *ast.Macro) string {
+	var arg ast.Arg
+	p.skipSpaces()
+	tok := p.next()
+	brace := tok.Kind == token.Lbrace
+	if brace {
+		arg.Lbrace = tok.Pos
+		p.skipSpaces()
+		tok = p.next()
+	}
+	if tok.Kind != token.Macro {
+		panic(fmt.Errorf("invalid macro name %q", tok.Text))
+	}
+	arg.List = ast.List{&ast.Macro{
+		Name: &ast.Ident{
+			NamePos: tok.Pos,
+			Name:    tok.Text,
+		},
+	}}
+	switch {
+	case brace:
+		p.skipSpaces()
+		p.expect('}')
+		arg.Rbrace = p.s.tok.Pos
+	default:
+		arg.Lbrace = tok.Pos
+		arg.Rbrace = tok.Pos
+	}
+	macro.Args = append(macro.Args, &arg)
+	return tok.Text
+}
+
+// skipSpaces skips the spaces between the arguments of a definition.
+func (p *parser) skipSpaces() {
+	for p.s.sc.Peek() == ' ' {
+		p.next()
+	}
+}
+
+// parseDefParams parses the number of parameters of a definition and its
+// optional default value, and returns the signature of the defined macro.
+func (p *parser) parseDefParams(macro *ast.Macro) string {
+	n := len(macro.Args)
+	p.skipSpaces()
+	p.parseOptMacroArg(macro)
+	if len(macro.Args) == n {
+		return ""
+	}
+	var (
+		opt    = macro.Args[n].(*ast.OptArg)
+		params = 0
+	)
+	if len(opt.List) == 1 {
+		if lit, ok := opt.List[0].(*ast.Literal); ok {
+			params, _ = strconv.Atoi(lit.Text)
+		}
+	}
+
+	p.skipSpaces()
+	p.parseOptMacroArg(macro)
+	if len(macro.Args) == n+1 || params == 0 {
+		return strings.Repeat("A", params)
+	}
+	// the first parameter is optional.
+	return "O" + strings.Repeat("A", params-1)
+}
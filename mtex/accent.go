@@ -0,0 +1,114 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+
+	"github.com/go-latex/latex/tex"
+)
+
+// mathAccent describes a math accent.
+type mathAccent struct {
+	sym   string // spacing glyph of the accent
+	wide  bool   // whether the accent stretches to the width of its nucleus
+	small bool   // whether the glyph is set in script size
+}
+
+// accents are the math accents.
+// Accents are drawn with spacing glyphs, rather than combining ones, as
+// they are more commonly available in fonts.
+var accents = map[string]mathAccent{
+	`\hat`:       {sym: "ˆ"},
+	`\check`:     {sym: "ˇ"},
+	`\tilde`:     {sym: "˜"},
+	`\bar`:       {sym: "¯"},
+	`\vec`:       {sym: "→", small: true},
+	`\dot`:       {sym: "˙"},
+	`\ddot`:      {sym: "¨"},
+	`\acute`:     {sym: "´"},
+	`\grave`:     {sym: "`"},
+	`\breve`:     {sym: "˘"},
+	`\widehat`:   {sym: "ˆ", wide: true},
+	`\widetilde`: {sym: "˜", wide: true},
+}
+
+const (
+	// maxWideAccent is the maximum scale factor of wide accents.
+	maxWideAccent = 3
+
+	// slantSlope is the slope of the glyphs of slanted fonts.
+	slantSlope = 0.2
+)
+
+// accent puts the named accent over the nucleus, following the rule 12 of
+// Appendix G of the TeXbook.
+// The accent is centered over the nucleus, moved right by its skew, and
+// sits above the nucleus or above the x-height, whichever is higher.
+func (p *parser) accent(name string, nucleus tex.Node, state tex.State) tex.Node {
+	var (
+		acc       = accents[name]
+		xheight   = p.be.XHeight(state.Font, state.DPI)
+		thickness = p.be.UnderlineThickness(state.Font, state.DPI)
+		body      = tex.HListOf([]tex.Node{nucleus}, true)
+		width     = body.Width()
+	)
+
+	state.Font.Type = "rm"
+	glyph := tex.NewAccent(acc.sym, state, true)
+	if acc.wide && glyph.Width() < width {
+		state.Font.Size *= math.Min(width/glyph.Width(), maxWideAccent)
+		glyph = tex.NewAccent(acc.sym, state, true)
+	}
+	if acc.small {
+		glyph.Shrink()
+	}
+
+	skew := skewOf(nucleus)
+	top := tex.HCentered([]tex.Node{tex.NewKern(2 * skew), glyph})
+	const additional = false // i.e.: exactly
+	top.HPack(width, additional)
+
+	gap := math.Max(body.Height(), xheight) - body.Height() + 2*thickness
+	return tex.VListOf([]tex.Node{top, tex.NewKern(gap), body})
+}
+
+// skewOf returns the horizontal offset of the center of the top of the
+// nucleus, relatively to the center of its box.
+// It is non-zero for slanted characters, and for accented boxes or lists
+// made of such a character.
+func skewOf(node tex.Node) float64 {
+	switch node := node.(type) {
+	case *tex.Char:
+		if !node.Slanted() {
+			return 0
+		}
+		return slantSlope * node.Height() / 2
+	case *tex.VList:
+		// an accented box: its nucleus is the last node.
+		nodes := node.Nodes()
+		if len(nodes) == 0 {
+			return 0
+		}
+		return skewOf(nodes[len(nodes)-1])
+	case *tex.HList:
+		var inner tex.Node
+		for _, n := range node.Nodes() {
+			switch n.(type) {
+			case *tex.Kern, *tex.Glue:
+				continue
+			}
+			if inner != nil {
+				return 0
+			}
+			inner = n
+		}
+		if inner == nil {
+			return 0
+		}
+		return skewOf(inner)
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"testing"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/drawtex"
+	"github.com/go-latex/latex/font"
+	"github.com/go-latex/latex/font/ttf"
+	"github.com/go-latex/latex/tex"
+)
+
+func TestAccent(t *testing.T) {
+	be := ttf.New(drawtex.New())
+
+	for _, tc := range []struct {
+		expr string
+		same string // expression with the same dimensions
+	}{
+		{expr: `$\hat x$`, same: `$\hat{x}$`},
+		{expr: `$\hat\bar x$`, same: `$\hat{\bar{x}}$`},
+		{expr: `$\hat xy$`, same: `$\hat{x}y$`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			var (
+				got  = mustParse(t, tc.expr)
+				want = mustParse(t, tc.same)
+			)
+			if got, want := got.Width(), want.Width(); !approxEq(got, want) {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+			if got, want := got.Height(), want.Height(); !approxEq(got, want) {
+				t.Fatalf("invalid height: got=%g, want=%g", got, want)
+			}
+		})
+	}
+
+	for _, name := range []string{`\hat`, `\check`, `\tilde`, `\bar`, `\vec`, `\dot`, `\ddot`, `\acute`, `\grave`, `\breve`} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				x   = mustParse(t, `$x$`)
+				acc = mustParse(t, `$`+name+`{x}$`)
+			)
+			if got, want := acc.Width(), x.Width(); got != want {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+			if !(acc.Height() > x.Height()) {
+				t.Fatalf("accent not above its nucleus: height=%g, want>%g", acc.Height(), x.Height())
+			}
+		})
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		var (
+			one = mustParse(t, `$\hat{x}$`)
+			two = mustParse(t, `$\hat{\bar{x}}$`)
+		)
+		if !(two.Height() > one.Height()) {
+			t.Fatalf("nested accents not stacked: height=%g, want>%g", two.Height(), one.Height())
+		}
+	})
+
+	t.Run("wide", func(t *testing.T) {
+		var (
+			p     = newParser(be)
+			state = tex.NewState(be, font.Font{Name: "default", Size: ftsize, Type: "it"}, dpi)
+			body  = p.handleNode(&ast.Word{Text: "xyz"}, state, true, textStyle)
+		)
+		glyph := func(name string) float64 {
+			box := p.accent(name, body, state).(*tex.VList)
+			for _, node := range box.Nodes()[0].(*tex.HList).Nodes() {
+				if acc, ok := node.(*tex.Accent); ok {
+					return acc.Width()
+				}
+			}
+			t.Fatalf("no accent glyph for %s", name)
+			return 0
+		}
+		if !(glyph(`\widehat`) > glyph(`\hat`)) {
+			t.Fatalf("wide accent not stretched: %g <= %g", glyph(`\widehat`), glyph(`\hat`))
+		}
+	})
+
+	t.Run("skew", func(t *testing.T) {
+		state := tex.NewState(be, font.Font{Name: "default", Size: ftsize, Type: "it"}, dpi)
+		x := tex.NewChar("x", state, true)
+		if skewOf(x) <= 0 {
+			t.Fatalf("slanted glyph not skewed: %g", skewOf(x))
+		}
+		p := newParser(be)
+		if got, want := skewOf(p.accent(`\bar`, x, state)), skewOf(x); got != want {
+			t.Fatalf("invalid skew of accented glyph: got=%g, want=%g", got, want)
+		}
+		state.Font.Type = "rm"
+		if got := skewOf(tex.NewChar("1", state, true)); got != 0 {
+			t.Fatalf("upright glyph skewed: %g", got)
+		}
+	})
+}
@@ -0,0 +1,341 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// alignKind describes how the lines of a display environment are aligned.
+type alignKind int
+
+const (
+	alignPairs    alignKind = iota // pairs of right- and left-aligned columns
+	alignCenter                    // centered lines
+	alignMultline                  // first line on the left, last line on the right
+)
+
+// displayEnv describes a multi-line display environment.
+type displayEnv struct {
+	kind     alignKind
+	inner    bool    // whether the environment is part of a formula, as aligned
+	sep      float64 // space between pairs of columns, in em
+	numbered bool    // whether equations are numbered
+	single   bool    // whether the whole display is a single equation
+}
+
+// displayEnvs are the multi-line display environments.
+var displayEnvs = map[string]displayEnv{
+	"equation":  {kind: alignCenter, numbered: true, single: true},
+	"equation*": {kind: alignCenter, single: true},
+	"align":     {kind: alignPairs, sep: 2, numbered: true},
+	"align*":    {kind: alignPairs, sep: 2},
+	"alignat":   {kind: alignPairs, numbered: true},
+	"alignat*":  {kind: alignPairs},
+	"gather":    {kind: alignCenter, numbered: true},
+	"gather*":   {kind: alignCenter},
+	"multline":  {kind: alignMultline, numbered: true, single: true},
+	"multline*": {kind: alignMultline, single: true},
+	"aligned":   {kind: alignPairs, sep: 2, inner: true},
+	"gathered":  {kind: alignCenter, inner: true},
+	"split":     {kind: alignPairs, inner: true},
+}
+
+const (
+	// jot is the extra space between the lines of a display environment,
+	// relatively to the font size (\jot).
+	jot = 0.3
+
+	// lineSkip is the minimal space between the lines of a display
+	// environment, relatively to the font size (\lineskip).
+	lineSkip = 0.1
+)
+
+// display typesets a multi-line display environment, such as align or
+// gather, in the provided style.
+// Lines are broken on \\, with an optional extra space as in \\[2pt], and
+// cells of aligned environments are separated by &.
+// Inner environments, such as aligned, are centered on the axis.
+func (p *parser) display(env *ast.Env, state tex.State, style mathStyleKind) tex.Node {
+	name := env.Name.Name
+	denv, ok := displayEnvs[name]
+	if !ok {
+		panic(fmt.Errorf("unsupported environment %q", name))
+	}
+	if !denv.inner {
+		// outer environments start a display math formula.
+		state.Font.Type = rcparams("mathtext.default").(string)
+	}
+
+	var (
+		lines [][]ast.List
+		skips []float64 // extra space after each line
+		tags  []eqTag   // tag of each line
+		line  []ast.List
+		cell  ast.List
+		tag   eqTag
+	)
+	for _, node := range env.List {
+		switch node := node.(type) {
+		case *ast.Symbol:
+			if node.Text == "&" {
+				// only aligned environments have alignment points.
+				if denv.kind == alignPairs {
+					line = append(line, cell)
+					cell = nil
+				}
+				continue
+			}
+		case *ast.Macro:
+			switch node.Name.Name {
+			case `\\`, `\\*`:
+				var skip float64
+				for _, arg := range node.Args {
+					if arg, ok := arg.(*ast.OptArg); ok {
+						skip = p.length(arg.List, state)
+					}
+				}
+				lines = append(lines, append(line, cell))
+				skips = append(skips, skip)
+				tags = append(tags, tag)
+				line, cell, tag = nil, nil, eqTag{}
+				continue
+			}
+			if !denv.inner && tag.tagMacro(node) {
+				continue
+			}
+		}
+		cell = append(cell, node)
+	}
+	// a trailing \\ does not start a new line.
+	if len(line) > 0 || len(cell) > 0 || len(lines) == 0 {
+		lines = append(lines, append(line, cell))
+		skips = append(skips, 0)
+		tags = append(tags, tag)
+	} else {
+		tags[len(tags)-1] = tags[len(tags)-1].merge(tag)
+	}
+
+	// cells.
+	var (
+		boxes  = make([][]tex.Node, len(lines))
+		widths []float64
+	)
+	for i, line := range lines {
+		boxes[i] = make([]tex.Node, len(line))
+		for j, cell := range line {
+			if j%2 == 1 {
+				// the right-hand side of a pair starts with an empty
+				// ordinary atom, so relations and binary operations are
+				// spaced as in the middle of a formula.
+				cell = append(ast.List{ast.List{}}, cell...)
+			}
+			boxes[i][j] = p.handleNode(cell, state, true, displayStyle)
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = math.Max(widths[j], boxes[i][j].Width())
+		}
+	}
+
+	// lines.
+	var (
+		width float64
+		sep   = p.makeSpace(state, denv.sep).Width()
+		rows  = make([]tex.Node, len(lines))
+	)
+	for j, w := range widths {
+		width += w
+		if j > 0 && j%2 == 0 {
+			width += sep
+		}
+	}
+	for i, line := range boxes {
+		switch denv.kind {
+		case alignPairs:
+			var nodes []tex.Node
+			for j, w := range widths {
+				if j > 0 && j%2 == 0 {
+					nodes = append(nodes, tex.NewKern(sep))
+				}
+				if j >= len(line) {
+					nodes = append(nodes, tex.HBox(w))
+					continue
+				}
+				align := 'r'
+				if j%2 == 1 {
+					align = 'l'
+				}
+				nodes = append(nodes, alignCell(line[j], w, align))
+			}
+			rows[i] = tex.HListOf(nodes, false)
+		default:
+			align := 'c'
+			if denv.kind == alignMultline && len(lines) > 1 {
+				switch i {
+				case 0:
+					align = 'l'
+				case len(lines) - 1:
+					align = 'r'
+				}
+			}
+			rows[i] = alignCell(line[0], width, align)
+		}
+	}
+
+	if !denv.inner {
+		rows = p.tagLines(rows, tags, denv, state)
+	}
+
+	vlist := p.stackLines(rows, skips, state)
+	if !denv.inner {
+		return vlist
+	}
+	vlist.SetShift((vlist.Height()-vlist.Depth())/2 - p.axisHeight(state))
+	box := tex.HListOf([]tex.Node{vlist}, false)
+	resize(box, displayStyle, style)
+	return box
+}
+
+// stackLines stacks lines of a display, with the extra space after each
+// line.
+// As in TeX, baselines are \baselineskip apart, unless lines would then be
+// closer than \lineskip, and lines are \jot further apart.
+func (p *parser) stackLines(lines []tex.Node, skips []float64, state tex.State) *tex.VList {
+	var (
+		size  = state.Font.Size * state.DPI / 72
+		nodes = make([]tex.Node, 0, 2*len(lines))
+	)
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			gap := baselineSkip*size - prev.Depth() - line.Height()
+			if gap < 0 {
+				gap = lineSkip * size
+			}
+			nodes = append(nodes, tex.NewKern(gap+jot*size+skips[i-1]))
+		}
+		nodes = append(nodes, line)
+	}
+	return tex.VListOf(nodes)
+}
+
+// length returns the length described by the nodes, as in 2pt or 1.5em.
+func (p *parser) length(nodes ast.List, state tex.State) float64 {
+	v, unit, err := lengthOf(nodes)
+	if err != nil {
+		// lengths are checked by checkLengths.
+		panic(err)
+	}
+
+	size := state.Font.Size * state.DPI / 72
+	switch unit {
+	case "pt", "bp":
+		return v * state.DPI / 72
+	case "mm":
+		return v * state.DPI / 25.4
+	case "cm":
+		return v * state.DPI / 2.54
+	case "in":
+		return v * state.DPI
+	case "pc":
+		return v * 12 * state.DPI / 72
+	case "em":
+		return v * size
+	case "ex":
+		return v * p.be.XHeight(state.Font, state.DPI)
+	case "mu":
+		return v * size / 18
+	case `\baselineskip`:
+		return v * baselineSkip * size
+	case `\jot`:
+		return v * jot * size
+	default: // \lineskip
+		return v * lineSkip * size
+	}
+}
+
+// lengthOf returns the value and the unit of the length described by the
+// nodes.
+// The unit may be a length register, as in 2\jot.
+func lengthOf(nodes ast.List) (float64, string, error) {
+	var unit string
+	if n := len(nodes); n > 0 {
+		if m, ok := nodes[n-1].(*ast.Macro); ok && lengthRegisters[m.Name.Name] {
+			unit, nodes = m.Name.Name, nodes[:n-1]
+		}
+	}
+
+	var err error
+	ast.Inspect(nodes, func(node ast.Node) bool {
+		if node, ok := node.(*ast.Macro); ok && err == nil {
+			err = fmt.Errorf("unsupported length %s", node.Name.Name)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	txt := strings.TrimSpace(textOf(nodes))
+	if unit != "" {
+		if txt == "" {
+			return 1, unit, nil
+		}
+		v, err := strconv.ParseFloat(txt, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid length %q", txt+unit)
+		}
+		return v, unit, nil
+	}
+
+	i := strings.LastIndexAny(txt, "0123456789.") + 1
+	v, err := strconv.ParseFloat(txt[:i], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid length %q", txt)
+	}
+
+	switch unit := strings.TrimSpace(txt[i:]); unit {
+	case "pt", "bp", "mm", "cm", "in", "pc", "em", "ex", "mu":
+		return v, unit, nil
+	default:
+		return 0, "", fmt.Errorf("invalid length %q: unknown unit %q", txt, unit)
+	}
+}
+
+// lengthRegisters are the length registers that may be used as units.
+var lengthRegisters = map[string]bool{
+	`\baselineskip`: true,
+	`\jot`:          true,
+	`\lineskip`:     true,
+}
+
+// checkLengths checks the lengths of the extra space after the lines of
+// displays, as in \\[2pt].
+func checkLengths(node ast.Node) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		macro, ok := node.(*ast.Macro)
+		if !ok || err != nil {
+			return err == nil
+		}
+		switch macro.Name.Name {
+		case `\\`, `\\*`:
+			for _, arg := range macro.Args {
+				if arg, ok := arg.(*ast.OptArg); ok {
+					_, _, err = lengthOf(arg.List)
+				}
+			}
+		}
+		return err == nil
+	})
+	return err
+}
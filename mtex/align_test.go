@@ -0,0 +1,167 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex/internal/fakebackend"
+	"github.com/go-latex/latex/tex"
+)
+
+func TestDisplay(t *testing.T) {
+	const skip = (baselineSkip + jot) * ftsize // distance between baselines
+	be := fakebackend.New()
+	a := mustParseWith(t, be, `$a$`)
+
+	for _, tc := range []struct {
+		expr string
+		gap  float64 // extra distance between the baselines of the lines
+	}{
+		{expr: `\begin{gather*} a \\ a \end{gather*}`},
+		{expr: `\begin{gather*} a \\ a \\ \end{gather*}`},
+		{expr: `\begin{align*} a &= a \\ a &= a \end{align*}`},
+		{expr: `\begin{align*} a &= a \\[2pt] a &= a \end{align*}`, gap: 2},
+		{expr: `\begin{multline*} a \\[0.5em] a \end{multline*}`, gap: 5},
+		{expr: `\begin{gather*} a \\[-1mm] a \end{gather*}`, gap: -72 / 25.4},
+		{expr: `\begin{gather*} a \\[2\jot] a \end{gather*}`, gap: 2 * jot * ftsize},
+		{expr: `\begin{gather*} a \\[\baselineskip] a \end{gather*}`, gap: baselineSkip * ftsize},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			box := mustParseWith(t, be, tc.expr)
+			if _, ok := box.(*tex.VList); !ok {
+				t.Fatalf("invalid display: got=%T, want=*tex.VList", box)
+			}
+			var (
+				got  = box.Height() + box.Depth()
+				want = a.Height() + skip + tc.gap + a.Depth()
+			)
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("invalid size: got=%g, want=%g", got, want)
+			}
+		})
+	}
+
+	t.Run("lineskip", func(t *testing.T) {
+		var (
+			box  = mustParseWith(t, be, `\begin{gather*} \frac{a}{a} \\ \frac{a}{a} \end{gather*}`)
+			frac = mustParseWith(t, be, `\[\frac{a}{a}\]`)
+			got  = box.Height() + box.Depth()
+			want = 2*(frac.Height()+frac.Depth()) + (lineSkip+jot)*ftsize
+		)
+		if math.Abs(got-want) > 1e-9 {
+			t.Fatalf("invalid size: got=%g, want=%g", got, want)
+		}
+	})
+
+	t.Run("columns", func(t *testing.T) {
+		var (
+			pairs  = mustParseWith(t, be, `\begin{align*} a &= a & a &= a \end{align*}`)
+			pairat = mustParseWith(t, be, `\begin{alignat*}{2} a &= a & a &= a \end{alignat*}`)
+			pair   = mustParseWith(t, be, `\begin{alignat*}{1} a &= a \end{alignat*}`)
+			qquad  = mustParseWith(t, be, `\[\qquad\]`)
+		)
+		if got, want := pairat.Width(), 2*pair.Width(); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("invalid alignat width: got=%g, want=%g", got, want)
+		}
+		if got, want := pairs.Width(), pairat.Width()+qquad.Width(); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("invalid align width: got=%g, want=%g", got, want)
+		}
+	})
+}
+
+func TestDisplayAlign(t *testing.T) {
+	for _, expr := range []string{
+		`\begin{align} f(x) &= (x+1)^2 \\ &= x^2 + 2x + 1 \\ \sum_i x_i &= y \end{align}`,
+		`\begin{equation} \begin{split} a &= b + c \\ &= d \end{split} \end{equation}`,
+		`$\begin{aligned} a &= b \\ c + d &= e \end{aligned}$`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			eqs := glyphOps(mustRender(t, expr), "=")
+			if len(eqs) < 2 {
+				t.Fatalf("missing relations: %v", eqs)
+			}
+			for _, eq := range eqs[1:] {
+				if eq.X != eqs[0].X {
+					t.Fatalf("relations not aligned: %v", eqs)
+				}
+				if !(eq.Y > eqs[0].Y) {
+					t.Fatalf("lines not stacked: %v", eqs)
+				}
+			}
+		})
+	}
+
+	t.Run("multline", func(t *testing.T) {
+		var (
+			ops = mustRender(t, `\begin{multline} a + b + c \\ d \\ e + f + g + h \end{multline}`)
+			a   = glyphOps(ops, "a")[0]
+			d   = glyphOps(ops, "d")[0]
+			e   = glyphOps(ops, "e")[0]
+		)
+		if a.X != 0 || !(a.X < d.X) || e.X != 0 {
+			t.Fatalf("invalid multline: a=%v, d=%v, e=%v", a, d, e)
+		}
+	})
+
+	t.Run("cases", func(t *testing.T) {
+		var (
+			cases = "$\\begin{cases} 1 & x > 0 \\\\ 0 & x \\leq 0 \\end{cases}$"
+			box   = mustParse(t, cases)
+			paren = mustParse(t, `$\{$`)
+		)
+		if !(box.Height()+box.Depth() > paren.Height()+paren.Depth()) {
+			t.Fatalf("brace not sized: %g", box.Height()+box.Depth())
+		}
+		ops := mustRender(t, cases)
+		if len(glyphOps(ops, "{")) != 1 {
+			t.Fatalf("missing brace: %v", ops)
+		}
+		var (
+			one  = glyphOps(ops, "1")[0]
+			zero = glyphOps(ops, "0")[1] // the 0 of the second line
+			xs   = glyphOps(ops, "x")
+		)
+		if one.X != zero.X || xs[0].X != xs[1].X {
+			t.Fatalf("cells not left-aligned: %v", ops)
+		}
+	})
+}
+
+func TestDisplaySkipError(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{
+			expr: `\begin{align}a\\[x]b\end{align}`,
+			want: `invalid length "x"`,
+		},
+		{
+			expr: `\begin{gather}a\\[2pc pt]b\end{gather}`,
+			want: `invalid length "2pcpt": unknown unit "pcpt"`,
+		},
+		{
+			expr: `\begin{align}a\\[x\jot]b\end{align}`,
+			want: `invalid length "x\\jot"`,
+		},
+		{
+			expr: `\begin{align}a\\[\quad]b\end{align}`,
+			want: `unsupported length \quad`,
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr, ftsize, dpi, nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; !strings.HasSuffix(got, want) {
+				t.Fatalf("invalid error:\ngot= %s\nwant=%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// xarrows are the extensible arrows, such as \xrightarrow, with the
+// extensible symbol they are drawn with.
+var xarrows = map[string]string{
+	`\xrightarrow`:     `\rightarrow`,
+	`\xleftarrow`:      `\leftarrow`,
+	`\xleftrightarrow`: `\leftrightarrow`,
+	`\xmapsto`:         `\rightarrow`,
+}
+
+// stretchyArrow describes an arrow stretched over or under its argument.
+type stretchyArrow struct {
+	sym  string // extensible symbol of the arrow
+	over bool   // whether the arrow is over its argument
+}
+
+// stretchyArrows are the arrows stretched over or under their argument,
+// such as \overrightarrow.
+var stretchyArrows = map[string]stretchyArrow{
+	`\overrightarrow`:      {sym: `\rightarrow`, over: true},
+	`\overleftarrow`:       {sym: `\leftarrow`, over: true},
+	`\overleftrightarrow`:  {sym: `\leftrightarrow`, over: true},
+	`\underrightarrow`:     {sym: `\rightarrow`},
+	`\underleftarrow`:      {sym: `\leftarrow`},
+	`\underleftrightarrow`: {sym: `\leftrightarrow`},
+}
+
+// xarrowPad is the space on each side of the labels of an extensible
+// arrow, in em.
+const xarrowPad = 0.4
+
+// handleXArrow typesets an extensible arrow, as \xrightarrow[below]{above}.
+// The arrow stretches to the width of its labels, which are set in script
+// style and stacked above and below it as the limits of a large operator,
+// as with amsmath.
+func handleXArrow(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		x, z  tex.Node // labels above and below the arrow
+		width float64
+	)
+	label := func(list ast.List, style mathStyleKind, to mathStyleKind) tex.Node {
+		box := p.handleNode(list, state, true, style)
+		resize(box, style, to)
+		width = max(width, box.Width())
+		return box
+	}
+	for _, arg := range macro.Args {
+		switch arg := arg.(type) {
+		case *ast.OptArg:
+			z = label(arg.List, style.sub(), style)
+		case *ast.Arg:
+			x = label(arg.List, style.sup(), style)
+		}
+	}
+
+	var (
+		thickness = p.be.UnderlineThickness(state.Font, state.DPI)
+		pad       = p.makeSpace(state, xarrowPad).Width()
+		arrow     = tex.Node(tex.AutoWidthChar(xarrows[macro.Name.Name], width+2*pad, state))
+	)
+	if macro.Name.Name == `\xmapsto` {
+		// the tail of \mapsto is a bar across the shaft of the arrow.
+		bar := tex.NewRule(thickness, arrow.Height(), arrow.Depth(), state)
+		arrow = tex.HListOf([]tex.Node{bar, arrow}, false)
+	}
+	return p.stackLimits(arrow, x, z, 0, state)
+}
+
+// handleStretchyArrow puts an arrow over or under the argument of a macro,
+// such as \overrightarrow{AB}, stretched to the width of the argument.
+// As with amsmath, the arrow is set in script size.
+func handleStretchyArrow(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		sa    = stretchyArrows[macro.Name.Name]
+		arg   = ast.List(macro.Args[0].(*ast.Arg).List)
+	)
+	if sa.over {
+		style = style.cramped()
+	}
+	var (
+		body      = p.handleNode(arg, state, math, style)
+		thickness = p.be.UnderlineThickness(state.Font, state.DPI)
+		small     = state
+	)
+	small.Font.Size *= shrinkFactor
+	arrow := tex.AutoWidthChar(sa.sym, body.Width(), small)
+
+	if sa.over {
+		// arrows are drawn above the baseline, around the axis: their
+		// box is deeper than their glyphs.
+		lift := max(0, -tex.NewChar(sa.sym, small, true).Depth())
+		return tex.VListOf([]tex.Node{arrow, tex.NewKern(thickness - lift), body})
+	}
+	box := tex.VListOf([]tex.Node{body, tex.NewKern(thickness), arrow})
+	box.SetShift(body.Depth() + thickness + arrow.Height())
+	return tex.HListOf([]tex.Node{box}, true)
+}
@@ -0,0 +1,82 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import "testing"
+
+func TestArrows(t *testing.T) {
+	t.Run("extensible", func(t *testing.T) {
+		var (
+			empty = mustParse(t, `$\xrightarrow{}$`)
+			short = mustParse(t, `$\xrightarrow{f}$`)
+			long  = mustParse(t, `$\xrightarrow{f \circ g \circ h}$`)
+			below = mustParse(t, `$\xrightarrow[f \circ g \circ h]{}$`)
+			label = mustParse(t, `$x^{f \circ g \circ h}$`).Width() - mustParse(t, `$x$`).Width()
+		)
+		if !(long.Width() > short.Width()) || !(long.Width() > label) {
+			t.Fatalf("arrow not stretched: short=%g, long=%g, label=%g", short.Width(), long.Width(), label)
+		}
+		if !approxEq(long.Width(), below.Width()) {
+			t.Fatalf("invalid width: above=%g, below=%g", long.Width(), below.Width())
+		}
+		if !(long.Height() > empty.Height()) || !approxEq(long.Depth(), empty.Depth()) {
+			t.Fatalf("invalid label above: h=%g, d=%g", long.Height(), long.Depth())
+		}
+		if !approxEq(below.Height(), empty.Height()) || !(below.Depth() > empty.Depth()) {
+			t.Fatalf("invalid label below: h=%g, d=%g", below.Height(), below.Depth())
+		}
+		for _, name := range []string{`\xleftarrow`, `\xleftrightarrow`} {
+			box := mustParse(t, `$`+name+`{f \circ g \circ h}$`)
+			if !approxEq(box.Width(), long.Width()) {
+				t.Fatalf("%s: invalid width: got=%g, want=%g", name, box.Width(), long.Width())
+			}
+		}
+		if mapsto := mustParse(t, `$\xmapsto{f \circ g \circ h}$`); !(mapsto.Width() > long.Width()) {
+			t.Fatalf("missing mapsto bar: w=%g", mapsto.Width())
+		}
+
+		// extensible arrows are relations.
+		var (
+			got  = mustParse(t, `$a\xrightarrow{f}b$`).Width() - short.Width()
+			want = mustParse(t, `$a=b$`).Width() - mustParse(t, `$=$`).Width()
+		)
+		if !approxEq(got, want) {
+			t.Fatalf("invalid spacing: got=%g, want=%g", got, want)
+		}
+	})
+
+	t.Run("stretchy", func(t *testing.T) {
+		body := mustParse(t, `$ABCD$`)
+		for _, name := range []string{`\overrightarrow`, `\overleftarrow`, `\overleftrightarrow`} {
+			box := mustParse(t, `$`+name+`{ABCD}$`)
+			if !approxEq(box.Width(), body.Width()) {
+				t.Fatalf("%s: invalid width: got=%g, want=%g", name, box.Width(), body.Width())
+			}
+			if !(box.Height() > body.Height()) || !approxEq(box.Depth(), body.Depth()) {
+				t.Fatalf("%s: invalid arrow: h=%g, d=%g", name, box.Height(), box.Depth())
+			}
+		}
+		for _, name := range []string{`\underrightarrow`, `\underleftarrow`, `\underleftrightarrow`} {
+			box := mustParse(t, `$`+name+`{ABCD}$`)
+			if !approxEq(box.Width(), body.Width()) {
+				t.Fatalf("%s: invalid width: got=%g, want=%g", name, box.Width(), body.Width())
+			}
+			if !approxEq(box.Height(), body.Height()) || !(box.Depth() > body.Depth()) {
+				t.Fatalf("%s: invalid arrow: h=%g, d=%g", name, box.Height(), box.Depth())
+			}
+		}
+	})
+
+	t.Run("assembly", func(t *testing.T) {
+		var (
+			ops   = mustRender(t, `$\xrightarrow{f \circ g \circ h \circ k}$`)
+			heads = len(glyphOps(ops, "→"))
+			bars  = len(glyphOps(ops, "−"))
+		)
+		if heads != 1 || bars < 2 {
+			t.Fatalf("invalid assembly: heads=%d, bars=%d", heads, bars)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/mtex/symbols"
+	"github.com/go-latex/latex/tex"
+)
+
+// atomClass is the TeX class of a math atom.
+// The class of an atom drives the spacing with its neighbours.
+type atomClass int
+
+const (
+	ordAtom   atomClass = iota // ordinary: x, 1, \alpha
+	opAtom                     // large operator: \sum, \sin
+	binAtom                    // binary operation: +, \times
+	relAtom                    // relation: =, \leq, \to
+	openAtom                   // opening delimiter: (, \langle
+	closeAtom                  // closing delimiter: ), \rangle, !
+	punctAtom                  // punctuation: ,, ;
+	innerAtom                  // delimited sub-formula: \frac{a}{b}
+
+	noAtom atomClass = -1 // not an atom, e.g. an explicit space.
+)
+
+// atom is a node of a math list, together with its TeX class.
+// The node is the nucleus of the atom, sized for the style of the atom.
+type atom struct {
+	class atomClass
+	node  tex.Node
+	style mathStyleKind
+
+	sub    ast.Node   // subscript, if any
+	sup    ast.Node   // superscript, if any
+	limits limitsKind // placement of the scripts of an operator
+}
+
+// classOf returns the TeX class of the named symbol or macro.
+func classOf(name string) atomClass {
+	switch name {
+	case `\frac`, `\dfrac`, `\tfrac`, `\binom`:
+		return innerAtom
+	case `\dots`, `\ldots`, `\cdots`:
+		return innerAtom
+	case `\hspace`:
+		return noAtom
+	case `\stackrel`, `\xrightarrow`, `\xleftarrow`, `\xleftrightarrow`, `\xmapsto`:
+		return relAtom
+	case `\overbrace`, `\underbrace`:
+		return opAtom
+	case ".", "/", "|":
+		return ordAtom
+	case "!", "?":
+		return closeAtom
+	}
+	if _, ok := spaceWidth[name]; ok {
+		return noAtom
+	}
+
+	switch {
+	case symbols.PunctuationSymbols.Has(name):
+		return punctAtom
+	case symbols.BinaryOperators.Has(name):
+		return binAtom
+	case symbols.RelationSymbols.Has(name), symbols.ArrowSymbols.Has(name):
+		return relAtom
+	case symbols.LeftDelim.Has(name):
+		return openAtom
+	case symbols.RightDelim.Has(name):
+		return closeAtom
+	case symbols.OverUnderSymbols.Has(name), symbols.DropSubSymbols.Has(name):
+		return opAtom
+	case len(name) > 1 && symbols.FunctionNames.Has(name[1:]): // drop leading `\`
+		return opAtom
+	}
+	return ordAtom
+}
+
+// mathSpacing is the inter-atom spacing table of TeX (see TeX: The Program,
+// node764), indexed by the classes of the left and right atoms:
+//
+//	0: no space
+//	1: conditional thin space
+//	2: thin space
+//	3: conditional medium space
+//	4: conditional thick space
+//	*: impossible
+//
+// Conditional spaces are not inserted in script and scriptscript styles.
+var mathSpacing = [8]string{
+	ordAtom:   "02340001",
+	opAtom:    "22*40001",
+	binAtom:   "33**3**3",
+	relAtom:   "44*04004",
+	openAtom:  "00*00000",
+	closeAtom: "02340001",
+	punctAtom: "11*11111",
+	innerAtom: "12341011",
+}
+
+// spacing returns the space, in mu, between two atoms of the provided
+// classes, in the provided style.
+func spacing(left, right atomClass, style mathStyleKind) float64 {
+	var (
+		mu     float64
+		script = style >= scriptStyle
+	)
+	switch mathSpacing[left][right] {
+	case '1':
+		if !script {
+			mu = 3
+		}
+	case '2':
+		mu = 3
+	case '3':
+		if !script {
+			mu = 4
+		}
+	case '4':
+		if !script {
+			mu = 5
+		}
+	}
+	return mu
+}
+
+// reclassify changes binary atoms into ordinary ones where TeX does so,
+// e.g. for a unary minus (see TeX: The Program, node728 and node729):
+// a binary atom must be preceded by an ordinary, closing or inner atom,
+// and followed by an atom that is not a relation, a closing atom or a
+// punctuation.
+func reclassify(atoms []atom) {
+	prev := -1
+	for i := range atoms {
+		a := &atoms[i]
+		switch a.class {
+		case noAtom:
+			continue
+		case binAtom:
+			if prev < 0 {
+				a.class = ordAtom
+				break
+			}
+			switch atoms[prev].class {
+			case binAtom, opAtom, relAtom, openAtom, punctAtom:
+				a.class = ordAtom
+			}
+		case relAtom, closeAtom, punctAtom:
+			if prev >= 0 && atoms[prev].class == binAtom {
+				atoms[prev].class = ordAtom
+			}
+		}
+		prev = i
+	}
+	if prev >= 0 && atoms[prev].class == binAtom {
+		atoms[prev].class = ordAtom
+	}
+}
+
+// hlist converts a math list into a list of nodes, inserting the TeX
+// inter-atom spacing.
+// The spacing is sized for the provided style of the list.
+func (p *parser) hlist(atoms []atom, style mathStyleKind, state tex.State) []tex.Node {
+	reclassify(atoms)
+
+	var (
+		nodes = make([]tex.Node, 0, len(atoms))
+		prev  = noAtom
+	)
+	for _, a := range atoms {
+		switch {
+		case a.class == opAtom:
+			a.node = p.operator(a, state)
+		case a.sub != nil || a.sup != nil:
+			var delta float64
+			if ch, ok := a.node.(*tex.Char); ok {
+				delta = ch.Italic()
+			}
+			a.node = p.scripts(a, delta, state)
+		}
+		resize(a.node, a.style, style)
+		if a.class == noAtom {
+			nodes = append(nodes, a.node)
+			continue
+		}
+		if prev != noAtom {
+			if mu := spacing(prev, a.class, a.style); mu != 0 {
+				space := p.makeSpace(state, mu/18)
+				resize(space, a.style, style)
+				nodes = append(nodes, space)
+			}
+		}
+		nodes = append(nodes, a.node)
+		prev = a.class
+	}
+	return nodes
+}
@@ -0,0 +1,136 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassOf(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want atomClass
+	}{
+		{"x", ordAtom},
+		{`\alpha`, ordAtom},
+		{".", ordAtom},
+		{"+", binAtom},
+		{`\times`, binAtom},
+		{"=", relAtom},
+		{"<", relAtom},
+		{`\rightarrow`, relAtom},
+		{"(", openAtom},
+		{`\langle`, openAtom},
+		{")", closeAtom},
+		{"!", closeAtom},
+		{",", punctAtom},
+		{";", punctAtom},
+		{`\sum`, opAtom},
+		{`\int`, opAtom},
+		{`\sin`, opAtom},
+		{`\frac`, innerAtom},
+		{`\ldots`, innerAtom},
+		{`\,`, noAtom},
+		{`\hspace`, noAtom},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := classOf(tc.name), tc.want; got != want {
+				t.Fatalf("invalid class: got=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestReclassify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []atomClass
+		want []atomClass
+	}{
+		{
+			name: "a-b",
+			in:   []atomClass{ordAtom, binAtom, ordAtom},
+			want: []atomClass{ordAtom, binAtom, ordAtom},
+		},
+		{
+			name: "-a",
+			in:   []atomClass{binAtom, ordAtom},
+			want: []atomClass{ordAtom, ordAtom},
+		},
+		{
+			name: "(-a)",
+			in:   []atomClass{openAtom, binAtom, ordAtom, closeAtom},
+			want: []atomClass{openAtom, ordAtom, ordAtom, closeAtom},
+		},
+		{
+			name: "a=-b",
+			in:   []atomClass{ordAtom, relAtom, binAtom, ordAtom},
+			want: []atomClass{ordAtom, relAtom, ordAtom, ordAtom},
+		},
+		{
+			name: "a+=b",
+			in:   []atomClass{ordAtom, binAtom, relAtom, ordAtom},
+			want: []atomClass{ordAtom, ordAtom, relAtom, ordAtom},
+		},
+		{
+			name: "a+",
+			in:   []atomClass{ordAtom, binAtom},
+			want: []atomClass{ordAtom, ordAtom},
+		},
+		{
+			name: `a\,-\,b`,
+			in:   []atomClass{ordAtom, noAtom, binAtom, noAtom, ordAtom},
+			want: []atomClass{ordAtom, noAtom, binAtom, noAtom, ordAtom},
+		},
+		{
+			name: `\sum-a`,
+			in:   []atomClass{opAtom, binAtom, ordAtom},
+			want: []atomClass{opAtom, ordAtom, ordAtom},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atoms := make([]atom, len(tc.in))
+			for i, c := range tc.in {
+				atoms[i].class = c
+			}
+			reclassify(atoms)
+			got := make([]atomClass, len(atoms))
+			for i, a := range atoms {
+				got[i] = a.class
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid classes:\ngot= %v\nwant=%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSpacing(t *testing.T) {
+	for _, tc := range []struct {
+		left, right atomClass
+		style       mathStyleKind
+		want        float64
+	}{
+		{ordAtom, ordAtom, textStyle, 0},
+		{ordAtom, opAtom, textStyle, 3},
+		{opAtom, ordAtom, scriptStyle, 3},
+		{ordAtom, binAtom, textStyle, 4},
+		{ordAtom, binAtom, scriptStyle, 0},
+		{ordAtom, relAtom, displayStyle, 5},
+		{relAtom, ordAtom, scriptScriptStyle, 0},
+		{relAtom, relAtom, textStyle, 0},
+		{openAtom, ordAtom, textStyle, 0},
+		{ordAtom, closeAtom, textStyle, 0},
+		{punctAtom, ordAtom, textStyle, 3},
+		{punctAtom, ordAtom, scriptStyle, 0},
+		{innerAtom, ordAtom, textStyle, 3},
+		{closeAtom, innerAtom, textStyle, 3},
+	} {
+		if got, want := spacing(tc.left, tc.right, tc.style), tc.want; got != want {
+			t.Errorf("invalid spacing between %d and %d (style=%d): got=%v, want=%v", tc.left, tc.right, tc.style, got, want)
+		}
+	}
+}
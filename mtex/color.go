@@ -0,0 +1,246 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// fboxsep is the space between the content of a \colorbox and the edges
+// of its background, in points.
+const fboxsep = 3
+
+// namedColors are the base colors of the xcolor package.
+var namedColors = map[string]color.Color{
+	"black":     rgb(0, 0, 0),
+	"white":     rgb(1, 1, 1),
+	"red":       rgb(1, 0, 0),
+	"green":     rgb(0, 1, 0),
+	"blue":      rgb(0, 0, 1),
+	"cyan":      rgb(0, 1, 1),
+	"magenta":   rgb(1, 0, 1),
+	"yellow":    rgb(1, 1, 0),
+	"gray":      rgb(0.5, 0.5, 0.5),
+	"darkgray":  rgb(0.25, 0.25, 0.25),
+	"lightgray": rgb(0.75, 0.75, 0.75),
+	"brown":     rgb(0.75, 0.5, 0.25),
+	"lime":      rgb(0.75, 1, 0),
+	"olive":     rgb(0.5, 0.5, 0),
+	"orange":    rgb(1, 0.5, 0),
+	"pink":      rgb(1, 0.75, 0.75),
+	"purple":    rgb(0.75, 0, 0.25),
+	"teal":      rgb(0, 0.5, 0.5),
+	"violet":    rgb(0.5, 0, 0.5),
+}
+
+// rgb returns the opaque color with the provided components, in [0,1].
+func rgb(r, g, b float64) color.Color {
+	c := func(v float64) uint8 {
+		return uint8(min(max(v, 0), 1)*255 + 0.5)
+	}
+	return color.NRGBA{R: c(r), G: c(g), B: c(b), A: 255}
+}
+
+// resolveColors resolves the colors of the color macros of the tree, in
+// the order of the expression, and registers the colors defined with
+// \definecolor.
+// Unknown colors and invalid specifications are reported before the
+// expression is typeset.
+func (p *parser) resolveColors(node ast.Node) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		macro, ok := node.(*ast.Macro)
+		if !ok || err != nil {
+			return err == nil
+		}
+		switch macro.Name.Name {
+		case `\definecolor`:
+			err = p.defineColor(macro)
+		case `\color`, `\textcolor`, `\colorbox`:
+			var c color.Color
+			c, err = p.colorOf(macro)
+			if err != nil {
+				err = fmt.Errorf("invalid %s color: %w", macro.Name.Name, err)
+			}
+			p.macroColors[macro] = c
+		}
+		return err == nil
+	})
+	return err
+}
+
+// colorOf returns the color of the arguments of a color macro, such as
+// \color[model]{spec} or \textcolor{name}{...}: the specification of the
+// color in the model of the optional argument, or the name of a color
+// otherwise.
+func (p *parser) colorOf(macro *ast.Macro) (color.Color, error) {
+	var model string
+	if opt, ok := macro.Args[0].(*ast.OptArg); ok {
+		model = strings.TrimSpace(textOf(opt.List))
+	}
+	for _, arg := range macro.Args {
+		if arg, ok := arg.(*ast.Arg); ok {
+			spec := strings.TrimSpace(textOf(arg.List))
+			if model == "" {
+				return p.namedColor(spec)
+			}
+			return parseColor(model, spec)
+		}
+	}
+	return nil, fmt.Errorf("missing color")
+}
+
+// namedColor returns the color of an xcolor expression: the name of a
+// color, possibly mixed with other colors as in red!30 or red!30!blue.
+// A name mixed with a percentage of p and nothing else is mixed with
+// (100-p)% of white.
+func (p *parser) namedColor(expr string) (color.Color, error) {
+	lookup := func(name string) (color.Color, error) {
+		if c, ok := p.colors[name]; ok {
+			return c, nil
+		}
+		if c, ok := namedColors[name]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("unknown color %q", name)
+	}
+
+	parts := strings.Split(expr, "!")
+	c, err := lookup(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(parts); i += 2 {
+		pct, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil || pct < 0 || pct > 100 {
+			return nil, fmt.Errorf("invalid color expression %q", expr)
+		}
+		other := namedColors["white"]
+		if i+1 < len(parts) {
+			other, err = lookup(parts[i+1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		c = mix(c, other, pct/100)
+	}
+	return c, nil
+}
+
+// mix returns the color made of the fraction f of c1 and 1-f of c2.
+func mix(c1, c2 color.Color, f float64) color.Color {
+	var (
+		v1 = color.NRGBAModel.Convert(c1).(color.NRGBA)
+		v2 = color.NRGBAModel.Convert(c2).(color.NRGBA)
+		mx = func(a, b uint8) float64 {
+			return (f*float64(a) + (1-f)*float64(b)) / 255
+		}
+	)
+	return rgb(mx(v1.R, v2.R), mx(v1.G, v2.G), mx(v1.B, v2.B))
+}
+
+// parseColor returns the color of the specification spec in the provided
+// color model: rgb, RGB, HTML, gray or cmyk.
+func parseColor(model, spec string) (color.Color, error) {
+	var (
+		n     int   // number of components
+		scale = 1.0 // maximum value of a component
+	)
+	switch model {
+	case "rgb":
+		n = 3
+	case "RGB":
+		n, scale = 3, 255
+	case "gray":
+		n = 1
+	case "cmyk":
+		n = 4
+	case "HTML":
+		v, err := strconv.ParseUint(spec, 16, 32)
+		if err != nil || len(spec) != 6 {
+			return nil, fmt.Errorf("invalid %s color %q", model, spec)
+		}
+		return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+	default:
+		return nil, fmt.Errorf("unknown color model %q", model)
+	}
+
+	fields := strings.Split(spec, ",")
+	if len(fields) != n {
+		return nil, fmt.Errorf("invalid %s color %q", model, spec)
+	}
+	vs := make([]float64, n)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || v < 0 || v > scale {
+			return nil, fmt.Errorf("invalid %s color %q", model, spec)
+		}
+		vs[i] = v / scale
+	}
+
+	switch model {
+	case "gray":
+		return rgb(vs[0], vs[0], vs[0]), nil
+	case "cmyk":
+		k := 1 - vs[3]
+		return rgb((1-vs[0])*k, (1-vs[1])*k, (1-vs[2])*k), nil
+	default:
+		return rgb(vs[0], vs[1], vs[2]), nil
+	}
+}
+
+// defineColor registers the color defined by \definecolor{name}{model}{spec}.
+func (p *parser) defineColor(macro *ast.Macro) error {
+	var args [3]string
+	for i := range args {
+		args[i] = strings.TrimSpace(textOf(macro.Args[i].(*ast.Arg).List))
+	}
+	c, err := parseColor(args[1], args[2])
+	if err != nil {
+		return fmt.Errorf("could not define color %q: %w", args[0], err)
+	}
+	p.colors[args[0]] = c
+	return nil
+}
+
+// handleTextColor typesets the last argument of \textcolor in the color
+// given by the other ones.
+func handleTextColor(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	macro := node.(*ast.Macro)
+	state.Color = p.macroColors[macro]
+	arg := ast.List(macro.Args[len(macro.Args)-1].(*ast.Arg).List)
+	return p.handleNode(arg, state, math, style)
+}
+
+// handleColorBox typesets the last argument of \colorbox in text mode, as
+// \mbox, over a background in the color given by the other ones.
+// As with LaTeX, the background extends by \fboxsep around the text, and
+// math material, such as x_i, goes in $...$ (see checkTextArgs).
+func handleColorBox(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	if math {
+		state.Font.Type, _ = textFontOf(`\mbox`)
+	}
+	var (
+		macro = node.(*ast.Macro)
+		arg   = ast.List(macro.Args[len(macro.Args)-1].(*ast.Arg).List)
+		body  = p.handleNode(arg, state, false, style)
+		sep   = fboxsep * state.DPI / 72
+		bg    = state
+	)
+	bg.Color = p.macroColors[macro]
+	var (
+		w    = body.Width() + 2*sep
+		back = tex.NewRule(w, body.Height()+sep, body.Depth()+sep, bg)
+	)
+	return tex.HListOf([]tex.Node{
+		back, tex.NewKern(sep - w), body, tex.NewKern(sep),
+	}, false)
+}
@@ -0,0 +1,249 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex/drawtex"
+)
+
+func TestParseColor(t *testing.T) {
+	p := newParser(nil)
+	p.colors["mine"] = rgb(0.2, 0.4, 0.6)
+
+	for _, tc := range []struct {
+		model string
+		spec  string
+		want  color.NRGBA
+	}{
+		{spec: "red", want: color.NRGBA{255, 0, 0, 255}},
+		{spec: "lightgray", want: color.NRGBA{191, 191, 191, 255}},
+		{spec: "mine", want: color.NRGBA{51, 102, 153, 255}},
+		{spec: "red!30", want: color.NRGBA{255, 179, 179, 255}},
+		{spec: "red!30!blue", want: color.NRGBA{77, 0, 179, 255}},
+		{spec: "red!50!blue!50", want: color.NRGBA{192, 128, 192, 255}},
+		{spec: "black!100", want: color.NRGBA{0, 0, 0, 255}},
+		{model: "rgb", spec: "1, 0.5,0", want: color.NRGBA{255, 128, 0, 255}},
+		{model: "RGB", spec: "0,128,255", want: color.NRGBA{0, 128, 255, 255}},
+		{model: "HTML", spec: "00FF7f", want: color.NRGBA{0, 255, 127, 255}},
+		{model: "gray", spec: "0.25", want: color.NRGBA{64, 64, 64, 255}},
+		{model: "cmyk", spec: "0,1,1,0.5", want: color.NRGBA{128, 0, 0, 255}},
+	} {
+		t.Run(tc.model+":"+tc.spec, func(t *testing.T) {
+			var (
+				got color.Color
+				err error
+			)
+			switch tc.model {
+			case "":
+				got, err = p.namedColor(tc.spec)
+			default:
+				got, err = parseColor(tc.model, tc.spec)
+			}
+			if err != nil {
+				t.Fatalf("could not parse color: %+v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("invalid color: got=%v, want=%v", got, tc.want)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		model string
+		spec  string
+		want  string
+	}{
+		{spec: "reddish", want: `unknown color "reddish"`},
+		{spec: "red!x", want: `invalid color expression "red!x"`},
+		{spec: "red!130", want: `invalid color expression "red!130"`},
+		{model: "rgb", spec: "1,0", want: `invalid rgb color "1,0"`},
+		{model: "RGB", spec: "0,0,256", want: `invalid RGB color "0,0,256"`},
+		{model: "HTML", spec: "FFF", want: `invalid HTML color "FFF"`},
+		{model: "hsb", spec: "0,1,1", want: `unknown color model "hsb"`},
+	} {
+		t.Run(tc.model+":"+tc.spec, func(t *testing.T) {
+			var err error
+			switch tc.model {
+			case "":
+				_, err = p.namedColor(tc.spec)
+			default:
+				_, err = parseColor(tc.model, tc.spec)
+			}
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; got != want {
+				t.Fatalf("invalid error:\ngot= %s\nwant=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestColorError(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{
+			expr: `$\textcolor{nonexistent}{x}$`,
+			want: `invalid \textcolor color: unknown color "nonexistent"`,
+		},
+		{
+			expr: `$\color{red!x} x$`,
+			want: `invalid \color color: invalid color expression "red!x"`,
+		},
+		{
+			expr: `$\colorbox[rgb]{2,0,0}{x}$`,
+			want: `invalid \colorbox color: invalid rgb color "2,0,0"`,
+		},
+		{
+			expr: `$\definecolor{mine}{hsb}{0,1,1} x$`,
+			want: `could not define color "mine": unknown color model "hsb"`,
+		},
+		{
+			// colors are defined before their use.
+			expr: `$\textcolor{mine}{x} \definecolor{mine}{rgb}{0,0,1}$`,
+			want: `invalid \textcolor color: unknown color "mine"`,
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr, ftsize, dpi, nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; !strings.HasSuffix(got, want) {
+				t.Fatalf("invalid error:\ngot= %s\nwant=%s", got, want)
+			}
+			err = Render(&recorder{}, tc.expr, ftsize, dpi, nil)
+			if err == nil {
+				t.Fatalf("expected a render error")
+			}
+		})
+	}
+}
+
+func TestColor(t *testing.T) {
+	var (
+		black  = color.Black
+		red    = namedColors["red"]
+		green  = namedColors["green"]
+		blue   = namedColors["blue"]
+		yellow = namedColors["yellow"]
+		mine   = rgb(0.2, 0.4, 0.6)
+	)
+
+	for _, tc := range []struct {
+		expr string
+		want []color.Color // colors of the glyphs
+	}{
+		{
+			expr: `$a\color{red}b{\color{blue}c}d\textcolor{green}{e}f$`,
+			want: []color.Color{black, red, blue, red, green, red},
+		},
+		{
+			expr: `$\textcolor[rgb]{0,1,0}{a}b$`,
+			want: []color.Color{green, black},
+		},
+		{
+			expr: `$\definecolor{mine}{rgb}{0.2,0.4,0.6}\color{mine}a$`,
+			want: []color.Color{mine},
+		},
+		{
+			expr: `a {\color{red} b} c`,
+			want: []color.Color{black, red, black},
+		},
+		{
+			expr: `$\colorbox{yellow}{a}\color{red}b$`,
+			want: []color.Color{black, red},
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			var got []color.Color
+			for _, op := range mustRender(t, tc.expr) {
+				if op, ok := op.(drawtex.GlyphOp); ok && op.Glyph.Symbol != " " {
+					got = append(got, op.Color)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("invalid number of glyphs: got=%d, want=%d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if !sameColor(got[i], tc.want[i]) {
+					t.Fatalf("invalid color for glyph #%d: got=%v, want=%v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		var got []color.Color
+		for _, op := range mustRender(t, `$\frac{1}{2}\color{blue}\sqrt{x}$`) {
+			if op, ok := op.(drawtex.RectOp); ok {
+				got = append(got, op.Color)
+			}
+		}
+		want := []color.Color{black, blue}
+		if len(got) != len(want) || !sameColor(got[0], want[0]) || !sameColor(got[1], want[1]) {
+			t.Fatalf("invalid rule colors: got=%v, want=%v", got, want)
+		}
+	})
+
+	t.Run("colorbox", func(t *testing.T) {
+		var (
+			ops   = mustRender(t, `$\colorbox{yellow}{ab}$`)
+			sep   = float64(fboxsep)
+			glyph []drawtex.GlyphOp
+			rect  []drawtex.RectOp
+		)
+		for _, op := range ops {
+			switch op := op.(type) {
+			case drawtex.GlyphOp:
+				glyph = append(glyph, op)
+			case drawtex.RectOp:
+				rect = append(rect, op)
+			}
+		}
+		if len(rect) != 1 || len(glyph) != 2 {
+			t.Fatalf("invalid ops: %v", ops)
+		}
+		if _, ok := ops[0].(drawtex.RectOp); !ok {
+			t.Fatalf("background not drawn first")
+		}
+		bg := rect[0]
+		if !sameColor(bg.Color, yellow) {
+			t.Fatalf("invalid background color: got=%v, want=%v", bg.Color, yellow)
+		}
+		if got, want := glyph[0].X-bg.X1, sep; !approxEq(got, want) {
+			t.Fatalf("invalid left padding: got=%g, want=%g", got, want)
+		}
+		if !(bg.X2 > glyph[1].X) || !(bg.Y2 > glyph[0].Y) {
+			t.Fatalf("background does not cover the text: %+v", bg)
+		}
+	})
+
+	t.Run("colorbox-math", func(t *testing.T) {
+		var (
+			ops = mustRender(t, `$\colorbox{yellow}{$x_i$}$`)
+			x   = glyphOps(ops, "x")
+			i   = glyphOps(ops, "i")
+		)
+		if len(x) != 1 || len(i) != 1 {
+			t.Fatalf("invalid glyphs: %v", glyphOps(ops))
+		}
+		if !(i[0].Y > x[0].Y) {
+			t.Fatalf("subscript not lowered: x=%g, i=%g", x[0].Y, i[0].Y)
+		}
+	})
+}
+
+func sameColor(c1, c2 color.Color) bool {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, a2 := c2.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
@@ -0,0 +1,120 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/mtex/symbols"
+	"github.com/go-latex/latex/tex"
+)
+
+// fontOf returns the font type selected by a font macro, such as \bf, or by
+// a math alphabet macro, such as \mathbf, and whether the macro is such
+// a macro.
+func fontOf(name string) (string, bool) {
+	var font string
+	switch {
+	case strings.HasPrefix(name, `\math`):
+		font = strings.TrimPrefix(name, `\math`)
+	case strings.HasPrefix(name, `\`):
+		font = strings.TrimPrefix(name, `\`)
+	default:
+		return "", false
+	}
+	if !symbols.FontNames.Has(font) {
+		return "", false
+	}
+	if font == "default" {
+		font = rcparams("mathtext.default").(string)
+	}
+	return font, true
+}
+
+// handleMathFont typesets the argument of a math alphabet macro, such as
+// \mathbf{x} or \mathbb{R}, in the font of that alphabet.
+func handleMathFont(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	macro := node.(*ast.Macro)
+	state.Font.Type, _ = fontOf(macro.Name.Name)
+	arg := ast.List(macro.Args[0].(*ast.Arg).List)
+	return p.handleNode(arg, state, math, style)
+}
+
+// textFonts are the font types selected by the text macros that are not
+// named after their font, such as \text or \textup.
+var textFonts = map[string]string{
+	`\text`:       "rm",
+	`\mbox`:       "rm",
+	`\textnormal`: "rm",
+	`\textup`:     "rm",
+	`\textmd`:     "rm",
+	`\textsl`:     "it",
+}
+
+// textFontOf returns the font type selected by a text macro, such as \text
+// or \textbf, and whether the macro is such a macro.
+func textFontOf(name string) (string, bool) {
+	if font, ok := textFonts[name]; ok {
+		return font, true
+	}
+	if !strings.HasPrefix(name, `\text`) {
+		return "", false
+	}
+	return fontOf(`\` + strings.TrimPrefix(name, `\text`))
+}
+
+// handleText typesets the argument of a text macro, such as \text{if} or
+// \textbf{and}, in text mode: spaces are kept and $...$ switches back to
+// math mode.
+func handleText(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	macro := node.(*ast.Macro)
+	state.Font.Type, _ = textFontOf(macro.Name.Name)
+	arg := ast.List(macro.Args[0].(*ast.Arg).List)
+	return p.handleNode(arg, state, false, style)
+}
+
+// checkTextArgs reports the scripts in the text-mode arguments of macros
+// such as \text{...} or \colorbox{...}{...}: as with LaTeX, scripts are
+// only allowed in math mode, as in \colorbox{yellow}{$x_i$}.
+func checkTextArgs(node ast.Node) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		macro, ok := node.(*ast.Macro)
+		if !ok || err != nil || len(macro.Args) == 0 || !isTextMacro(macro.Name.Name) {
+			return err == nil
+		}
+		arg, ok := macro.Args[len(macro.Args)-1].(*ast.Arg)
+		if !ok {
+			return true
+		}
+		ast.Inspect(arg, func(node ast.Node) bool {
+			switch node.(type) {
+			case *ast.MathExpr:
+				return false
+			case *ast.Sub, *ast.Sup:
+				if err == nil {
+					err = fmt.Errorf("scripts are not allowed in the text of %s, outside of $...$", macro.Name.Name)
+				}
+				return false
+			}
+			return true
+		})
+		return err == nil
+	})
+	return err
+}
+
+// isTextMacro returns whether the last argument of the named macro is
+// typeset in text mode.
+func isTextMacro(name string) bool {
+	switch name {
+	case `\colorbox`, `\fbox`, `\framebox`:
+		return true
+	}
+	_, ok := textFontOf(name)
+	return ok
+}
@@ -0,0 +1,121 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-fonts/dejavu/dejavusans"
+	"github.com/go-fonts/dejavu/dejavusansoblique"
+	"github.com/go-latex/latex/font/ttf"
+	"github.com/go-latex/latex/internal/fakebackend"
+	"golang.org/x/image/font/sfnt"
+)
+
+func TestMathFont(t *testing.T) {
+	parse := func(raw []byte) *sfnt.Font {
+		ft, err := sfnt.Parse(raw)
+		if err != nil {
+			t.Fatalf("could not parse font: %+v", err)
+		}
+		return ft
+	}
+	dejavu := &ttf.Fonts{
+		Default: parse(dejavusans.TTF),
+		Rm:      parse(dejavusans.TTF),
+		It:      parse(dejavusansoblique.TTF),
+		Bf:      parse(dejavusans.TTF),
+	}
+
+	for _, tc := range []struct {
+		expr  string
+		fonts *ttf.Fonts
+		want  string
+	}{
+		// DejaVu Sans has double-struck and sans-serif glyphs.
+		{expr: `$\mathbb{R}$`, fonts: dejavu, want: "ℝ"},
+		{expr: `$\mathbb{A1}$`, fonts: dejavu, want: "𝔸𝟙"},
+		{expr: `$\mathsf{a}$`, fonts: dejavu, want: "𝖺"},
+		{expr: `$\mathcal{L}$`, fonts: dejavu, want: "ℒ"},
+		{expr: `$\mathbb{R}^n$`, fonts: dejavu, want: "ℝn"},
+		{expr: `${\bb R} x$`, fonts: dejavu, want: "ℝx"},
+		// fallback to the font of the family.
+		{expr: `$\mathbf{x}$`, fonts: dejavu, want: "x"},
+		{expr: `$\mathfrak{g}$`, fonts: dejavu, want: "g"},
+		{expr: `$\mathbb{R}$`, want: "R"},
+		{expr: `$\mathbf{x}+\mathrm{d}x$`, want: "x+dx"},
+		// text macros.
+		{expr: `$x \text{if $y$ odd}$`, want: "xifyodd"},
+		{expr: `$\textbf{a b}+\textsf{c}$`, want: "ab+c"},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			var dst recorder
+			err := Render(&dst, tc.expr, ftsize, dpi, tc.fonts)
+			if err != nil {
+				t.Fatalf("could not render %q: %+v", tc.expr, err)
+			}
+			got := ""
+			for _, g := range glyphOps(dst.ops) {
+				got += g.Glyph.Symbol
+			}
+			if got != tc.want {
+				t.Fatalf("invalid glyphs: got=%q, want=%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	const space = 3.1787109375
+	be := fakebackend.New()
+
+	for _, tc := range []struct {
+		expr string
+		same string
+		diff float64
+	}{
+		// spaces are dropped in math mode, but not in text mode.
+		{expr: `$a b$`, same: `$ab$`},
+		{expr: `$\text{a b}$`, same: `$\text{ab}$`, diff: space},
+		{expr: `$\mbox{a  b}$`, same: `$\mbox{ab}$`, diff: 2 * space},
+		{expr: `$x\text{ if }x$`, same: `$x\text{if}x$`, diff: 2 * space},
+		// nested math mode.
+		{expr: `$\text{a $b c$}$`, same: `$\text{a }bc$`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			got := mustParseWith(t, be, tc.expr).Width()
+			want := mustParseWith(t, be, tc.same).Width() + tc.diff
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+		})
+	}
+}
+
+func TestTextScripts(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{expr: `$\colorbox{yellow}{x_i}$`, want: `scripts are not allowed in the text of \colorbox, outside of $...$`},
+		{expr: `$\text{x^2}$`, want: `scripts are not allowed in the text of \text, outside of $...$`},
+		{expr: `\fbox{a {b_c}}`, want: `scripts are not allowed in the text of \fbox, outside of $...$`},
+		{expr: `$\colorbox{yellow}{$x_i$} \text{if $x^2$}$`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr, ftsize, dpi, fakebackend.New())
+			switch {
+			case tc.want == "" && err != nil:
+				t.Fatalf("could not parse %q: %+v", tc.expr, err)
+			case tc.want != "" && err == nil:
+				t.Fatalf("expected an error")
+			case tc.want != "" && !strings.HasSuffix(err.Error(), tc.want):
+				t.Fatalf("invalid error:\ngot= %s\nwant=%s", err, tc.want)
+			}
+		})
+	}
+}
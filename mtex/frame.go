@@ -0,0 +1,167 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"fmt"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// framePad is the space between a frame and its content, in line
+// thicknesses (about \fboxsep at 10pt).
+const framePad = 5
+
+// handleBoxed frames the argument of \boxed, typeset in display style as
+// with amsmath.
+func handleBoxed(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		arg   = ast.List(macro.Args[0].(*ast.Arg).List)
+		body  = p.handleNode(arg, state, true, displayStyle)
+	)
+	resize(body, displayStyle, style)
+	return p.frame(body, state)
+}
+
+// handleFrameBox frames the last argument of \fbox or \framebox, typeset
+// in text mode as \mbox.
+// As with LaTeX, the optional arguments of \framebox set the width of the
+// text and its position, l, c or r, within that width.
+func handleFrameBox(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro            = node.(*ast.Macro)
+		length, pos, err = frameOptions(macro)
+		width            = -1.0
+	)
+	if err != nil {
+		// options are checked by checkFrames.
+		panic(err)
+	}
+	if length != nil {
+		width = p.length(length, state)
+	}
+	if math {
+		state.Font.Type, _ = textFontOf(`\mbox`)
+	}
+	var (
+		arg  = ast.List(macro.Args[len(macro.Args)-1].(*ast.Arg).List)
+		body = tex.Node(p.handleNode(arg, state, false, style))
+	)
+	if width >= 0 {
+		var (
+			room        = width - body.Width()
+			left, right float64
+		)
+		switch pos {
+		case "l":
+			right = room
+		case "r":
+			left = room
+		default: // c or s
+			left, right = room/2, room/2
+		}
+		body = tex.HListOf([]tex.Node{tex.NewKern(left), body, tex.NewKern(right)}, false)
+	}
+	return p.frame(body, state)
+}
+
+// frameOptions returns the width and the position given by the optional
+// arguments of \framebox.
+// The width is nil when it is not given; the position defaults to c.
+func frameOptions(macro *ast.Macro) (width ast.List, pos string, err error) {
+	pos = "c"
+	for i, arg := range macro.Args {
+		opt, ok := arg.(*ast.OptArg)
+		if !ok {
+			break
+		}
+		switch i {
+		case 0:
+			width = opt.List
+			_, _, err = lengthOf(width)
+			if err != nil {
+				return nil, "", err
+			}
+		default:
+			pos = textOf(opt.List)
+		}
+	}
+	switch pos {
+	case "l", "r", "c", "s":
+		return width, pos, nil
+	default:
+		return nil, "", fmt.Errorf("invalid %s position %q", macro.Name.Name, pos)
+	}
+}
+
+// checkFrames checks the width and the position of the frames of
+// \framebox, as in \framebox[1in][l]{x}.
+func checkFrames(node ast.Node) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		macro, ok := node.(*ast.Macro)
+		if !ok || err != nil {
+			return err == nil
+		}
+		if macro.Name.Name == `\framebox` {
+			_, _, err = frameOptions(macro)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// frame returns the body surrounded by a frame.
+// The line thickness of the frame is the one of a fraction bar.
+func (p *parser) frame(body tex.Node, state tex.State) tex.Node {
+	var (
+		thickness = p.be.UnderlineThickness(state.Font, state.DPI)
+		sep       = (framePad + 1) * thickness // from the outer edge of the frame
+		w         = body.Width() + 2*sep
+		frame     = tex.NewStroke(
+			tex.StrokeFrame,
+			w, body.Height()+sep, body.Depth()+sep,
+			thickness, state,
+		)
+	)
+	return tex.HListOf([]tex.Node{
+		frame, tex.NewKern(sep - w), body, tex.NewKern(sep),
+	}, false)
+}
+
+// handleCancel strikes out the argument of \cancel with a rising diagonal,
+// the one of \bcancel with a falling diagonal, and the one of \xcancel with
+// both diagonals, as with the cancel package.
+func handleCancel(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		arg   = ast.List(macro.Args[0].(*ast.Arg).List)
+		body  = p.handleNode(arg, state, math, style)
+		kinds []tex.StrokeKind
+	)
+	switch macro.Name.Name {
+	case `\cancel`:
+		kinds = []tex.StrokeKind{tex.StrokeRising}
+	case `\bcancel`:
+		kinds = []tex.StrokeKind{tex.StrokeFalling}
+	default:
+		kinds = []tex.StrokeKind{tex.StrokeRising, tex.StrokeFalling}
+	}
+
+	var (
+		thickness = p.be.UnderlineThickness(state.Font, state.DPI)
+		w, h, d   = body.Width(), body.Height(), body.Depth()
+		nodes     = []tex.Node{body}
+	)
+	for _, kind := range kinds {
+		nodes = append(nodes,
+			tex.NewKern(-w),
+			tex.NewStroke(kind, w, h, d, thickness, state),
+		)
+	}
+	return tex.HListOf(nodes, false)
+}
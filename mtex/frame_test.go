@@ -0,0 +1,143 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex/drawtex"
+	"github.com/go-latex/latex/font"
+	"github.com/go-latex/latex/font/ttf"
+)
+
+func TestFrame(t *testing.T) {
+	var (
+		be        = ttf.New(drawtex.New())
+		thickness = be.UnderlineThickness(font.Font{Name: "default", Size: ftsize, Type: "rm"}, dpi)
+		sep       = (framePad + 1) * thickness
+	)
+
+	for _, tc := range []struct {
+		expr  string
+		body  string  // expression of the framed material
+		width float64 // width of the framed material, if not the one of body
+		rects int     // number of stroked rectangles
+		lines int     // number of lines
+	}{
+		{expr: `$\boxed{x=\frac{a}{b}}$`, body: `$\displaystyle x=\frac{a}{b}$`, rects: 1},
+		{expr: `$\fbox{a b}$`, body: `$\text{a b}$`, rects: 1},
+		{expr: `\fbox{a b}`, body: `a b`, rects: 1},
+		{expr: `$\framebox{a b}$`, body: `$\text{a b}$`, rects: 1},
+		{expr: `$\framebox[1in][l]{a b}$`, body: `$\text{a b}$`, width: 72, rects: 1},
+		{expr: `$\framebox[1in][r]{a b}$`, body: `$\text{a b}$`, width: 72, rects: 1},
+		{expr: `$\cancel{xy}$`, body: `$xy$`, lines: 1},
+		{expr: `$\bcancel{xy}$`, body: `$xy$`, lines: 1},
+		{expr: `$\xcancel{xy}$`, body: `$xy$`, lines: 2},
+		{expr: `$\boxed{\cancel{xy}}$`, body: `$xy$`, rects: 1, lines: 1},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			var (
+				box  = mustParse(t, tc.expr)
+				body = mustParse(t, tc.body)
+				w    = body.Width()
+				h, d = body.Height(), body.Depth()
+			)
+			if tc.width != 0 {
+				w = tc.width
+			}
+			if tc.rects > 0 {
+				w += 2 * sep
+				h += sep
+				d += sep
+			}
+			if got, want := box.Width(), w; !approxEq(got, want) {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+			if got, want := box.Height(), h; !approxEq(got, want) {
+				t.Fatalf("invalid height: got=%g, want=%g", got, want)
+			}
+			if got, want := box.Depth(), d; !approxEq(got, want) {
+				t.Fatalf("invalid depth: got=%g, want=%g", got, want)
+			}
+
+			var rects, lines int
+			for _, op := range mustRender(t, tc.expr) {
+				switch op := op.(type) {
+				case drawtex.StrokedRectOp:
+					rects++
+					if !approxEq(op.Width, thickness) {
+						t.Fatalf("invalid frame thickness: got=%g, want=%g", op.Width, thickness)
+					}
+					// the frame is stroked within the box.
+					if got, want := op.X2-op.X1, w-thickness; !approxEq(got, want) {
+						t.Fatalf("invalid frame width: got=%g, want=%g", got, want)
+					}
+					if got, want := op.Y2-op.Y1, h+d-thickness; !approxEq(got, want) {
+						t.Fatalf("invalid frame height: got=%g, want=%g", got, want)
+					}
+				case drawtex.LineOp:
+					lines++
+					if !approxEq(op.Width, thickness) {
+						t.Fatalf("invalid line thickness: got=%g, want=%g", op.Width, thickness)
+					}
+				}
+			}
+			if rects != tc.rects || lines != tc.lines {
+				t.Fatalf("invalid strokes: rects=%d, lines=%d", rects, lines)
+			}
+		})
+	}
+
+	t.Run("position", func(t *testing.T) {
+		x := func(expr string) float64 {
+			t.Helper()
+			glyphs := glyphOps(mustRender(t, expr))
+			if len(glyphs) == 0 {
+				t.Fatalf("no glyph in %q", expr)
+			}
+			return glyphs[0].X
+		}
+		var (
+			room  = 72 - mustParse(t, `$\text{ab}$`).Width()
+			left  = x(`$\framebox[1in][l]{ab}$`)
+			mid   = x(`$\framebox[1in]{ab}$`)
+			right = x(`$\framebox[1in][r]{ab}$`)
+		)
+		if !approxEq(left, sep) || !approxEq(mid, sep+room/2) || !approxEq(right, sep+room) {
+			t.Fatalf("invalid positions: l=%g, c=%g, r=%g", left, mid, right)
+		}
+	})
+}
+
+func TestFrameError(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{
+			expr: `$\framebox[x]{x}$`,
+			want: `invalid length "x"`,
+		},
+		{
+			expr: `$\framebox[2fm]{x}$`,
+			want: `invalid length "2fm": unknown unit "fm"`,
+		},
+		{
+			expr: `$\framebox[1in][q]{x}$`,
+			want: `invalid \framebox position "q"`,
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr, ftsize, dpi, nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; !strings.HasSuffix(got, want) {
+				t.Fatalf("invalid error:\ngot= %s\nwant=%s", got, want)
+			}
+		})
+	}
+}
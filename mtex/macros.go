@@ -11,14 +11,16 @@ import (
 	"github.com/go-latex/latex/tex"
 )
 
-type handlerFunc func(p *parser, node ast.Node, state tex.State, math bool) tex.Node
+type handlerFunc func(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node
 
-func (h handlerFunc) Handle(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
-	return h(p, node, state, math)
+func (h handlerFunc) Handle(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	return h(p, node, state, math, style)
 }
 
+// handler typesets a node in the provided math style.
+// The returned node is sized for that style.
 type handler interface {
-	Handle(p *parser, node ast.Node, state tex.State, math bool) tex.Node
+	Handle(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node
 }
 
 var (
@@ -69,6 +71,29 @@ var (
 		`\tfrac`:    builtinMacro("AA"),
 		`\genfrac`:  nil, // FIXME(sbinet)
 
+		// stacking
+		`\overset`:    builtinMacro("AA"),
+		`\underset`:   builtinMacro("AA"),
+		`\overbrace`:  builtinMacro("A"),
+		`\underbrace`: builtinMacro("A"),
+
+		// phantoms, smashing and overlaps
+		`\phantom`:  builtinMacro("A"),
+		`\hphantom`: builtinMacro("A"),
+		`\vphantom`: builtinMacro("A"),
+		`\smash`:    builtinMacro("OA"),
+		`\mathllap`: builtinMacro("OA"),
+		`\mathrlap`: builtinMacro("OA"),
+		`\mathclap`: builtinMacro("OA"),
+
+		// frames and cancellations
+		`\boxed`:    builtinMacro("A"),
+		`\fbox`:     builtinMacro("T"),
+		`\framebox`: builtinMacro("OOT"),
+		`\cancel`:   builtinMacro("A"),
+		`\bcancel`:  builtinMacro("A"),
+		`\xcancel`:  builtinMacro("A"),
+
 		// relation symbols
 		`\approx`:     builtinMacro(""),
 		`\asymp`:      builtinMacro(""),
@@ -145,6 +170,18 @@ var (
 		`\Uparrow`:            builtinMacro(""),
 		`\Updownarrow`:        builtinMacro(""),
 
+		// extensible arrows
+		`\xrightarrow`:         builtinMacro("OA"),
+		`\xleftarrow`:          builtinMacro("OA"),
+		`\xleftrightarrow`:     builtinMacro("OA"),
+		`\xmapsto`:             builtinMacro("OA"),
+		`\overrightarrow`:      builtinMacro("A"),
+		`\overleftarrow`:       builtinMacro("A"),
+		`\overleftrightarrow`:  builtinMacro("A"),
+		`\underrightarrow`:     builtinMacro("A"),
+		`\underleftarrow`:      builtinMacro("A"),
+		`\underleftrightarrow`: builtinMacro("A"),
+
 		// punctuation symbols
 		`\ldotp`: builtinMacro(""),
 		`\cdotp`: builtinMacro(""),
@@ -175,6 +212,17 @@ var (
 		`\int`:  builtinMacro(""),
 		`\oint`: builtinMacro(""),
 
+		// limits placement
+		`\limits`:   builtinMacro(""),
+		`\nolimits`: builtinMacro(""),
+
+		// math style
+		`\displaystyle`:      builtinMacro(""),
+		`\textstyle`:         builtinMacro(""),
+		`\scriptstyle`:       builtinMacro(""),
+		`\scriptscriptstyle`: builtinMacro(""),
+		`\mathchoice`:        builtinMacro("AAAA"),
+
 		// font names
 		`\rm`:      builtinMacro(""),
 		`\cal`:     builtinMacro(""),
@@ -301,18 +349,32 @@ var (
 		`\mathfrak`:    builtinMacro("A"),
 		`\mathscr`:     builtinMacro("A"),
 		`\mathregular`: builtinMacro("A"),
+		`\mathrm`:      builtinMacro("A"),
 
 		// text
-		`\textbf`:      builtinMacro("A"),
-		`\textit`:      builtinMacro("A"),
-		`\textsf`:      builtinMacro("A"),
-		`\texttt`:      builtinMacro("A"),
-		`\textcal`:     builtinMacro("A"),
-		`\textdefault`: builtinMacro("A"),
-		`\textbb`:      builtinMacro("A"),
-		`\textfrak`:    builtinMacro("A"),
-		`\textscr`:     builtinMacro("A"),
-		`\textregular`: builtinMacro("A"),
+		`\textbf`:      builtinMacro("T"),
+		`\textit`:      builtinMacro("T"),
+		`\textsf`:      builtinMacro("T"),
+		`\texttt`:      builtinMacro("T"),
+		`\textcal`:     builtinMacro("T"),
+		`\textdefault`: builtinMacro("T"),
+		`\textbb`:      builtinMacro("T"),
+		`\textfrak`:    builtinMacro("T"),
+		`\textscr`:     builtinMacro("T"),
+		`\textregular`: builtinMacro("T"),
+		`\textrm`:      builtinMacro("T"),
+		`\textnormal`:  builtinMacro("T"),
+		`\textup`:      builtinMacro("T"),
+		`\textmd`:      builtinMacro("T"),
+		`\textsl`:      builtinMacro("T"),
+		`\text`:        builtinMacro("T"),
+		`\mbox`:        builtinMacro("T"),
+
+		// colors
+		`\color`:       builtinMacro("OV"),
+		`\textcolor`:   builtinMacro("OVA"),
+		`\colorbox`:    builtinMacro("OVT"),
+		`\definecolor`: builtinMacro("VVV"),
 
 		// space, symbols
 		`\ `:      builtinMacro(""),
@@ -337,7 +399,7 @@ var (
 
 type builtinMacro string
 
-func (m builtinMacro) Handle(p *parser, n ast.Node, state tex.State, math bool) tex.Node {
+func (m builtinMacro) Handle(p *parser, n ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	node := n.(*ast.Macro)
 	if m == "" {
 		return tex.NewChar(node.Name.Name, state, math)
@@ -349,6 +411,8 @@ func (m builtinMacro) Handle(p *parser, n ast.Node, state tex.State, math bool)
 			panic("not implemented")
 		case 'o':
 			panic("not implemented")
+		case 't':
+			panic("not implemented")
 		case 'v':
 			panic("not implemented")
 		}
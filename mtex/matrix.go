@@ -0,0 +1,281 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// matrixDelims are the delimiters of the matrix environments, and of cases.
+var matrixDelims = map[string][2]string{
+	"array":       {".", "."},
+	"matrix":      {".", "."},
+	"smallmatrix": {".", "."},
+	"pmatrix":     {"(", ")"},
+	"bmatrix":     {"[", "]"},
+	"Bmatrix":     {`\{`, `\}`},
+	"vmatrix":     {"|", "|"},
+	"Vmatrix":     {`\Vert`, `\Vert`},
+	"cases":       {`\{`, "."},
+}
+
+const (
+	// arrayColSep is the space on each side of the columns of an array,
+	// in em (\arraycolsep).
+	arrayColSep = 0.5
+
+	// smallColSep is the space on each side of the columns of a small
+	// matrix, in em.
+	smallColSep = 0.13889
+
+	// baselineSkip is the distance between the baselines of the rows of an
+	// array, relatively to the font size.
+	// As in LaTeX, rows are at least 0.7 of it high and 0.3 of it deep.
+	baselineSkip = 1.2
+)
+
+// matrix typesets a matrix or an array environment in the provided style.
+// Cells are set in text style, or script style for small matrices, rows are
+// aligned on their baselines and the whole matrix is centered on the axis.
+// The matrix is put between delimiters sized to its height.
+func (p *parser) matrix(tbl *ast.Table, state tex.State, style mathStyleKind) tex.Node {
+	name := tbl.Name.Name
+	delims, ok := matrixDelims[name]
+	if !ok {
+		panic(fmt.Errorf("unsupported environment %q", name))
+	}
+
+	var (
+		cellStyle = textStyle
+		colsep    = arrayColSep
+		stretch   = 1.0
+		spec      = tbl.Spec
+	)
+	if name == "smallmatrix" {
+		cellStyle = scriptStyle
+		colsep = smallColSep
+		stretch = shrinkFactor
+	}
+
+	// cells.
+	var (
+		ncols int
+		cells = make([][]tex.Node, len(tbl.Rows))
+	)
+	for i, row := range tbl.Rows {
+		cells[i] = make([]tex.Node, len(row))
+		col := 0
+		for j, cell := range row {
+			box := p.handleNode(cell.List, state, true, cellStyle)
+			resize(box, cellStyle, textStyle)
+			cells[i][j] = box
+			col += cell.Span
+		}
+		ncols = max(ncols, col)
+	}
+
+	// columns: matrices have centered columns, and cases left-aligned
+	// ones, with no space on their outer sides.
+	var (
+		outer = true
+		align = 'c'
+	)
+	if name == "cases" {
+		align = 'l'
+	}
+	if spec == nil {
+		outer = false
+		for j := 0; j < ncols; j++ {
+			spec = append(spec, ast.ColSpec{Kind: align})
+		}
+	}
+	var cols []ast.ColSpec
+	for _, c := range spec {
+		if c.IsColumn() {
+			cols = append(cols, c)
+		}
+	}
+	for len(cols) < ncols {
+		cols = append(cols, ast.ColSpec{Kind: align})
+		spec = append(spec, ast.ColSpec{Kind: align})
+	}
+
+	widths := make([]float64, len(cols))
+	for i, row := range tbl.Rows {
+		col := 0
+		for j, cell := range row {
+			if cell.Span == 1 {
+				widths[col] = math.Max(widths[col], cells[i][j].Width())
+			}
+			col += cell.Span
+		}
+	}
+
+	sep := p.makeSpace(state, colsep).Width()
+	// cells spanning several columns widen the last of them, if needed.
+	for i, row := range tbl.Rows {
+		col := 0
+		for j, cell := range row {
+			if cell.Span > 1 {
+				last := min(col+cell.Span, len(cols)) - 1
+				w := 2 * sep * float64(last-col)
+				for k := col; k <= last; k++ {
+					w += widths[k]
+				}
+				widths[last] += math.Max(0, cells[i][j].Width()-w)
+			}
+			col += cell.Span
+		}
+	}
+
+	// rows.
+	var (
+		size   = state.Font.Size * state.DPI / 72
+		strutH = 0.7 * baselineSkip * size * stretch
+		strutD = 0.3 * baselineSkip * size * stretch
+		rows   = make([]tex.Node, 0, len(tbl.Rows)+len(tbl.Lines))
+	)
+	hlines := func(i int) {
+		for _, line := range tbl.Lines {
+			if line.Row != i {
+				continue
+			}
+			rows = append(rows, p.hline(line, widths, sep, outer, spec, state))
+		}
+	}
+	for i, row := range tbl.Rows {
+		hlines(i)
+		var (
+			nodes = []tex.Node{tex.VBox(strutH, strutD)}
+			col   = 0 // index of the next column
+			next  = 0 // index of the next column not spanned by a cell
+			j     = 0 // index of the next cell
+		)
+		for _, c := range spec {
+			switch {
+			case c.Kind == '|' && col >= next:
+				nodes = append(nodes, tex.VRule(state))
+				continue
+			case !c.IsColumn():
+				continue
+			case col < next:
+				col++
+				continue
+			}
+			var (
+				box  tex.Node = tex.HBox(widths[col])
+				last          = col
+			)
+			if j < len(row) {
+				var (
+					cell = row[j]
+					kind = c.Kind
+					w    float64
+				)
+				last = min(col+cell.Span, len(cols)) - 1
+				for k := col; k <= last; k++ {
+					w += widths[k]
+				}
+				w += 2 * sep * float64(last-col)
+				for _, cs := range cell.Spec {
+					if cs.IsColumn() {
+						kind = cs.Kind
+					}
+				}
+				box = alignCell(cells[i][j], w, kind)
+				j++
+			}
+			if outer || col > 0 {
+				nodes = append(nodes, tex.NewKern(sep))
+			}
+			nodes = append(nodes, box)
+			if outer || last < len(cols)-1 {
+				nodes = append(nodes, tex.NewKern(sep))
+			}
+			col++
+			next = last + 1
+		}
+		rows = append(rows, tex.HListOf(nodes, false))
+	}
+	hlines(len(tbl.Rows))
+
+	vlist := tex.VListOf(rows)
+	vlist.SetShift((vlist.Height()-vlist.Depth())/2 - p.axisHeight(state))
+	box := tex.HListOf([]tex.Node{vlist}, false)
+
+	var node tex.Node = box
+	if delims[0] != "." || delims[1] != "." {
+		node = p.autoSizedDelimiter(delims[0], []tex.Node{box}, delims[1], state)
+	}
+	resize(node, textStyle, style)
+	return node
+}
+
+// alignCell aligns the cell in a box of the provided width, following the
+// alignment of its column: l, c or r.
+func alignCell(cell tex.Node, width float64, kind rune) tex.Node {
+	var box *tex.HList
+	switch kind {
+	case 'l':
+		box = tex.HListOf([]tex.Node{cell, tex.NewGlue("fil")}, false)
+	case 'r':
+		box = tex.HListOf([]tex.Node{tex.NewGlue("fil"), cell}, false)
+	default:
+		box = tex.HCentered([]tex.Node{cell})
+	}
+	const additional = false // i.e.: exactly
+	box.HPack(width, additional)
+	return box
+}
+
+// hline returns a horizontal rule spanning the columns of the line.
+func (p *parser) hline(line ast.HLine, widths []float64, sep float64, outer bool, spec []ast.ColSpec, state tex.State) tex.Node {
+	thickness := state.Backend().UnderlineThickness(state.Font, state.DPI)
+	if line.From == 0 {
+		return tex.HRule(state, thickness)
+	}
+
+	// x positions of the edges of the columns.
+	var (
+		x     float64
+		col   int
+		start = make([]float64, len(widths))
+		end   = make([]float64, len(widths))
+	)
+	for _, c := range spec {
+		if !c.IsColumn() {
+			if c.Kind == '|' {
+				x += thickness
+			}
+			continue
+		}
+		if col >= len(widths) {
+			break
+		}
+		start[col] = x
+		if outer || col > 0 {
+			x += sep
+		}
+		x += widths[col]
+		if outer || col < len(widths)-1 {
+			x += sep
+		}
+		end[col] = x
+		col++
+	}
+
+	var (
+		from = min(line.From, len(widths)) - 1
+		to   = min(line.To, len(widths)) - 1
+	)
+	return tex.HListOf([]tex.Node{
+		tex.NewKern(start[from]),
+		tex.NewRule(end[to]-start[from], thickness/2, thickness/2, state),
+	}, false)
+}
@@ -0,0 +1,131 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-latex/latex/drawtex"
+	"github.com/go-latex/latex/font"
+	"github.com/go-latex/latex/font/ttf"
+	"github.com/go-latex/latex/tex"
+)
+
+func TestMatrix(t *testing.T) {
+	be := ttf.New(drawtex.New())
+
+	t.Run("rows", func(t *testing.T) {
+		var (
+			one   = mustParse(t, `$\begin{matrix} a & b \end{matrix}$`)
+			two   = mustParse(t, `$\begin{matrix} a & b \\ c & d \end{matrix}$`)
+			three = mustParse(t, `$\begin{matrix} a & b \\ c & d \\ e & f \end{matrix}$`)
+		)
+		if !(one.Height()+one.Depth() < two.Height()+two.Depth()) ||
+			!(two.Height()+two.Depth() < three.Height()+three.Depth()) {
+			t.Fatalf("rows not stacked: %g, %g, %g",
+				one.Height()+one.Depth(),
+				two.Height()+two.Depth(),
+				three.Height()+three.Depth(),
+			)
+		}
+		if got, want := two.Width(), one.Width(); got != want {
+			t.Fatalf("invalid width: got=%g, want=%g", got, want)
+		}
+	})
+
+	t.Run("centered", func(t *testing.T) {
+		var (
+			box    = mustParse(t, `$\begin{matrix} a \\ b \\ c \\ d \end{matrix}$`)
+			state  = tex.NewState(be, font.Font{Name: "default", Size: ftsize, Type: "rm"}, dpi)
+			center = (box.Height() - box.Depth()) / 2
+			axis   = newParser(be).axisHeight(state)
+		)
+		if math.Abs(center-axis) > 1e-6 {
+			t.Fatalf("matrix not centered on the axis: center=%g, axis=%g", center, axis)
+		}
+	})
+
+	t.Run("delimiters", func(t *testing.T) {
+		var (
+			matrix = mustParse(t, `$\begin{matrix} a & b \\ c & d \end{matrix}$`)
+			paren  = mustParse(t, `$(a)$`)
+		)
+		for _, env := range []string{"pmatrix", "bmatrix", "Bmatrix", "vmatrix", "Vmatrix"} {
+			box := mustParse(t, `$\begin{`+env+`} a & b \\ c & d \end{`+env+`}$`)
+			if !(box.Width() > matrix.Width()) {
+				t.Fatalf("%s: missing delimiters: width=%g, want>%g", env, box.Width(), matrix.Width())
+			}
+			if !(box.Height()+box.Depth() > paren.Height()+paren.Depth()) {
+				t.Fatalf("%s: delimiters not sized: size=%g, want>%g",
+					env, box.Height()+box.Depth(), paren.Height()+paren.Depth(),
+				)
+			}
+		}
+	})
+
+	t.Run("columns", func(t *testing.T) {
+		var (
+			matrix = mustParse(t, `$\begin{matrix} a & b \\ c & d \end{matrix}$`)
+			array  = mustParse(t, `$\begin{array}{cc} a & b \\ c & d \end{array}$`)
+			rules  = mustParse(t, `$\begin{array}{|c|c|} a & b \\ c & d \end{array}$`)
+			three  = mustParse(t, `$\begin{array}{ccc} a & b \\ c & d \end{array}$`)
+		)
+		if !(array.Width() > matrix.Width()) {
+			t.Fatalf("array without outer space: width=%g, want>%g", array.Width(), matrix.Width())
+		}
+		if !(rules.Width() > array.Width()) {
+			t.Fatalf("array without rules: width=%g, want>%g", rules.Width(), array.Width())
+		}
+		if !(three.Width() > array.Width()) {
+			t.Fatalf("array without empty column: width=%g, want>%g", three.Width(), array.Width())
+		}
+		for _, spec := range []string{"lr", "rl", "cc"} {
+			box := mustParse(t, `$\begin{array}{`+spec+`} a & bb \\ aaa & b \end{array}$`)
+			if got, want := box.Width(), mustParse(t, `$\begin{array}{ll} a & bb \\ aaa & b \end{array}$`).Width(); got != want {
+				t.Fatalf("%s: invalid width: got=%g, want=%g", spec, got, want)
+			}
+		}
+	})
+
+	t.Run("hline", func(t *testing.T) {
+		var (
+			array = mustParse(t, `$\begin{array}{cc} a & b \\ c & d \end{array}$`)
+			hline = mustParse(t, `$\begin{array}{cc} a & b \\ \hline c & d \end{array}$`)
+			cline = mustParse(t, `$\begin{array}{cc} a & b \\ \cline{1-1} c & d \end{array}$`)
+		)
+		for _, box := range []tex.Node{hline, cline} {
+			if !(box.Height()+box.Depth() > array.Height()+array.Depth()) {
+				t.Fatalf("missing rule: size=%g, want>%g",
+					box.Height()+box.Depth(), array.Height()+array.Depth(),
+				)
+			}
+			if got, want := box.Width(), array.Width(); got != want {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+		}
+	})
+
+	t.Run("dots", func(t *testing.T) {
+		box := mustParse(t, `$\begin{pmatrix} a_1 & \cdots & a_n \\ \vdots & \ddots & \vdots \\ b_1 & \cdots & b_n \end{pmatrix}$`)
+		if !(box.Width() > 0 && box.Height() > 0) {
+			t.Fatalf("invalid matrix: w=%g, h=%g", box.Width(), box.Height())
+		}
+	})
+
+	t.Run("small", func(t *testing.T) {
+		var (
+			matrix = mustParse(t, `$\begin{matrix} a & b \\ c & d \end{matrix}$`)
+			small  = mustParse(t, `$\begin{smallmatrix} a & b \\ c & d \end{smallmatrix}$`)
+			script = mustParse(t, `$x^{\begin{matrix} a & b \\ c & d \end{matrix}}$`)
+		)
+		if !(small.Width() < matrix.Width()) || !(small.Height() < matrix.Height()) {
+			t.Fatalf("small matrix not smaller: w=%g, h=%g", small.Width(), small.Height())
+		}
+		if !(script.Height() > matrix.Height()) {
+			t.Fatalf("invalid matrix in script: h=%g", script.Height())
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"testing"
+
+	"github.com/go-latex/latex/drawtex"
+	"github.com/go-latex/latex/font"
+	"github.com/go-latex/latex/font/ttf"
+	"github.com/go-latex/latex/tex"
+)
+
+// resolution and font size of the test expressions.
+const (
+	dpi    = 72
+	ftsize = 10
+)
+
+// mustParse parses the expression with the default TrueType fonts.
+func mustParse(t *testing.T, expr string) tex.Node {
+	t.Helper()
+	return mustParseWith(t, ttf.New(drawtex.New()), expr)
+}
+
+// mustParseWith parses the expression with the provided backend.
+func mustParseWith(t *testing.T, be font.Backend, expr string) tex.Node {
+	t.Helper()
+	box, err := Parse(expr, ftsize, dpi, be)
+	if err != nil {
+		t.Fatalf("could not parse %q: %+v", expr, err)
+	}
+	return box
+}
+
+// recorder records the drawing operations of the rendered canvas.
+type recorder struct {
+	ops []drawtex.Op
+}
+
+func (r *recorder) Render(width, height, dpi float64, c *drawtex.Canvas) error {
+	r.ops = append(r.ops, c.Ops()...)
+	return nil
+}
+
+// mustRender renders the expression with the default TrueType fonts and
+// returns its drawing operations.
+func mustRender(t *testing.T, expr string) []drawtex.Op {
+	t.Helper()
+	var dst recorder
+	err := Render(&dst, expr, ftsize, dpi, nil)
+	if err != nil {
+		t.Fatalf("could not render %q: %+v", expr, err)
+	}
+	return dst.ops
+}
+
+// glyphOps returns the glyphs drawn by the operations, in drawing order.
+// If symbols are provided, only the glyphs of these symbols are returned.
+func glyphOps(ops []drawtex.Op, syms ...string) []drawtex.GlyphOp {
+	var o []drawtex.GlyphOp
+	for _, op := range ops {
+		op, ok := op.(drawtex.GlyphOp)
+		if !ok {
+			continue
+		}
+		if len(syms) == 0 {
+			o = append(o, op)
+			continue
+		}
+		for _, sym := range syms {
+			if op.Glyph.Symbol == sym {
+				o = append(o, op)
+				break
+			}
+		}
+	}
+	return o
+}
+
+func approxEq(a, b float64) bool {
+	const eps = 1e-9
+	return a-b < eps && b-a < eps
+}
@@ -0,0 +1,180 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// Numbering is the numbering of the equations of display environments,
+// such as equation or align.
+//
+// Each numbered equation increments the counter and is tagged with its new
+// value, unless it is tagged with \tag or left unnumbered with \notag or
+// \nonumber.
+type Numbering struct {
+	Counter int  // equation counter, i.e. the number of the last numbered equation
+	Left    bool // whether tags are on the left of equations, rather than on the right
+
+	// Labels are the tags of the equations labeled with \label, without
+	// parentheses, as resolved by \eqref.
+	Labels map[string]string
+}
+
+// tagSep is the minimal space between an equation and its tag, in em.
+const tagSep = 2
+
+// eqTag describes the tag of an equation.
+type eqTag struct {
+	text   ast.List // argument of \tag, if any
+	star   bool     // whether the tag was set with \tag*
+	none   bool     // whether the equation is not numbered
+	labels []string // labels of the equation
+}
+
+// merge merges the tag of an equation with the tag of another line of the
+// same numbered display.
+func (t eqTag) merge(o eqTag) eqTag {
+	if o.text != nil {
+		t.text = o.text
+		t.star = o.star
+	}
+	t.none = t.none || o.none
+	t.labels = append(t.labels, o.labels...)
+	return t
+}
+
+// tagMacro records the equation numbering command in the tag, and returns
+// whether the macro is such a command.
+func (t *eqTag) tagMacro(macro *ast.Macro) bool {
+	switch macro.Name.Name {
+	case `\tag`, `\tag*`:
+		t.text = macro.Args[0].(*ast.Arg).List
+		if t.text == nil {
+			t.text = ast.List{}
+		}
+		t.star = macro.Name.Name == `\tag*`
+	case `\notag`, `\nonumber`:
+		t.none = true
+	case `\label`:
+		t.labels = append(t.labels, strings.TrimSpace(textOf(macro.Args[0].(*ast.Arg).List)))
+	default:
+		return false
+	}
+	return true
+}
+
+// tag typesets the tag of an equation, numbering it if needed, and records
+// its labels.
+// It returns nil when the equation has no tag.
+func (p *parser) tag(t eqTag, numbered bool, state tex.State) tex.Node {
+	var (
+		txt  string
+		list ast.List
+	)
+	switch {
+	case t.text != nil:
+		txt = strings.TrimSpace(textOf(t.text))
+		list = t.text
+	case numbered && !t.none:
+		p.num.Counter++
+		txt = strconv.Itoa(p.num.Counter)
+		list = ast.List{&ast.Word{Text: txt}}
+	default:
+		return nil
+	}
+
+	for _, label := range t.labels {
+		if p.num.Labels == nil {
+			p.num.Labels = make(map[string]string)
+		}
+		p.num.Labels[label] = txt
+	}
+
+	if !t.star {
+		list = append(append(ast.List{&ast.Word{Text: "("}}, list...), &ast.Word{Text: ")"})
+	}
+	state.Font.Type = "rm"
+	return p.handleNode(list, state, false, displayStyle)
+}
+
+// tagLines puts the tags of the equations of a display next to its lines,
+// on their right or on their left, following the numbering.
+// Tags are aligned on the side of the display, so lines keep their
+// alignment.
+func (p *parser) tagLines(lines []tex.Node, tags []eqTag, denv displayEnv, state tex.State) []tex.Node {
+	boxes := make([]tex.Node, len(lines))
+	switch {
+	case denv.single:
+		// a single equation is tagged on its last line, or on its first
+		// line when tags are on the left.
+		var tag eqTag
+		for _, t := range tags {
+			tag = tag.merge(t)
+		}
+		i := len(lines) - 1
+		if p.num.Left {
+			i = 0
+		}
+		boxes[i] = p.tag(tag, denv.numbered, state)
+	default:
+		for i, t := range tags {
+			boxes[i] = p.tag(t, denv.numbered, state)
+		}
+	}
+
+	var width float64
+	for _, box := range boxes {
+		if box != nil {
+			width = math.Max(width, box.Width())
+		}
+	}
+	if width == 0 {
+		return lines
+	}
+
+	var (
+		sep   = p.makeSpace(state, tagSep).Width()
+		align = 'r'
+		o     = make([]tex.Node, len(lines))
+	)
+	if p.num.Left {
+		align = 'l'
+	}
+	for i, line := range lines {
+		var tag tex.Node = tex.HBox(width)
+		if boxes[i] != nil {
+			tag = alignCell(boxes[i], width, align)
+		}
+		nodes := []tex.Node{line, tex.NewKern(sep), tag}
+		if p.num.Left {
+			nodes = []tex.Node{tag, tex.NewKern(sep), line}
+		}
+		o[i] = tex.HListOf(nodes, false)
+	}
+	return o
+}
+
+// textOf returns the text of the nodes, as in the argument of \label.
+func textOf(nodes ast.List) string {
+	o := new(strings.Builder)
+	ast.Inspect(nodes, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Literal:
+			o.WriteString(node.Text)
+		case *ast.Word:
+			o.WriteString(node.Text)
+		case *ast.Symbol:
+			o.WriteString(node.Text)
+		}
+		return true
+	})
+	return o.String()
+}
@@ -0,0 +1,159 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-latex/latex/drawtex"
+	"github.com/go-latex/latex/internal/fakebackend"
+)
+
+func TestNumbering(t *testing.T) {
+	be := fakebackend.New()
+
+	for _, tc := range []struct {
+		expr    string
+		counter int
+		want    int
+		labels  map[string]string
+	}{
+		{
+			expr: `\begin{equation} a \label{eq:a} \end{equation}`,
+			want: 1,
+			labels: map[string]string{
+				"eq:a": "1",
+			},
+		},
+		{
+			expr:    `\begin{equation*} a \label{eq:a} \end{equation*}`,
+			counter: 2,
+			want:    2,
+		},
+		{
+			expr:    `\begin{align} a &= a \label{eq:a} \\ a &= a \notag \\ a &= a \tag{A} \label{eq:b} \\ a \label{eq:c} \end{align}`,
+			counter: 4,
+			want:    6,
+			labels: map[string]string{
+				"eq:a": "5",
+				"eq:b": "A",
+				"eq:c": "6",
+			},
+		},
+		{
+			expr: `\begin{gather*} a \\ a \tag*{B} \label{eq:b} \\ \end{gather*}`,
+			labels: map[string]string{
+				"eq:b": "B",
+			},
+		},
+		{
+			expr: `\begin{multline} a \label{eq:a} \\ a \\ a \end{multline}`,
+			want: 1,
+			labels: map[string]string{
+				"eq:a": "1",
+			},
+		},
+		{
+			expr: `\begin{multline} a \\ a \nonumber \end{multline}`,
+		},
+		{
+			expr: `\begin{equation} \begin{split} a &= a \\ &= a \end{split} \label{eq:a} \end{equation}`,
+			want: 1,
+			labels: map[string]string{
+				"eq:a": "1",
+			},
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			num := Numbering{Counter: tc.counter}
+			_, err := ParseNumbered(tc.expr, ftsize, dpi, be, &num)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.expr, err)
+			}
+			if got, want := num.Counter, tc.want; got != want {
+				t.Fatalf("invalid counter: got=%d, want=%d", got, want)
+			}
+			if got, want := num.Labels, tc.labels; !reflect.DeepEqual(got, want) {
+				t.Fatalf("invalid labels:\ngot= %v\nwant=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestNumberingLayout(t *testing.T) {
+	render := func(expr string, num Numbering) []drawtex.Op {
+		t.Helper()
+		var dst recorder
+		err := RenderNumbered(&dst, expr, ftsize, dpi, nil, &num)
+		if err != nil {
+			t.Fatalf("could not render %q: %+v", expr, err)
+		}
+		return dst.ops
+	}
+
+	const expr = `\begin{align} a &= b + c \\ d &= e \notag \\ f &= g \end{align}`
+
+	t.Run("right", func(t *testing.T) {
+		var (
+			ops    = render(expr, Numbering{Counter: 2})
+			lparen = glyphOps(ops, "(")
+			rparen = glyphOps(ops, ")")
+			b      = glyphOps(ops, "b")[0]
+		)
+		if len(lparen) != 2 || len(glyphOps(ops, "3")) != 1 || len(glyphOps(ops, "4")) != 1 {
+			t.Fatalf("invalid tags: %v", ops)
+		}
+		if !(lparen[0].X > b.X) {
+			t.Fatalf("tag not on the right: tag=%v, b=%v", lparen[0], b)
+		}
+		if rparen[0].X != rparen[1].X {
+			t.Fatalf("tags not aligned: %v", rparen)
+		}
+	})
+
+	t.Run("left", func(t *testing.T) {
+		var (
+			ops    = render(expr, Numbering{Left: true})
+			lparen = glyphOps(ops, "(")
+			a      = glyphOps(ops, "a")[0]
+		)
+		if len(lparen) != 2 {
+			t.Fatalf("invalid tags: %v", ops)
+		}
+		if !(lparen[0].X < a.X) {
+			t.Fatalf("tag not on the left: tag=%v, a=%v", lparen[0], a)
+		}
+		if lparen[0].X != lparen[1].X {
+			t.Fatalf("tags not aligned: %v", lparen)
+		}
+	})
+
+	t.Run("multline", func(t *testing.T) {
+		const expr = `\begin{multline} a + b \\ c + d \end{multline}`
+		var (
+			right = glyphOps(render(expr, Numbering{}), "(")
+			left  = glyphOps(render(expr, Numbering{Left: true}), "(")
+			d     = glyphOps(render(expr, Numbering{}), "d")[0]
+			a     = glyphOps(render(expr, Numbering{Left: true}), "a")[0]
+		)
+		if len(right) != 1 || right[0].Y != d.Y {
+			t.Fatalf("tag not on the last line: tag=%v, d=%v", right, d)
+		}
+		if len(left) != 1 || left[0].Y != a.Y {
+			t.Fatalf("tag not on the first line: tag=%v, a=%v", left, a)
+		}
+	})
+
+	t.Run("starred", func(t *testing.T) {
+		ops := render(`\begin{align*} a &= b \\ c &= d \tag*{$\star$} \end{align*}`, Numbering{})
+		if len(glyphOps(ops, "(")) != 0 {
+			t.Fatalf("unexpected parentheses: %v", ops)
+		}
+		if len(glyphOps(ops, "1")) != 0 {
+			t.Fatalf("unexpected number: %v", ops)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/mtex/symbols"
+	"github.com/go-latex/latex/tex"
+)
+
+// limitsKind is the placement of the sub- and superscripts of a large
+// operator.
+type limitsKind int
+
+const (
+	displayLimits limitsKind = iota // limits in display style, scripts otherwise
+	withLimits                      // limits above and below the operator
+	noLimits                        // sub- and superscripts
+)
+
+// limitsOf returns the default placement of the scripts of the named
+// large operator.
+// Integrals and functions such as \sin take scripts, while \sum or \lim
+// take limits in display style, and horizontal braces always take limits.
+func limitsOf(name string) limitsKind {
+	switch {
+	case name == `\overbrace`, name == `\underbrace`:
+		return withLimits
+	case symbols.OverUnderSymbols.Has(name):
+		return displayLimits
+	case len(name) > 1 && symbols.OverUnderFunctions.Has(name[1:]): // drop leading `\`
+		return displayLimits
+	}
+	return noLimits
+}
+
+// displayOpScale is the scale factor of large operators in display style.
+// The display-size variants of the operators of Computer Modern are about
+// 1.4 times as tall as their text-size variants.
+const displayOpScale = 1.4
+
+// isBigOperator returns whether the named symbol is a large operator
+// symbol, such as \sum or \int.
+func isBigOperator(name string) bool {
+	return symbols.OverUnderSymbols.Has(name) || symbols.DropSubSymbols.Has(name)
+}
+
+// operator typesets a large operator atom with its limits or scripts,
+// following the rules 13 and 13a of Appendix G of the TeXbook.
+// Operator symbols are enlarged in display style and centered on the math
+// axis.
+// The returned node is sized for the style of the atom.
+func (p *parser) operator(a atom, state tex.State) tex.Node {
+	var delta float64 // italic correction of the operator
+	if ch, ok := a.node.(*tex.Char); ok {
+		name := ch.String()
+		if a.style.kind() == displayStyle && isBigOperator(name) {
+			state := state
+			state.Font.Size *= displayOpScale
+			ch = tex.NewChar(name, state, true)
+		}
+		delta = ch.Italic()
+		if symbols.DropSubSymbols.Has(name) {
+			xheight := p.be.XHeight(state.Font, state.DPI)
+			delta = math.Max(delta, tex.DefaultFontConstants.DeltaIntegral*xheight)
+		}
+
+		box := tex.VListOf([]tex.Node{tex.HListOf([]tex.Node{ch}, true)})
+		box.SetShift((ch.Height()-ch.Depth())/2 - p.axisHeight(state))
+		a.node = tex.HListOf([]tex.Node{box}, true)
+	}
+
+	switch {
+	case a.sub == nil && a.sup == nil:
+		return a.node
+	case a.limits == withLimits, a.limits == displayLimits && a.style.kind() == displayStyle:
+		return p.limits(a, delta, state)
+	default:
+		return p.scripts(a, delta, state)
+	}
+}
+
+// limits typesets the limits of a large operator, and stacks them above
+// and below it.
+func (p *parser) limits(a atom, delta float64, state tex.State) tex.Node {
+	var x, z tex.Node // upper and lower limits
+
+	limit := func(node ast.Node, style mathStyleKind) tex.Node {
+		box := p.handleNode(node, state, true, style)
+		resize(box, style, a.style)
+		return box
+	}
+	if a.sup != nil {
+		x = limit(a.sup, a.style.sup())
+	}
+	if a.sub != nil {
+		z = limit(a.sub, a.style.sub())
+	}
+	return p.stackLimits(a.node, x, z, delta, state)
+}
+
+// stackLimits stacks the upper and lower limits, x and z, above and below
+// the nucleus, following the rule 13a of Appendix G of the TeXbook.
+// Limits are nil when missing.
+// The upper limit is moved right by half the italic correction of the
+// nucleus, delta, the lower one is moved left by as much.
+func (p *parser) stackLimits(nucleus, x, z tex.Node, delta float64, state tex.State) tex.Node {
+	var (
+		fc      = tex.DefaultFontConstants
+		xheight = p.be.XHeight(state.Font, state.DPI)
+		width   = nucleus.Width()
+	)
+	for _, limit := range []tex.Node{x, z} {
+		if limit != nil {
+			width = math.Max(width, limit.Width()+delta)
+		}
+	}
+
+	center := func(nodes ...tex.Node) tex.Node {
+		const additional = false // i.e.: exactly
+		box := tex.HCentered(nodes)
+		box.HPack(width, additional)
+		return box
+	}
+
+	var (
+		nodes []tex.Node
+		shift float64
+	)
+	if x != nil {
+		nodes = append(nodes,
+			tex.NewKern(fc.BigOpSpacing5*xheight),
+			center(tex.NewKern(delta), x),
+			tex.NewKern(math.Max(
+				fc.BigOpSpacing1*xheight,
+				fc.BigOpSpacing3*xheight-x.Depth(),
+			)),
+		)
+	}
+	nodes = append(nodes, center(nucleus))
+	if z != nil {
+		kern := math.Max(
+			fc.BigOpSpacing2*xheight,
+			fc.BigOpSpacing4*xheight-z.Height(),
+		)
+		nodes = append(nodes,
+			tex.NewKern(kern),
+			center(z, tex.NewKern(delta)),
+			tex.NewKern(fc.BigOpSpacing5*xheight),
+		)
+		// the list ends with a kern: lower it so the operator sits on
+		// the baseline.
+		shift = nucleus.Depth() + kern + z.Height() + z.Depth() + fc.BigOpSpacing5*xheight
+	}
+
+	box := tex.VListOf(nodes)
+	box.SetShift(shift)
+
+	return &tex.SubSuperCluster{
+		HList:   tex.HListOf([]tex.Node{box}, true),
+		Nucleus: nucleus,
+		Sub:     z,
+		Super:   x,
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import "testing"
+
+func TestOperator(t *testing.T) {
+	t.Run("display-size", func(t *testing.T) {
+		for _, op := range []string{`\sum`, `\prod`, `\int`} {
+			var (
+				text    = mustParse(t, `$`+op+`$`)
+				display = mustParse(t, `\[`+op+`\]`)
+			)
+			if !(display.Height()+display.Depth() > text.Height()+text.Depth()) {
+				t.Fatalf("%s not enlarged in display style: text=%g, display=%g",
+					op, text.Height()+text.Depth(), display.Height()+display.Depth(),
+				)
+			}
+		}
+	})
+
+	for _, tc := range []struct {
+		limits  string // expression with limits
+		scripts string // expression with scripts
+	}{
+		{limits: `\[\sum_{i=1}^n\]`, scripts: `$\sum_{i=1}^n$`},
+		{limits: `\[\prod_{i=1}^n\]`, scripts: `\[\prod\nolimits_{i=1}^n\]`},
+		{limits: `$\sum\limits_{i=1}^n$`, scripts: `$\sum_{i=1}^n$`},
+		{limits: `\[\lim_{x \to 0}\]`, scripts: `$\lim_{x \to 0}$`},
+		{limits: `\[\max_{x}\]`, scripts: `\[\max\nolimits_{x}\]`},
+		{limits: `\[\int\limits_0^1\]`, scripts: `\[\int_0^1\]`},
+	} {
+		t.Run(tc.limits, func(t *testing.T) {
+			var (
+				limits  = mustParse(t, tc.limits)
+				scripts = mustParse(t, tc.scripts)
+			)
+			if !(limits.Width() < scripts.Width()) {
+				t.Fatalf("limits not stacked: width=%g, want<%g", limits.Width(), scripts.Width())
+			}
+			if !(limits.Depth() > scripts.Depth()) {
+				t.Fatalf("lower limit not below the operator: depth=%g, want>%g", limits.Depth(), scripts.Depth())
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package mtex
 
 import (
 	"fmt"
+	"image/color"
 	"math"
 	"strconv"
 	"strings"
@@ -20,31 +21,85 @@ import (
 
 // Parse parses a LaTeX math expression and returns the TeX-like box model
 // and an error if any.
+// Equations of display environments are numbered from 1.
 func Parse(expr string, fontSize, DPI float64, backend font.Backend) (tex.Node, error) {
 	p := newParser(backend)
 	return p.parse(expr, fontSize, DPI)
 }
 
+// ParseNumbered is like Parse, but numbers the equations of display
+// environments following num, which is updated with the last equation
+// number and the tags of the labeled equations.
+func ParseNumbered(expr string, fontSize, DPI float64, backend font.Backend, num *Numbering) (tex.Node, error) {
+	p := newParser(backend)
+	if num != nil {
+		p.num = num
+	}
+	return p.parse(expr, fontSize, DPI)
+}
+
 type parser struct {
-	be font.Backend
+	be          font.Backend
+	num         *Numbering                 // numbering of the equations
+	colors      map[string]color.Color     // colors defined with \definecolor
+	macroColors map[*ast.Macro]color.Color // colors of the color macros, such as \color
 
-	expr   string
 	macros map[string]handler
 }
 
 func newParser(be font.Backend) *parser {
 	p := &parser{
-		be:     be,
-		macros: make(map[string]handler),
+		be:          be,
+		num:         new(Numbering),
+		colors:      make(map[string]color.Color),
+		macros:      make(map[string]handler),
+		macroColors: make(map[*ast.Macro]color.Color),
 	}
 	p.init()
 
 	return p
 }
 
+// parseExpr parses the LaTeX expression, reporting the panics of the LaTeX
+// parser, such as unknown macros, as errors.
+func parseExpr(x string) (node ast.Node, err error) {
+	defer func() {
+		e := recover()
+		if e == nil {
+			return
+		}
+		node, err = nil, fmt.Errorf("%v", e)
+	}()
+	return latex.ParseExpr(x)
+}
+
 func (p *parser) parse(x string, size, dpi float64) (tex.Node, error) {
-	p.expr = x
-	node, err := latex.ParseExpr(x)
+	node, err := parseExpr(x)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse latex expression %q: %w", x, err)
+	}
+
+	err = checkTextArgs(node)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse latex expression %q: %w", x, err)
+	}
+
+	err = p.resolveColors(node)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse latex expression %q: %w", x, err)
+	}
+
+	err = checkLengths(node)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse latex expression %q: %w", x, err)
+	}
+
+	err = checkPhantoms(node)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse latex expression %q: %w", x, err)
+	}
+
+	err = checkFrames(node)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse latex expression %q: %w", x, err)
 	}
@@ -55,23 +110,106 @@ func (p *parser) parse(x string, size, dpi float64) (tex.Node, error) {
 		Type: "rm",
 	}, dpi)
 
+	if list, ok := node.(ast.List); ok && len(list) == 1 {
+		if env, ok := list[0].(*ast.Env); ok {
+			// a display environment, such as align, is a list of lines.
+			return p.display(env, state, displayStyle), nil
+		}
+	}
+
 	v := visitor{p: p, state: state}
 	ast.Walk(&v, node)
-	nodes := tex.HListOf(v.nodes, true)
+	nodes := tex.HListOf(v.hlist(), true)
 
 	return nodes, nil
 }
 
 type visitor struct {
 	p     *parser
-	nodes []tex.Node
+	atoms []atom
 	state tex.State
 	math  bool
+	base  mathStyleKind // style of the list, the size of state applies to
+	style mathStyleKind // current style
+
+	accents []string // accents to put over the next atom
+}
+
+// add appends a node of the provided class to the visited list.
+// Nodes outside of math mode are not atoms.
+// Pending accents are put over the node, which becomes an ordinary atom.
+// The node is sized for the current style.
+func (v *visitor) add(class atomClass, node tex.Node) {
+	if !v.math {
+		class = noAtom
+	}
+	if len(v.accents) > 0 && class != noAtom {
+		for i := len(v.accents) - 1; i >= 0; i-- {
+			node = v.p.accent(v.accents[i], node, v.state)
+		}
+		v.accents = v.accents[:0]
+		class = ordAtom
+	}
+	v.atoms = append(v.atoms, atom{class: class, node: node, style: v.style})
+}
+
+// script attaches a subscript or a superscript to the last atom of the
+// visited list.
+// An empty ordinary atom is used as the nucleus when there is no such atom,
+// or when the atom already has that script, as in {}_i or x^2^3.
+func (v *visitor) script(node ast.Node, sub bool) {
+	n := len(v.atoms)
+	if n == 0 || v.atoms[n-1].class == noAtom ||
+		(sub && v.atoms[n-1].sub != nil) ||
+		(!sub && v.atoms[n-1].sup != nil) {
+		v.atoms = append(v.atoms, atom{
+			class: ordAtom,
+			node:  tex.HListOf(nil, true),
+			style: v.style,
+		})
+		n++
+	}
+	a := &v.atoms[n-1]
+	if sub {
+		a.sub = node
+		return
+	}
+	a.sup = node
+}
+
+// hlist returns the nodes of the visited list.
+func (v *visitor) hlist() []tex.Node {
+	if !v.math {
+		nodes := make([]tex.Node, len(v.atoms))
+		for i, a := range v.atoms {
+			resize(a.node, a.style, v.base)
+			nodes[i] = a.node
+		}
+		return nodes
+	}
+	return v.p.hlist(v.atoms, v.base, v.state)
 }
 
 func (v *visitor) Visit(n ast.Node) ast.Visitor {
 	switch n := n.(type) {
 	case ast.List:
+		if v.math {
+			// a group in math mode is an ordinary atom.
+			// style changes are local to the group.
+			sub := visitor{p: v.p, state: v.state, math: v.math, base: v.style, style: v.style}
+			for _, x := range n {
+				sub.Visit(x)
+			}
+			v.add(ordAtom, tex.HListOf(sub.hlist(), true))
+			return nil
+		}
+		// color switches are local to the group.
+		state := v.state
+		for _, x := range n {
+			ast.Walk(v, x)
+		}
+		v.state = state
+		return nil
 	case *ast.Symbol:
 		switch {
 		case v.math:
@@ -79,34 +217,71 @@ func (v *visitor) Visit(n ast.Node) ast.Visitor {
 			if h == nil {
 				panic("no handler for symbol [" + n.Text + "]")
 			}
-			v.nodes = append(v.nodes, h.Handle(v.p, n, v.state, v.math))
+			v.add(classOf(n.Text), h.Handle(v.p, n, v.state, v.math, v.style))
+		case n.Text == " ":
+			v.add(noAtom, tex.NewSpaceGlue(v.state))
 		default:
-			v.nodes = append(v.nodes, tex.NewChar(string(n.Text), v.state, v.math))
+			v.add(noAtom, tex.NewChar(string(n.Text), v.state, v.math))
 		}
 	case *ast.Word:
+		if v.math {
+			// each letter is an atom, so scripts attach to the last one.
+			for _, x := range n.Text {
+				v.add(ordAtom, tex.NewChar(string(x), v.state, v.math))
+			}
+			break
+		}
 		var nodes []tex.Node
 		for _, x := range n.Text {
 			nodes = append(nodes, tex.NewChar(string(x), v.state, v.math))
 		}
-		v.nodes = append(v.nodes, tex.HListOf(nodes, true))
+		v.add(ordAtom, tex.HListOf(nodes, true))
 	case *ast.Literal:
 		h := handlerFunc(handleSymbol)
 		for _, c := range n.Text {
 			n := &ast.Literal{Text: string(c)}
-			v.nodes = append(v.nodes, h.Handle(v.p, n, v.state, v.math))
+			v.add(ordAtom, h.Handle(v.p, n, v.state, v.math, v.style))
 		}
 
+	case *ast.Sub:
+		v.script(n.Node, true)
+		return nil
+
+	case *ast.Sup:
+		v.script(n.Node, false)
+		return nil
+
 	case *ast.MathExpr:
-		oldm := v.math
-		oldt := v.state.Font.Type
-		v.math = true
-		v.state.Font.Type = rcparams("mathtext.default").(string)
+		style := textStyle
+		if n.Delim == `\[` {
+			style = displayStyle
+		}
+		math := visitor{p: v.p, state: v.state, math: true, base: style, style: style}
+		math.state.Font.Type = rcparams("mathtext.default").(string)
 
 		for _, x := range n.List {
-			v.Visit(x)
+			math.Visit(x)
+		}
+		for _, node := range math.hlist() {
+			v.add(noAtom, node)
 		}
-		v.math = oldm
-		v.state.Font.Type = oldt
+		return nil
+
+	case *ast.Env:
+		if v.math {
+			v.add(ordAtom, v.p.display(n, v.state, v.style))
+			return nil
+		}
+		v.add(noAtom, v.p.display(n, v.state, displayStyle))
+		return nil
+
+	case *ast.Table:
+		class := ordAtom
+		if d := matrixDelims[n.Name.Name]; d[0] != "." || d[1] != "." {
+			// a delimited matrix is an inner atom, as \left...\right.
+			class = innerAtom
+		}
+		v.add(class, v.p.matrix(n, v.state, v.style))
 		return nil
 
 	case *ast.Macro:
@@ -114,11 +289,51 @@ func (v *visitor) Visit(n ast.Node) ast.Visitor {
 			panic("macro with nil identifier")
 		}
 		macro := n.Name.Name
+		if style, ok := mathStyles[macro]; ok {
+			v.style = style
+			return nil
+		}
+		if font, ok := fontOf(macro); ok && v.math && len(n.Args) == 0 {
+			// font switches, such as \bf, apply to the rest of the list.
+			v.state.Font.Type = font
+			return nil
+		}
+		if _, ok := accents[macro]; ok && v.math {
+			// the accent applies to the next atom, e.g. \hat x or \hat{x}.
+			v.accents = append(v.accents, macro)
+			return nil
+		}
+		switch macro {
+		case `\limits`, `\nolimits`:
+			// limits placement applies to the preceding large operator.
+			if n := len(v.atoms); n > 0 && v.atoms[n-1].class == opAtom {
+				v.atoms[n-1].limits = withLimits
+				if macro == `\nolimits` {
+					v.atoms[n-1].limits = noLimits
+				}
+			}
+			return nil
+		case `\color`:
+			// color switches apply to the rest of the list.
+			v.state.Color = v.p.macroColors[n]
+			return nil
+		case `\definecolor`:
+			// colors are defined by resolveColors.
+			return nil
+		}
 		h := v.p.handler(macro)
 		if h == nil {
 			panic(fmt.Errorf("unknown macro %q", macro))
 		}
-		v.nodes = append(v.nodes, h.Handle(v.p, n, v.state, v.math))
+		class := classOf(macro)
+		switch macro {
+		case `\overset`, `\underset`:
+			class = stackedClass(n.Args[1])
+		}
+		v.add(class, h.Handle(v.p, n, v.state, v.math, v.style))
+		if class == opAtom && v.math {
+			v.atoms[len(v.atoms)-1].limits = limitsOf(macro)
+		}
 		return nil
 
 	case nil:
@@ -130,10 +345,19 @@ func (v *visitor) Visit(n ast.Node) ast.Visitor {
 	return v
 }
 
-func (p *parser) handleNode(node ast.Node, state tex.State, math bool) tex.Node {
-	v := visitor{p: p, state: state, math: math}
-	ast.Walk(&v, node)
-	return tex.HListOf(v.nodes, true)
+// handleNode typesets node in the provided style.
+// The returned node is sized for that style.
+func (p *parser) handleNode(node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	v := visitor{p: p, state: state, math: math, base: style, style: style}
+	switch node := node.(type) {
+	case ast.List:
+		for _, x := range node {
+			ast.Walk(&v, x)
+		}
+	default:
+		ast.Walk(&v, node)
+	}
+	return tex.HListOf(v.hlist(), true)
 }
 
 func (p *parser) handler(name string) handler {
@@ -143,12 +367,21 @@ func (p *parser) handler(name string) handler {
 	if symbols.IsSpaced(name) || symbols.PunctuationSymbols.Has(name) {
 		return handlerFunc(handleSymbol)
 	}
+	if symbols.LeftDelim.Has(name) || symbols.RightDelim.Has(name) || symbols.AmbiDelim.Has(name) {
+		return handlerFunc(handleSymbol)
+	}
 	if name == `\hspace` {
 		return handlerFunc(handleCustomSpace)
 	}
 	if symbols.FunctionNames.Has(name[1:]) { // drop leading `\`
 		return handlerFunc(handleFunction)
 	}
+	if _, ok := xarrows[name]; ok {
+		return handlerFunc(handleXArrow)
+	}
+	if _, ok := stretchyArrows[name]; ok {
+		return handlerFunc(handleStretchyArrow)
+	}
 	switch name {
 	case `\frac`:
 		return handlerFunc(handleFrac)
@@ -164,6 +397,34 @@ func (p *parser) handler(name string) handler {
 		return handlerFunc(handleSqrt)
 	case `\overline`:
 		return handlerFunc(handleOverline)
+	case `\overset`, `\underset`, `\stackrel`:
+		return handlerFunc(handleStack)
+	case `\overbrace`, `\underbrace`:
+		return handlerFunc(handleBrace)
+	case `\phantom`, `\hphantom`, `\vphantom`:
+		return handlerFunc(handlePhantom)
+	case `\smash`:
+		return handlerFunc(handleSmash)
+	case `\mathllap`, `\mathrlap`, `\mathclap`:
+		return handlerFunc(handleLap)
+	case `\boxed`:
+		return handlerFunc(handleBoxed)
+	case `\fbox`, `\framebox`:
+		return handlerFunc(handleFrameBox)
+	case `\cancel`, `\bcancel`, `\xcancel`:
+		return handlerFunc(handleCancel)
+	case `\mathchoice`:
+		return handlerFunc(handleMathChoice)
+	case `\textcolor`:
+		return handlerFunc(handleTextColor)
+	case `\colorbox`:
+		return handlerFunc(handleColorBox)
+	}
+	if _, ok := fontOf(name); ok && strings.HasPrefix(name, `\math`) {
+		return handlerFunc(handleMathFont)
+	}
+	if _, ok := textFontOf(name); ok {
+		return handlerFunc(handleText)
 	}
 	_, ok := p.macros[name]
 	if ok {
@@ -181,8 +442,7 @@ func (p *parser) init() {
 	}
 }
 
-func handleSymbol(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
-	pos := int(node.Pos())
+func handleSymbol(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	sym := ""
 	switch node := node.(type) {
 	case *ast.Macro:
@@ -196,47 +456,9 @@ func handleSymbol(p *parser, node ast.Node, state tex.State, math bool) tex.Node
 	default:
 		panic("invalid ast Node")
 	}
-	ch := tex.NewChar(sym, state, math)
-	switch {
-	case symbols.IsSpaced(sym):
-		i := strings.LastIndexFunc(p.expr[:pos], func(r rune) bool {
-			return r != ' '
-		})
-		prev := ""
-		if i >= 0 {
-			prev = string(p.expr[i])
-		}
-		switch {
-		case symbols.BinaryOperators.Has(sym) && (len(strings.Split(p.expr[:pos], " ")) == 0 ||
-			prev == "{" ||
-			symbols.LeftDelim.Has(prev)):
-			// binary operators at start of string should not be spaced
-			return ch
-		default:
-			return tex.HListOf([]tex.Node{
-				p.makeSpace(state, 0.2),
-				ch,
-				p.makeSpace(state, 0.2),
-			}, true)
-		}
-
-	case symbols.PunctuationSymbols.Has(sym):
-		switch sym {
-		case ".":
-			pos := strings.Index(p.expr[pos:], sym)
-			if (pos > 0 && isdigit(p.expr[pos-1])) &&
-				(pos < len(p.expr)-1 && isdigit(p.expr[pos+1])) {
-				// do not space dots as decimal separators.
-				return ch
-			}
-			return tex.HListOf([]tex.Node{
-				ch,
-				p.makeSpace(state, 0.2),
-			}, true)
-		}
-		panic("not implemented")
-	}
-	return ch
+	// spacing around the symbol is handled by the math list, from its
+	// atom class.
+	return tex.NewChar(sym, state, math)
 }
 
 var spaceWidth = map[string]float64{
@@ -255,7 +477,7 @@ var spaceWidth = map[string]float64{
 
 }
 
-func handleSpace(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
+func handleSpace(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	var (
 		width float64
 		ok    bool
@@ -275,7 +497,7 @@ func handleSpace(p *parser, node ast.Node, state tex.State, math bool) tex.Node
 	return p.makeSpace(state, width)
 }
 
-func handleCustomSpace(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
+func handleCustomSpace(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	macro := node.(*ast.Macro)
 	arg := macro.Args[0].(*ast.Arg).List[0].(*ast.Literal).Text
 	val, err := strconv.ParseFloat(arg, 64)
@@ -285,7 +507,7 @@ func handleCustomSpace(p *parser, node ast.Node, state tex.State, math bool) tex
 	return p.makeSpace(state, val)
 }
 
-func handleFunction(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
+func handleFunction(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	macro := node.(*ast.Macro)
 	state.Font.Type = "rm"
 	fun := macro.Name.Name[1:] // drop leading `\`
@@ -296,73 +518,50 @@ func handleFunction(p *parser, node ast.Node, state tex.State, math bool) tex.No
 	return tex.HListOf(nodes, true)
 }
 
-func handleFrac(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
-	var (
-		macro     = node.(*ast.Macro)
-		thickness = state.Backend().UnderlineThickness(state.Font, state.DPI)
-		numNode   = ast.List(macro.Args[0].(*ast.Arg).List)
-		denNode   = ast.List(macro.Args[1].(*ast.Arg).List)
-	)
-
-	num := p.handleNode(numNode, state, math)
-	den := p.handleNode(denNode, state, math)
-
-	// FIXME(sbinet): this should be infered from the context.
-	// ie: textStyle    when in $  $ environment.
-	//     displayStyle when in \[\] environment.
-	sty := textStyle
-
-	return p.genfrac("", "", thickness, sty, num, den, state)
+func handleFrac(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	thickness := state.Backend().UnderlineThickness(state.Font, state.DPI)
+	return p.fraction(node.(*ast.Macro), "", "", thickness, style, state, math)
 }
 
-func handleDFrac(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
-	var (
-		macro     = node.(*ast.Macro)
-		thickness = state.Backend().UnderlineThickness(state.Font, state.DPI)
-		numNode   = ast.List(macro.Args[0].(*ast.Arg).List)
-		denNode   = ast.List(macro.Args[1].(*ast.Arg).List)
-	)
-
-	num := p.handleNode(numNode, state, math)
-	den := p.handleNode(denNode, state, math)
-
-	return p.genfrac("", "", thickness, displayStyle, num, den, state)
+func handleDFrac(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	thickness := state.Backend().UnderlineThickness(state.Font, state.DPI)
+	frac := p.fraction(node.(*ast.Macro), "", "", thickness, displayStyle, state, math)
+	resize(frac, displayStyle, style)
+	return frac
 }
 
-func handleTFrac(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
-	var (
-		macro     = node.(*ast.Macro)
-		thickness = state.Backend().UnderlineThickness(state.Font, state.DPI)
-		numNode   = ast.List(macro.Args[0].(*ast.Arg).List)
-		denNode   = ast.List(macro.Args[1].(*ast.Arg).List)
-	)
-
-	num := p.handleNode(numNode, state, math)
-	den := p.handleNode(denNode, state, math)
+func handleTFrac(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	thickness := state.Backend().UnderlineThickness(state.Font, state.DPI)
+	frac := p.fraction(node.(*ast.Macro), "", "", thickness, textStyle, state, math)
+	resize(frac, textStyle, style)
+	return frac
+}
 
-	return p.genfrac("", "", thickness, textStyle, num, den, state)
+func handleBinom(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	return p.fraction(node.(*ast.Macro), "(", ")", 0, style, state, math)
 }
 
-func handleBinom(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
+// fraction typesets the first two arguments of the macro as a fraction in
+// the provided style.
+func (p *parser) fraction(macro *ast.Macro, ldelim, rdelim string, rule float64, style mathStyleKind, state tex.State, math bool) tex.Node {
 	var (
-		macro   = node.(*ast.Macro)
 		numNode = ast.List(macro.Args[0].(*ast.Arg).List)
 		denNode = ast.List(macro.Args[1].(*ast.Arg).List)
 	)
 
-	num := p.handleNode(numNode, state, math)
-	den := p.handleNode(denNode, state, math)
+	num := p.handleNode(numNode, state, math, style.num())
+	den := p.handleNode(denNode, state, math, style.den())
 
-	return p.genfrac("(", ")", 0, textStyle, num, den, state)
+	return p.genfrac(ldelim, rdelim, rule, style, num, den, state)
 }
 
+// genfrac typesets a generalized fraction in the provided style, from its
+// numerator and denominator sized for the numerator and denominator styles.
 func (p *parser) genfrac(ldelim, rdelim string, rule float64, style mathStyleKind, num, den tex.Node, state tex.State) tex.Node {
 	thickness := state.Backend().UnderlineThickness(state.Font, state.DPI)
 
-	if style != displayStyle {
-		num.Shrink()
-		den.Shrink()
-	}
+	resize(num, style.num(), style)
+	resize(den, style.den(), style)
 
 	cnum := tex.HCentered([]tex.Node{num})
 	cden := tex.HCentered([]tex.Node{den})
@@ -381,10 +580,7 @@ func (p *parser) genfrac(ldelim, rdelim string, rule float64, style mathStyleKin
 	})
 
 	// shift so the fraction line sits in the middle of the '=' sign
-	fnt := state.Font
-	fnt.Type = rcparams("mathtext.default").(string)
-	metrics := state.Backend().Metrics("=", fnt, state.DPI, true)
-	shift := cden.Height() - ((metrics.YMax+metrics.YMin)/2 - 3*thickness)
+	shift := cden.Height() - (p.axisHeight(state) - 3*thickness)
 	vlist.SetShift(shift)
 
 	box := tex.HListOf([]tex.Node{vlist, tex.HBox(2 * thickness)}, true)
@@ -401,7 +597,7 @@ func (p *parser) genfrac(ldelim, rdelim string, rule float64, style mathStyleKin
 	return box
 }
 
-func handleSqrt(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
+func handleSqrt(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	var (
 		macro = node.(*ast.Macro)
 		root  tex.Node
@@ -411,17 +607,17 @@ func handleSqrt(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
 	case 2:
 		root = p.handleNode(
 			ast.List(macro.Args[0].(*ast.OptArg).List),
-			state, math,
+			state, math, scriptScriptStyle,
 		)
 		body = p.handleNode(
 			ast.List(macro.Args[1].(*ast.Arg).List),
-			state, math,
+			state, math, style.cramped(),
 		).(*tex.HList)
 	case 1:
 		// ok
 		body = p.handleNode(
 			ast.List(macro.Args[0].(*ast.Arg).List),
-			state, math,
+			state, math, style.cramped(),
 		).(*tex.HList)
 	default:
 		panic("invalid sqrt")
@@ -458,8 +654,8 @@ func handleSqrt(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
 	case nil:
 		root = tex.HBox(check.Width() * 0.5)
 	default:
-		root.Shrink()
-		root.Shrink()
+		// the index of the root is set in scriptscript style.
+		resize(root, scriptScriptStyle, style)
 	}
 
 	vl := tex.VListOf([]tex.Node{
@@ -480,11 +676,11 @@ func handleSqrt(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
 	return hl
 }
 
-func handleOverline(p *parser, node ast.Node, state tex.State, math bool) tex.Node {
+func handleOverline(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
 	macro := node.(*ast.Macro)
 	body := p.handleNode(
 		ast.List(macro.Args[0].(*ast.Arg).List),
-		state, math,
+		state, math, style.cramped(),
 	).(*tex.HList)
 
 	thickness := state.Backend().UnderlineThickness(state.Font, state.DPI)
@@ -507,6 +703,15 @@ func handleOverline(p *parser, node ast.Node, state tex.State, math bool) tex.No
 	return hl
 }
 
+// axisHeight returns the height of the math axis, the middle of the '='
+// sign, above the baseline.
+func (p *parser) axisHeight(state tex.State) float64 {
+	fnt := state.Font
+	fnt.Type = rcparams("mathtext.default").(string)
+	metrics := state.Backend().Metrics("=", fnt, state.DPI, true)
+	return (metrics.YMax + metrics.YMin) / 2
+}
+
 func (p *parser) makeSpace(state tex.State, percentage float64) *tex.Kern {
 	const math = true
 	fnt := state.Font
@@ -546,15 +751,6 @@ func (p *parser) autoSizedDelimiter(left string, middle []tex.Node, right string
 	return tex.HListOf(parts, true)
 }
 
-type mathStyleKind int
-
-const (
-	displayStyle mathStyleKind = iota
-	textStyle
-	//scriptStyle       // FIXME
-	//scriptScriptStyle // FIXME
-)
-
 func rcparams(k string) interface{} {
 	switch k {
 	case "mathtext.default":
@@ -563,11 +759,3 @@ func rcparams(k string) interface{} {
 		panic("unknown rc.params key [" + k + "]")
 	}
 }
-
-func isdigit(v byte) bool {
-	switch v {
-	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		return true
-	}
-	return false
-}
@@ -55,55 +55,109 @@ func TestParse(t *testing.T) {
 		},
 		{
 			expr: `$1.$`,
-			w:    11.4892578125,
+			w:    9.541015625,
 			h:    7.296875,
 			d:    0.0,
 		},
 		{
 			expr: `$.2$`,
-			w:    11.4892578125,
+			w:    9.541015625,
 			h:    7.421875,
 			d:    0.0,
 		},
 		{
 			expr: `$.$`,
-			w:    5.126953125,
+			w:    3.1787109375,
 			h:    1.234375,
 			d:    0.0,
 		},
 		{
 			expr: `$x.x$`,
-			w:    16.962890625,
+			w:    15.0146484375,
 			h:    5.46875,
 			d:    0.0,
 		},
-		//{ // FIXME(sbinet): handler for '('
-		//	expr: `$\sigma = f(x)$`,
-		//	w:    35.8544921875,
-		//	h:    7.59375,
-		//	d:    1.3125,
-		//},
+		{
+			expr: `$\sigma = f(x)$`,
+			w:    37.36979166666667,
+			h:    7.59375,
+			d:    1.3125,
+		},
+		{
+			expr: `$a-b$`,
+			w:    25.183919270833332,
+			h:    7.59375,
+			d:    0.140625,
+		},
+		{
+			expr: `$-a$`,
+			w:    14.5068359375,
+			h:    5.59375,
+			d:    0.140625,
+		},
+		{
+			expr: `$(-a)$`,
+			w:    22.3095703125,
+			h:    7.59375,
+			d:    1.3125,
+		},
+		{
+			expr: `${-a}+b$`,
+			w:    33.562825520833336,
+			h:    7.59375,
+			d:    0.140625,
+		},
+		{
+			expr: `$x^2$`,
+			w:    11.00595703125,
+			h:    9.80546875,
+			d:    0,
+		},
+		{
+			expr: `$x_i$`,
+			w:    8.49716796875,
+			h:    5.46875,
+			d:    1.903125,
+		},
+		{
+			expr: `$x_i^2$`,
+			w:    11.00595703125,
+			h:    9.805468750000001,
+			d:    3.2054687499999996,
+		},
+		{
+			expr: `$e^{x_1^2}$`,
+			w:    14.490888671875,
+			h:    11.473984375,
+			d:    0.140625,
+		},
+		{
+			expr: `$\sum\limits_{i=1}^n$`,
+			w:    12.263671875,
+			h:    17.305468750000003,
+			d:    12.2046875,
+		},
 		{
 			expr: `$\sigma \rightarrow \infty$`,
-			w:    26.943359375,
+			w:    28.458658854166668,
 			h:    5.46875,
 			d:    0.140625,
 		},
 		{
 			expr: `$\sigma\,=\infty$`,
-			w:    28.566927001953125,
+			w:    30.082226481119793,
 			h:    5.46875,
 			d:    0.140625,
 		},
 		{
 			expr: `$\sigma\hspace{2}=\infty$`,
-			w:    46.42578125,
+			w:    47.941080729166664,
 			h:    5.46875,
 			d:    0.140625,
 		},
 		{
 			expr: `$\cos\theta$`,
-			w:    22.9443359375,
+			w:    24.56787109375,
 			h:    7.671875,
 			d:    0.140625,
 		},
@@ -0,0 +1,152 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"fmt"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// handlePhantom typesets the argument of \phantom, \hphantom or \vphantom
+// as an empty box: \phantom keeps the width, height and depth of the
+// argument, \hphantom only its width and \vphantom only its height and
+// depth.
+func handlePhantom(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro   = node.(*ast.Macro)
+		arg     = ast.List(macro.Args[0].(*ast.Arg).List)
+		body    = p.handleNode(arg, state, math, style)
+		w, h, d = body.Width(), body.Height(), body.Depth()
+	)
+	switch macro.Name.Name {
+	case `\hphantom`:
+		h, d = 0, 0
+	case `\vphantom`:
+		w = 0
+	}
+	// boxes draw nothing: the phantom only takes room.
+	return tex.HListOf([]tex.Node{tex.VBox(h, d), tex.HBox(w)}, false)
+}
+
+// handleSmash typesets the argument of \smash with a zero height and
+// depth, so it does not take room above or below the baseline.
+// As with amsmath, \smash[t] only zeroes the height, and \smash[b] the
+// depth.
+func handleSmash(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	macro := node.(*ast.Macro)
+	top, bottom, err := smashedSides(macro)
+	if err != nil {
+		// options are checked by checkPhantoms.
+		panic(err)
+	}
+	var (
+		arg   = ast.List(macro.Args[len(macro.Args)-1].(*ast.Arg).List)
+		body  = p.handleNode(arg, state, math, style)
+		nodes = []tex.Node{body}
+	)
+	// the kerns cancel the height and depth of the body in the vertical
+	// list, without moving the body off the baseline.
+	if top {
+		nodes = append([]tex.Node{tex.NewKern(-body.Height())}, nodes...)
+	}
+	if bottom {
+		nodes = append(nodes, tex.NewKern(-body.Depth()))
+	}
+	return tex.HListOf([]tex.Node{tex.VListOf(nodes)}, false)
+}
+
+// handleLap typesets the argument of \mathllap, \mathrlap or \mathclap with
+// a zero width, so it overlaps the material on its left, on its right or
+// on both sides.
+// As with mathtools, an optional argument, such as \scriptstyle, sets the
+// style of the argument.
+func handleLap(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		from  = style
+	)
+	style, err := lapStyle(macro, style)
+	if err != nil {
+		// options are checked by checkPhantoms.
+		panic(err)
+	}
+	var (
+		arg  = ast.List(macro.Args[len(macro.Args)-1].(*ast.Arg).List)
+		body = p.handleNode(arg, state, math, style)
+	)
+	resize(body, style, from)
+	w := body.Width()
+	switch macro.Name.Name {
+	case `\mathllap`:
+		return tex.HListOf([]tex.Node{tex.NewKern(-w), body}, false)
+	case `\mathrlap`:
+		return tex.HListOf([]tex.Node{body, tex.NewKern(-w)}, false)
+	default:
+		return tex.HListOf([]tex.Node{tex.NewKern(-w / 2), body, tex.NewKern(-w / 2)}, false)
+	}
+}
+
+// smashedSides returns whether \smash zeroes the height and the depth of
+// its argument, from its optional argument.
+func smashedSides(macro *ast.Macro) (top, bottom bool, err error) {
+	opt, ok := macro.Args[0].(*ast.OptArg)
+	if !ok {
+		return true, true, nil
+	}
+	switch pos := textOf(opt.List); pos {
+	case "t":
+		return true, false, nil
+	case "b":
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf("invalid %s position %q", macro.Name.Name, pos)
+	}
+}
+
+// lapStyle returns the style of the argument of \mathllap, \mathrlap or
+// \mathclap, from its optional argument.
+func lapStyle(macro *ast.Macro, style mathStyleKind) (mathStyleKind, error) {
+	opt, ok := macro.Args[0].(*ast.OptArg)
+	if !ok {
+		return style, nil
+	}
+	if len(opt.List) == 1 {
+		if m, ok := opt.List[0].(*ast.Macro); ok {
+			if s, ok := mathStyles[m.Name.Name]; ok {
+				return s, nil
+			}
+		}
+	}
+	name := textOf(opt.List)
+	for _, node := range opt.List {
+		if m, ok := node.(*ast.Macro); ok {
+			name = m.Name.Name
+			break
+		}
+	}
+	return style, fmt.Errorf("invalid %s style %q", macro.Name.Name, name)
+}
+
+// checkPhantoms checks the optional arguments of \smash and of the
+// \mathllap, \mathrlap and \mathclap overlaps.
+func checkPhantoms(node ast.Node) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		macro, ok := node.(*ast.Macro)
+		if !ok || err != nil || len(macro.Args) == 0 {
+			return err == nil
+		}
+		switch macro.Name.Name {
+		case `\smash`:
+			_, _, err = smashedSides(macro)
+		case `\mathllap`, `\mathrlap`, `\mathclap`:
+			_, err = lapStyle(macro, textStyle)
+		}
+		return err == nil
+	})
+	return err
+}
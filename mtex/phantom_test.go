@@ -0,0 +1,113 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPhantom(t *testing.T) {
+	var (
+		body    = mustParse(t, `$\frac{a}{b}$`)
+		small   = mustParse(t, `$\scriptstyle \frac{a}{b}$`)
+		w, h, d = body.Width(), body.Height(), body.Depth()
+		glyphs  = 2 // glyphs of the body, the bar being a rule
+	)
+	for _, tc := range []struct {
+		expr    string
+		w, h, d float64
+		glyphs  int
+	}{
+		{expr: `$\phantom{\frac{a}{b}}$`, w: w, h: h, d: d},
+		{expr: `$\hphantom{\frac{a}{b}}$`, w: w},
+		{expr: `$\vphantom{\frac{a}{b}}$`, h: h, d: d},
+		{expr: `$\smash{\frac{a}{b}}$`, w: w, glyphs: glyphs},
+		{expr: `$\smash[t]{\frac{a}{b}}$`, w: w, d: d, glyphs: glyphs},
+		{expr: `$\smash[b]{\frac{a}{b}}$`, w: w, h: h, glyphs: glyphs},
+		{expr: `$\mathllap{\frac{a}{b}}$`, h: h, d: d, glyphs: glyphs},
+		{expr: `$\mathrlap{\frac{a}{b}}$`, h: h, d: d, glyphs: glyphs},
+		{expr: `$\mathclap{\frac{a}{b}}$`, h: h, d: d, glyphs: glyphs},
+		{expr: `$\mathclap[\scriptstyle]{\frac{a}{b}}$`, h: small.Height(), d: small.Depth(), glyphs: glyphs},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			box := mustParse(t, tc.expr)
+			if got, want := box.Width(), tc.w; !approxEq(got, want) {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+			if got, want := box.Height(), tc.h; !approxEq(got, want) {
+				t.Fatalf("invalid height: got=%g, want=%g", got, want)
+			}
+			if got, want := box.Depth(), tc.d; !approxEq(got, want) {
+				t.Fatalf("invalid depth: got=%g, want=%g", got, want)
+			}
+
+			if got, want := len(glyphOps(mustRender(t, tc.expr))), tc.glyphs; got != want {
+				t.Fatalf("invalid number of glyphs: got=%d, want=%d", got, want)
+			}
+		})
+	}
+
+	t.Run("overlap", func(t *testing.T) {
+		pos := func(expr, sym string) float64 {
+			t.Helper()
+			glyphs := glyphOps(mustRender(t, expr), sym)
+			if len(glyphs) == 0 {
+				t.Fatalf("no glyph %q in %q", sym, expr)
+			}
+			return glyphs[0].X
+		}
+		var (
+			x    = pos(`$ab$`, "b")
+			llap = pos(`$a\mathllap{b}$`, "b")
+			rlap = pos(`$a\mathrlap{b}$`, "b")
+			clap = pos(`$a\mathclap{b}$`, "b")
+			b    = mustParse(t, `$b$`).Width()
+		)
+		if !approxEq(rlap, x) {
+			t.Fatalf("invalid rlap: got=%g, want=%g", rlap, x)
+		}
+		if !approxEq(llap, x-b) {
+			t.Fatalf("invalid llap: got=%g, want=%g", llap, x-b)
+		}
+		if !approxEq(clap, x-b/2) {
+			t.Fatalf("invalid clap: got=%g, want=%g", clap, x-b/2)
+		}
+	})
+}
+
+func TestPhantomError(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{
+			expr: `$\smash[q]{x}$`,
+			want: `invalid \smash position "q"`,
+		},
+		{
+			expr: `$\mathclap[\alpha]{x}$`,
+			want: `invalid \mathclap style "\\alpha"`,
+		},
+		{
+			expr: `$\mathllap[big]{x}$`,
+			want: `invalid \mathllap style "big"`,
+		},
+		{
+			expr: `$\mathclap[\foo]{x}$`,
+			want: `unknown macro \foo`,
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr, ftsize, dpi, nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got, want := err.Error(), tc.want; !strings.HasSuffix(got, want) {
+				t.Fatalf("invalid error:\ngot= %s\nwant=%s", got, want)
+			}
+		})
+	}
+}
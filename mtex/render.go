@@ -18,6 +18,13 @@ type Renderer interface {
 }
 
 func Render(dst Renderer, expr string, size, dpi float64, fonts *ttf.Fonts) error {
+	return RenderNumbered(dst, expr, size, dpi, fonts, new(Numbering))
+}
+
+// RenderNumbered is like Render, but numbers the equations of display
+// environments following num, which is updated with the last equation
+// number and the tags of the labeled equations.
+func RenderNumbered(dst Renderer, expr string, size, dpi float64, fonts *ttf.Fonts, num *Numbering) error {
 	var (
 		canvas  = drawtex.New()
 		backend *ttf.Backend
@@ -29,11 +36,17 @@ func Render(dst Renderer, expr string, size, dpi float64, fonts *ttf.Fonts) erro
 		backend = ttf.NewFrom(canvas, fonts)
 	}
 
-	box, err := Parse(expr, size, 72, backend)
+	box, err := ParseNumbered(expr, size, 72, backend, num)
 	if err != nil {
 		return fmt.Errorf("could not parse math expression: %w", err)
 	}
 
+	if lines, ok := box.(*tex.VList); ok {
+		// display environments, such as align, are stacked lines:
+		// ship them inside a box.
+		box = tex.HListOf([]tex.Node{lines}, false)
+	}
+
 	var sh tex.Ship
 	sh.Call(0, 0, box.(tex.Tree))
 
@@ -46,6 +46,27 @@ func TestRender(t *testing.T) {
 		{
 			expr: `$\int\frac{\partial x}{x}$`,
 		},
+		{
+			expr: `$f(a, b; c) = n!$`,
+		},
+		{
+			expr: `$x_i^2 + e^{x_1^2} + \int_0^1 f$`,
+		},
+		{
+			expr: `\[\sum_{i=1}^n x_i = \prod\nolimits_{j} y_j + \lim_{x \to 0} f\]`,
+		},
+		{
+			expr: `$\hat{x} + \bar y^2 = \vec{v} \cdot \widehat{xyz} - \hat{\tilde{a}}$`,
+		},
+		{
+			expr: `$A = \begin{pmatrix} a & \cdots & b \\ \vdots & \ddots & \vdots \end{pmatrix} \begin{array}{|l|r|} \hline x & y \\ \cline{1-1} \end{array}$`,
+		},
+		{
+			expr: `\begin{align} f(x) &= (x+1)^2 \\[2pt] &= x^2 + 2x + 1 & g &= \begin{cases} 1 & x > 0 \\ 0 & \text{otherwise} \end{cases} \end{align}`,
+		},
+		{
+			expr: `\begin{gather} a = b \\ \begin{split} c &= d \\ &= e \end{split} \end{gather}`,
+		},
 	} {
 		t.Run(tc.expr, func(t *testing.T) {
 			err := Render(dummyRenderer{}, tc.expr, ftsize, dpi, nil)
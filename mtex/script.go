@@ -0,0 +1,125 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// scripts attaches the subscript and superscript of an atom to its nucleus,
+// following the rules 18a to 18f of Appendix G of the TeXbook.
+// The superscript is moved right by delta, the italic correction of the
+// nucleus.
+// The returned node is sized for the style of the atom.
+func (p *parser) scripts(a atom, delta float64, state tex.State) tex.Node {
+	var (
+		fc        = tex.DefaultFontConstants
+		xheight   = p.be.XHeight(state.Font, state.DPI)
+		thickness = p.be.UnderlineThickness(state.Font, state.DPI)
+
+		// x-height of the font of the scripts.
+		xhsup = xheight * a.style.sup().scale(a.style)
+		xhsub = xheight * a.style.sub().scale(a.style)
+
+		nucleus = a.node
+
+		u float64 // raise of the superscript
+		v float64 // drop of the subscript
+	)
+
+	// rule 18a: the scripts of a character are placed from the baseline,
+	// the ones of a box from its top and bottom.
+	if _, ok := nucleus.(*tex.Char); !ok || a.class == opAtom {
+		u = nucleus.Height() - fc.SupDrop*xhsup
+		v = nucleus.Depth() + fc.SubDrop*xhsub
+	}
+
+	var x, y tex.Node // superscript and subscript boxes
+	if a.sup != nil {
+		x = p.scriptBox(a.sup, a.style.sup(), a.style, xheight, state)
+	}
+	if a.sub != nil {
+		y = p.scriptBox(a.sub, a.style.sub(), a.style, xheight, state)
+	}
+
+	if x == nil {
+		// rule 18b: subscript only.
+		v = math.Max(v, math.Max(fc.Sub1*xheight, y.Height()-4*xheight/5))
+		sub := tex.VListOf([]tex.Node{y})
+		sub.SetShift(v)
+		return &tex.SubSuperCluster{
+			HList:   tex.HListOf([]tex.Node{nucleus, sub}, true),
+			Nucleus: nucleus,
+			Sub:     y,
+		}
+	}
+
+	// rule 18c: raise the superscript.
+	var sup1 float64
+	switch {
+	case a.style == displayStyle:
+		sup1 = fc.Sup1
+	case a.style.isCramped():
+		sup1 = fc.Sup3
+	default:
+		sup1 = fc.Sup2
+	}
+	u = math.Max(u, math.Max(sup1*xheight, x.Depth()+xheight/4))
+
+	if y == nil {
+		// rule 18d: superscript only.
+		sup := tex.VListOf([]tex.Node{x})
+		sup.SetShift(-u)
+		return &tex.SubSuperCluster{
+			HList:   tex.HListOf([]tex.Node{nucleus, tex.NewKern(delta), sup}, true),
+			Nucleus: nucleus,
+			Super:   x,
+		}
+	}
+
+	// rule 18e: leave at least 4θ between the subscript and superscript,
+	// and keep the bottom of the superscript at least 4/5 of the x-height
+	// above the baseline.
+	v = math.Max(v, fc.Sub2*xheight)
+	if gap := (u - x.Depth()) - (y.Height() - v); gap < 4*thickness {
+		v = 4*thickness - u + x.Depth() + y.Height()
+		if psi := 4*xheight/5 - (u - x.Depth()); psi > 0 {
+			u += psi
+			v -= psi
+		}
+	}
+
+	// rule 18f: stack the superscript, shifted by the italic correction,
+	// over the subscript.
+	scripts := tex.VListOf([]tex.Node{
+		tex.HListOf([]tex.Node{tex.NewKern(delta), x}, true),
+		tex.NewKern((u - x.Depth()) - (y.Height() - v)),
+		y,
+	})
+	scripts.SetShift(v)
+
+	return &tex.SubSuperCluster{
+		HList:   tex.HListOf([]tex.Node{nucleus, scripts}, true),
+		Nucleus: nucleus,
+		Sub:     y,
+		Super:   x,
+	}
+}
+
+// scriptBox typesets a sub- or superscript in the provided script style,
+// followed by the script space.
+// The returned node is sized for the style of its nucleus.
+func (p *parser) scriptBox(node ast.Node, script, style mathStyleKind, xheight float64, state tex.State) tex.Node {
+	const math = true
+	box := p.handleNode(node, state, math, script)
+	resize(box, script, style)
+	return tex.HListOf([]tex.Node{
+		box,
+		tex.NewKern(tex.DefaultFontConstants.ScriptSpace * xheight),
+	}, true)
+}
@@ -0,0 +1,78 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"testing"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/font"
+	"github.com/go-latex/latex/internal/fakebackend"
+	"github.com/go-latex/latex/tex"
+)
+
+func TestScripts(t *testing.T) {
+	var (
+		be        = fakebackend.New()
+		fnt       = font.Font{Name: "default", Size: ftsize, Type: "it"}
+		xheight   = be.XHeight(fnt, dpi)
+		thickness = be.UnderlineThickness(fnt, dpi)
+		fc        = tex.DefaultFontConstants
+	)
+
+	t.Run("raise", func(t *testing.T) {
+		for _, tc := range []struct {
+			style mathStyleKind
+			sup   float64
+		}{
+			{displayStyle, fc.Sup1},
+			{textStyle, fc.Sup2},
+			{textStyle.cramped(), fc.Sup3},
+		} {
+			p := newParser(be)
+			state := tex.NewState(be, fnt, dpi)
+			a := atom{
+				class: ordAtom,
+				node:  tex.NewChar("x", state, true),
+				style: tc.style,
+				sup:   &ast.Literal{Text: "2"},
+			}
+			c := p.scripts(a, 0, state).(*tex.SubSuperCluster)
+			got := c.Height() - c.Super.Height()
+			if want := tc.sup * xheight; !approxEq(got, want) {
+				t.Fatalf("invalid raise in style %d: got=%g, want=%g", tc.style, got, want)
+			}
+		}
+	})
+
+	t.Run("display", func(t *testing.T) {
+		var (
+			text    = mustParseWith(t, be, `$x^2$`)
+			display = mustParseWith(t, be, `\[x^2\]`)
+		)
+		if !(display.Height() > text.Height()) {
+			t.Fatalf("superscript not raised more in display style: text=%g, display=%g",
+				text.Height(), display.Height(),
+			)
+		}
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		p := newParser(be)
+		state := tex.NewState(be, fnt, dpi)
+		a := atom{
+			class: ordAtom,
+			node:  tex.NewChar("x", state, true),
+			style: textStyle,
+			sub:   &ast.Literal{Text: "i"},
+			sup:   &ast.Literal{Text: "2"},
+		}
+		c := p.scripts(a, 0, state).(*tex.SubSuperCluster)
+		gap := c.Height() + c.Depth() - c.Super.Height() - c.Super.Depth() - c.Sub.Height() - c.Sub.Depth()
+		if gap < 4*thickness {
+			t.Fatalf("invalid gap between sub- and superscript: got=%g, want>=%g", gap, 4*thickness)
+		}
+	})
+}
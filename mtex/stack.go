@@ -0,0 +1,110 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+const (
+	// braceGap is the space between a horizontal brace and the material it
+	// spans, relatively to the font size (3pt at 10pt).
+	braceGap = 0.3
+
+	// braceHeight is the height of a horizontal brace, relatively to the
+	// font size.
+	braceHeight = 0.45
+)
+
+// handleStack stacks the first argument of \overset or \stackrel over the
+// second one, or the first argument of \underset under the second one.
+// As with amsmath, the base is a large operator with limits: the stacked
+// material is set in script style and spaced following the rule 13a of
+// Appendix G of the TeXbook.
+func handleStack(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		arg   = ast.List(macro.Args[0].(*ast.Arg).List)
+		base  = p.handleNode(ast.List(macro.Args[1].(*ast.Arg).List), state, math, style)
+		a     = atom{class: opAtom, node: base, style: style, limits: withLimits}
+	)
+	switch macro.Name.Name {
+	case `\underset`:
+		a.sub = arg
+	default:
+		a.sup = arg
+	}
+	return p.limits(a, 0, state)
+}
+
+// stackedClass returns the class of a stacked construct, from its base.
+// As with amsmath, the construct is a binary operation or a relation when
+// its base is one, and an ordinary atom otherwise.
+func stackedClass(base ast.Node) atomClass {
+	arg, ok := base.(*ast.Arg)
+	if !ok || len(arg.List) != 1 {
+		return ordAtom
+	}
+	var name string
+	switch node := arg.List[0].(type) {
+	case *ast.Macro:
+		name = node.Name.Name
+	case *ast.Symbol:
+		name = node.Text
+	}
+	switch class := classOf(name); class {
+	case binAtom, relAtom:
+		return class
+	}
+	return ordAtom
+}
+
+// handleBrace puts a horizontal brace over the argument of \overbrace, or
+// under the argument of \underbrace.
+// The result is a large operator taking limits, so a superscript of
+// \overbrace or a subscript of \underbrace labels the brace.
+func handleBrace(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	var (
+		macro = node.(*ast.Macro)
+		over  = macro.Name.Name == `\overbrace`
+		arg   = ast.List(macro.Args[0].(*ast.Arg).List)
+	)
+	if over {
+		style = style.cramped()
+	}
+	var (
+		body  = p.handleNode(arg, state, math, style)
+		size  = state.Font.Size * state.DPI / 72
+		gap   = tex.NewKern(braceGap * size)
+		brace = p.brace(body.Width(), over, state)
+	)
+	if over {
+		return tex.VListOf([]tex.Node{brace, gap, body})
+	}
+	box := tex.VListOf([]tex.Node{body, gap, brace})
+	box.SetShift(body.Depth() + braceGap*size + brace.Height())
+	return tex.HListOf([]tex.Node{box}, true)
+}
+
+// brace returns a horizontal brace of the provided width, pointing up when
+// over is true and down otherwise.
+// The brace is an extensible symbol, set at the font size that gives it its
+// height.
+func (p *parser) brace(width float64, over bool, state tex.State) tex.Node {
+	sym := "⏟"
+	if over {
+		sym = "⏞"
+	}
+	var (
+		size  = state.Font.Size * state.DPI / 72
+		brace = tex.AutoWidthChar(sym, width, state)
+	)
+	if h := brace.Height() + brace.Depth(); h > 0 {
+		state.Font.Size *= braceHeight * size / h
+		brace = tex.AutoWidthChar(sym, width, state)
+	}
+	return brace
+}
@@ -0,0 +1,140 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStack(t *testing.T) {
+	eq := mustParse(t, `$=$`)
+	for _, tc := range []struct {
+		expr  string
+		over  bool
+		width string // expression with the same width
+	}{
+		{expr: `$\overset{a}{=}$`, over: true},
+		{expr: `$\stackrel{a}{=}$`, over: true, width: `$\overset{a}{=}$`},
+		{expr: `$\underset{a}{=}$`, width: `$\overset{a}{=}$`},
+		{expr: `$\overset{\text{def}}{=}$`, over: true},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			box := mustParse(t, tc.expr)
+			if !(box.Width() >= eq.Width()) {
+				t.Fatalf("invalid width: got=%g, want>=%g", box.Width(), eq.Width())
+			}
+			if tc.width != "" {
+				if got, want := box.Width(), mustParse(t, tc.width).Width(); !approxEq(got, want) {
+					t.Fatalf("invalid width: got=%g, want=%g", got, want)
+				}
+			}
+			switch {
+			case tc.over:
+				if !(box.Height() > eq.Height()) || !approxEq(box.Depth(), eq.Depth()) {
+					t.Fatalf("invalid over: h=%g, d=%g", box.Height(), box.Depth())
+				}
+			default:
+				if !approxEq(box.Height(), eq.Height()) || !(box.Depth() > eq.Depth()) {
+					t.Fatalf("invalid under: h=%g, d=%g", box.Height(), box.Depth())
+				}
+			}
+		})
+	}
+
+	t.Run("class", func(t *testing.T) {
+		var (
+			xy  = mustParse(t, `$xy$`)
+			rel = mustParse(t, `$x=y$`).Width() - eq.Width()
+			bin = mustParse(t, `$x+y$`).Width() - mustParse(t, `$+$`).Width()
+		)
+		for _, tc := range []struct {
+			expr string
+			base string
+			want float64 // width of x and y, with the spacing around the base
+		}{
+			{expr: `$x\overset{a}{=}y$`, base: `$\overset{a}{=}$`, want: rel},
+			{expr: `$x\stackrel{a}{b}y$`, base: `$\stackrel{a}{b}$`, want: rel},
+			{expr: `$x\underset{a}{+}y$`, base: `$\underset{a}{+}$`, want: bin},
+			{expr: `$x\overset{a}{b}y$`, base: `$\overset{a}{b}$`, want: xy.Width()},
+		} {
+			got := mustParse(t, tc.expr).Width() - mustParse(t, tc.base).Width()
+			if !approxEq(got, tc.want) {
+				t.Fatalf("%s: invalid spacing: got=%g, want=%g", tc.expr, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("braces", func(t *testing.T) {
+		var (
+			body  = mustParse(t, `$a+b$`)
+			over  = mustParse(t, `$\overbrace{a+b}$`)
+			under = mustParse(t, `$\underbrace{a+b}$`)
+			size  = braceHeight * ftsize
+		)
+		if got, want := over.Width(), body.Width(); !approxEq(got, want) {
+			t.Fatalf("invalid overbrace width: got=%g, want=%g", got, want)
+		}
+		if got, want := under.Width(), body.Width(); !approxEq(got, want) {
+			t.Fatalf("invalid underbrace width: got=%g, want=%g", got, want)
+		}
+		if !(over.Height() > body.Height()+size) || !approxEq(over.Depth(), body.Depth()) {
+			t.Fatalf("invalid overbrace: h=%g, d=%g", over.Height(), over.Depth())
+		}
+		if !approxEq(under.Height(), body.Height()) || !(under.Depth() > body.Depth()+size) {
+			t.Fatalf("invalid underbrace: h=%g, d=%g", under.Height(), under.Depth())
+		}
+
+		var (
+			olabel = mustParse(t, `$\overbrace{a+b}^{n}$`)
+			ulabel = mustParse(t, `$\underbrace{a+b}_{n}$`)
+		)
+		if !(olabel.Height() > over.Height()) || !approxEq(olabel.Width(), over.Width()) {
+			t.Fatalf("overbrace label not above: h=%g, w=%g", olabel.Height(), olabel.Width())
+		}
+		if !(ulabel.Depth() > under.Depth()) || !approxEq(ulabel.Width(), under.Width()) {
+			t.Fatalf("underbrace label not below: d=%g, w=%g", ulabel.Depth(), ulabel.Width())
+		}
+	})
+
+	t.Run("render", func(t *testing.T) {
+		for _, tc := range []struct {
+			expr string
+			over bool
+			want [3]string // left, middle and right pieces of the brace
+		}{
+			{expr: `$\overbrace{a+b+c}^{3}$`, over: true, want: [3]string{"╭", "╯╰", "╮"}},
+			{expr: `$\underbrace{a+b+c}_{3}$`, want: [3]string{"╰", "╮╭", "╯"}},
+		} {
+			t.Run(tc.expr, func(t *testing.T) {
+				var (
+					ops   = mustRender(t, tc.expr)
+					brace = glyphOps(ops, "╭", "╮", "╯", "╰", "─")
+					body  = glyphOps(ops, "b")[0]
+					got   string
+				)
+				for _, g := range brace {
+					got += g.Glyph.Symbol
+				}
+				n := strings.Count(got, "─") / 2
+				if n == 0 {
+					t.Fatalf("missing rules: %q", got)
+				}
+				rule := strings.Repeat("─", n)
+				if want := tc.want[0] + rule + tc.want[1] + rule + tc.want[2]; got != want {
+					t.Fatalf("invalid brace:\ngot= %q\nwant=%q", got, want)
+				}
+				for _, g := range brace {
+					if above := g.Y < body.Y; above != tc.over {
+						t.Fatalf("invalid brace position: brace=%g, body=%g", g.Y, body.Y)
+					}
+				}
+				if mid := brace[n+1]; !(mid.X > glyphOps(ops, "a")[0].X && mid.X < glyphOps(ops, "c")[0].X) {
+					t.Fatalf("brace not centered on its body")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"math"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/tex"
+)
+
+// mathStyleKind is the TeX style of a math list.
+// Each style has a cramped variant, where superscripts are raised less.
+type mathStyleKind int
+
+const (
+	displayStyle      mathStyleKind = 2 * iota
+	textStyle                       // 2
+	scriptStyle                     // 4
+	scriptScriptStyle               // 6
+
+	cramped mathStyleKind = 1 // cramped flag of a style
+)
+
+// mathStyles are the macros switching the style of the rest of the
+// current math list.
+var mathStyles = map[string]mathStyleKind{
+	`\displaystyle`:      displayStyle,
+	`\textstyle`:         textStyle,
+	`\scriptstyle`:       scriptStyle,
+	`\scriptscriptstyle`: scriptScriptStyle,
+}
+
+// kind returns the non-cramped variant of the style.
+func (style mathStyleKind) kind() mathStyleKind {
+	return style &^ cramped
+}
+
+// cramped returns the cramped variant of the style.
+func (style mathStyleKind) cramped() mathStyleKind {
+	return style | cramped
+}
+
+// isCramped returns whether the style is cramped.
+func (style mathStyleKind) isCramped() bool {
+	return style&cramped != 0
+}
+
+// level returns the size level of the style: text and display styles are
+// set at the base size, script and scriptscript styles are shrunk once and
+// twice.
+func (style mathStyleKind) level() int {
+	switch style.kind() {
+	case scriptStyle:
+		return 1
+	case scriptScriptStyle:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// num returns the style of the numerator of a fraction.
+func (style mathStyleKind) num() mathStyleKind {
+	c := style & cramped
+	switch style.kind() {
+	case displayStyle:
+		return textStyle | c
+	case textStyle:
+		return scriptStyle | c
+	default:
+		return scriptScriptStyle | c
+	}
+}
+
+// den returns the style of the denominator of a fraction.
+func (style mathStyleKind) den() mathStyleKind {
+	return style.num().cramped()
+}
+
+// sup returns the style of a superscript.
+func (style mathStyleKind) sup() mathStyleKind {
+	c := style & cramped
+	switch style.kind() {
+	case displayStyle, textStyle:
+		return scriptStyle | c
+	default:
+		return scriptScriptStyle | c
+	}
+}
+
+// sub returns the style of a subscript.
+func (style mathStyleKind) sub() mathStyleKind {
+	return style.sup().cramped()
+}
+
+// scale returns the scale factor of the style, relatively to the provided
+// one.
+func (style mathStyleKind) scale(to mathStyleKind) float64 {
+	return math.Pow(shrinkFactor, float64(style.level()-to.level()))
+}
+
+// shrinkFactor is the factor by which text shrinks from a size level to
+// the next one.
+const shrinkFactor = 0.7
+
+// resize resizes a node sized for the from style, so it is sized
+// relatively to the to style.
+func resize(node tex.Node, from, to mathStyleKind) {
+	for i := to.level(); i < from.level(); i++ {
+		node.Shrink()
+	}
+	for i := from.level(); i < to.level(); i++ {
+		node.Grow()
+	}
+}
+
+// handleMathChoice typesets the argument of \mathchoice{D}{T}{S}{SS}
+// matching the current style.
+func handleMathChoice(p *parser, node ast.Node, state tex.State, math bool, style mathStyleKind) tex.Node {
+	macro := node.(*ast.Macro)
+	arg := ast.List(macro.Args[style.kind()/2].(*ast.Arg).List)
+	return p.handleNode(arg, state, math, style)
+}
@@ -0,0 +1,65 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mtex
+
+import (
+	"testing"
+
+	"github.com/go-latex/latex/internal/fakebackend"
+)
+
+func TestStyle(t *testing.T) {
+	be := fakebackend.New()
+	for _, tc := range []struct {
+		expr string
+		same string // expression with the same dimensions
+	}{
+		{expr: `$\displaystyle\frac{1}{2}$`, same: `$\dfrac{1}{2}$`},
+		{expr: `\[\frac{1}{2}\]`, same: `$\dfrac{1}{2}$`},
+		{expr: `\[\textstyle\frac{1}{2}\]`, same: `$\frac{1}{2}$`},
+		{expr: `\[\tfrac{1}{2}\]`, same: `$\frac{1}{2}$`},
+		{expr: `$\mathchoice{a}{b}{c}{d}$`, same: `$b$`},
+		{expr: `\[\mathchoice{a}{b}{c}{d}\]`, same: `$a$`},
+		{expr: `${\displaystyle a} b$`, same: `$ab$`},
+		{expr: `$\frac{\mathchoice{a}{b}{c}{d}}{x}$`, same: `$\frac{c}{x}$`},
+		{expr: `$\scriptstyle{\scriptstyle x}$`, same: `$\scriptstyle x$`},
+		{expr: `$\scriptscriptstyle{\scriptscriptstyle x}$`, same: `$\scriptscriptstyle x$`},
+		{expr: `$\scriptstyle\frac{x}{y}$`, same: `$\scriptstyle\frac{\scriptscriptstyle x}{y}$`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			var (
+				got  = mustParseWith(t, be, tc.expr)
+				want = mustParseWith(t, be, tc.same)
+			)
+			if got, want := got.Width(), want.Width(); !approxEq(got, want) {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+			if got, want := got.Height(), want.Height(); !approxEq(got, want) {
+				t.Fatalf("invalid height: got=%g, want=%g", got, want)
+			}
+			if got, want := got.Depth(), want.Depth(); !approxEq(got, want) {
+				t.Fatalf("invalid depth: got=%g, want=%g", got, want)
+			}
+		})
+	}
+
+	t.Run("shrink", func(t *testing.T) {
+		var sizes []float64
+		for _, expr := range []string{
+			`$\displaystyle x$`,
+			`$\textstyle x$`,
+			`$\scriptstyle x$`,
+			`$\scriptscriptstyle x$`,
+		} {
+			sizes = append(sizes, mustParseWith(t, be, expr).Width())
+		}
+		if sizes[0] != sizes[1] {
+			t.Fatalf("display and text styles differ: %v", sizes)
+		}
+		if !(sizes[1] > sizes[2] && sizes[2] > sizes[3]) {
+			t.Fatalf("script styles are not shrunk: %v", sizes)
+		}
+	})
+}
@@ -6,6 +6,7 @@ package latex // import "github.com/go-latex/latex"
 
 import (
 	"fmt"
+	"io/fs"
 	"strings"
 
 	"github.com/go-latex/latex/ast"
@@ -28,8 +29,14 @@ const (
 type parser struct {
 	s     *texScanner
 	state state
+	lax   bool // whether unknown macros are accepted
 
 	macros map[string]macroParser
+	envs   map[string]string // signatures of the arguments of environments
+
+	fset *token.FileSet // file set of the included files
+	fsys fs.FS          // file system holding the included files
+	dir  string         // directory of the main document
 }
 
 func newParser(x string) *parser {
@@ -38,6 +45,7 @@ func newParser(x string) *parser {
 		state: normalState,
 	}
 	p.addBuiltinMacros()
+	p.addBuiltinEnvs()
 	return p
 }
 
@@ -91,16 +99,13 @@ func (p *parser) parseNode(tok token.Token) ast.Node {
 			return p.parseSymbol(tok)
 		}
 	case token.Lbrace:
+		return p.parseLbrace(tok)
+	case token.Other:
 		switch p.state {
 		case mathState:
-			return p.parseMathLbrace(tok)
-		default:
-			panic("not implemented")
-		}
-	case token.Other:
-		switch tok.Text {
-		default:
 			panic("not implemented: " + tok.String())
+		default:
+			return p.parseSymbol(tok)
 		}
 	case token.Space:
 		switch p.state {
@@ -166,6 +171,9 @@ func (p *parser) parseMacro(tok token.Token) ast.Node {
 	name := tok.Text
 	macro, ok := p.macros[name]
 	if !ok {
+		if p.lax {
+			return builtinMacro("").parseMacro(p)
+		}
 		panic("unknown macro " + name)
 		//return nil
 	}
@@ -208,6 +216,19 @@ loop:
 	macro.Args = append(macro.Args, &arg)
 }
 
+// parseTextMacroArg parses an argument in text mode, e.g. the argument of
+// \text or \mbox inside a math expression: spaces are kept and a nested
+// $...$ switches back to math mode.
+func (p *parser) parseTextMacroArg(macro *ast.Macro) {
+	state := p.state
+	p.state = normalState
+	defer func() {
+		p.state = state
+	}()
+
+	p.parseMacroArg(macro)
+}
+
 func (p *parser) parseOptMacroArg(macro *ast.Macro) {
 	nxt := p.s.sc.Peek()
 	if nxt != '[' {
@@ -237,6 +258,102 @@ loop:
 }
 
 func (p *parser) parseVerbatimMacroArg(macro *ast.Macro) {
+	var arg ast.Arg
+	p.expect('{')
+	arg.Lbrace = p.s.tok.Pos
+
+	var txt string
+	txt, arg.Rbrace = p.s.verbatim('{', '}')
+	arg.List = ast.List{&ast.Word{
+		WordPos: arg.Lbrace + 1,
+		Text:    txt,
+	}}
+	macro.Args = append(macro.Args, &arg)
+}
+
+func (p *parser) parseOptVerbatimMacroArg(macro *ast.Macro) {
+	nxt := p.s.sc.Peek()
+	if nxt != '[' {
+		return
+	}
+
+	var opt ast.OptArg
+	p.expect('[')
+	opt.Lbrack = p.s.tok.Pos
+
+	var txt string
+	txt, opt.Rbrack = p.s.verbatim('[', ']')
+	opt.List = ast.List{&ast.Word{
+		WordPos: opt.Lbrack + 1,
+		Text:    txt,
+	}}
+	macro.Args = append(macro.Args, &opt)
+}
+
+func (p *parser) parseStar(macro *ast.Macro) {
+	if p.s.sc.Peek() != '*' {
+		return
+	}
+	p.next()
+	macro.Name.Name += "*"
+}
+
+func (p *parser) parseEnv(tok token.Token) ast.Node {
+	env := &ast.Env{
+		Left: tok.Pos,
+		Name: p.parseEnvName(),
+	}
+	name := env.Name.Name
+
+	switch {
+	case verbatimEnvs[name]:
+		var txt string
+		pos := p.s.offset()
+		txt, env.Right = p.s.verbatimUntil(`\end{` + name + `}`)
+		env.List = ast.List{&ast.Word{WordPos: pos, Text: txt}}
+		return env
+	case mathEnvs[name]:
+		state := p.state
+		p.state = mathState
+		defer func() {
+			p.state = state
+		}()
+	}
+
+	args := &ast.Macro{}
+	p.parseMacroArgs(args, p.envs[name])
+	env.Args = args.Args
+
+	for p.s.Next() {
+		tok := p.s.tok
+		if tok.Kind == token.Macro && tok.Text == `\end` {
+			env.Right = tok.Pos
+			end := p.parseEnvName()
+			if end.Name != name {
+				panic(fmt.Errorf(`\begin{%s} ended by \end{%s}`, name, end.Name))
+			}
+			if tableEnvs[name] {
+				return newTable(env)
+			}
+			return env
+		}
+		node := p.parseNode(tok)
+		if node == nil {
+			continue
+		}
+		env.List = append(env.List, node)
+	}
+	panic(fmt.Errorf(`missing \end{%s}`, name))
+}
+
+func (p *parser) parseEnvName() *ast.Ident {
+	p.expect('{')
+	pos := p.s.tok.Pos + 1
+	name, _ := p.s.verbatim('{', '}')
+	return &ast.Ident{
+		NamePos: pos,
+		Name:    name,
+	}
 }
 
 func (p *parser) parseSup(tok token.Token) ast.Node {
@@ -306,7 +423,7 @@ func (p *parser) parseSymbol(tok token.Token) ast.Node {
 	}
 }
 
-func (p *parser) parseMathLbrace(tok token.Token) ast.Node {
+func (p *parser) parseLbrace(tok token.Token) ast.Node {
 	var (
 		lst    ast.List
 		ldelim = tok.Kind
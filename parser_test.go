@@ -7,6 +7,7 @@ package latex // import "github.com/go-latex/latex"
 
 import (
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -403,18 +404,164 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
-		//	{ // FIXME(sbinet): not ready
-		//		input: `\[x =3\]`,
-		//		want:  nil,
-		//	},
-		//	{ // FIXME(sbinet): not ready
-		//		input: `\(x =3\)`,
-		//		want:  nil,
-		//	},
-		//	{ // FIXME(sbinet): not ready
-		//		input: `\begin{equation}x=3\end{equation}`,
-		//		want: nil,
-		//	},
+		{
+			input: `$x \text{if $x$ is odd}$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Word{Text: "x"},
+						&ast.Macro{
+							Name: &ast.Ident{Name: `\text`},
+							Args: ast.List{
+								&ast.Arg{
+									List: ast.List{
+										&ast.Word{Text: "if"},
+										&ast.Symbol{Text: " "},
+										&ast.MathExpr{
+											List: ast.List{
+												&ast.Word{Text: "x"},
+											},
+										},
+										&ast.Symbol{Text: " "},
+										&ast.Word{Text: "is"},
+										&ast.Symbol{Text: " "},
+										&ast.Word{Text: "odd"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: `$x \tag*{odd} \notag$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Word{Text: "x"},
+						&ast.Macro{
+							Name: &ast.Ident{Name: `\tag*`},
+							Args: ast.List{
+								&ast.Arg{
+									List: ast.List{
+										&ast.Word{Text: "odd"},
+									},
+								},
+							},
+						},
+						&ast.Macro{
+							Name: &ast.Ident{Name: `\notag`},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: `$\color[rgb]{1,0,0}x \colorbox{yellow}{a b}$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Macro{
+							Name: &ast.Ident{Name: `\color`},
+							Args: ast.List{
+								&ast.OptArg{
+									List: ast.List{
+										&ast.Word{Text: "rgb"},
+									},
+								},
+								&ast.Arg{
+									List: ast.List{
+										&ast.Word{Text: "1,0,0"},
+									},
+								},
+							},
+						},
+						&ast.Word{Text: "x"},
+						&ast.Macro{
+							Name: &ast.Ident{Name: `\colorbox`},
+							Args: ast.List{
+								&ast.Arg{
+									List: ast.List{
+										&ast.Word{Text: "yellow"},
+									},
+								},
+								&ast.Arg{
+									List: ast.List{
+										&ast.Word{Text: "a"},
+										&ast.Symbol{Text: " "},
+										&ast.Word{Text: "b"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: `\[x =3\]`,
+			want: ast.List{
+				&ast.MathExpr{
+					Delim: `\[`,
+					List: ast.List{
+						&ast.Word{Text: "x"},
+						&ast.Symbol{Text: "="},
+						&ast.Literal{Text: "3"},
+					},
+				},
+			},
+		},
+		{
+			input: `\(x =3\)`,
+			want: ast.List{
+				&ast.MathExpr{
+					Delim: `\(`,
+					List: ast.List{
+						&ast.Word{Text: "x"},
+						&ast.Symbol{Text: "="},
+						&ast.Literal{Text: "3"},
+					},
+				},
+			},
+		},
+		{
+			input: `\begin{equation}x=3\end{equation}`,
+			want: ast.List{
+				&ast.Env{
+					Name: &ast.Ident{Name: "equation"},
+					List: ast.List{
+						&ast.Word{Text: "x"},
+						&ast.Symbol{Text: "="},
+						&ast.Literal{Text: "3"},
+					},
+				},
+			},
+		},
+		{
+			input: `\begin{tabular}{lc}a & b\end{tabular}`,
+			want: ast.List{
+				&ast.Table{
+					Name: &ast.Ident{Name: "tabular"},
+					Spec: []ast.ColSpec{{Kind: 'l'}, {Kind: 'c'}},
+					Rows: [][]ast.Cell{{
+						{List: ast.List{&ast.Word{Text: "a"}}, Span: 1},
+						{List: ast.List{&ast.Word{Text: "b"}}, Span: 1},
+					}},
+				},
+			},
+		},
+		{
+			input: `\label{eq:1}`,
+			want: ast.List{
+				&ast.Macro{
+					Name: &ast.Ident{Name: `\label`},
+					Args: ast.List{
+						&ast.Arg{List: ast.List{&ast.Word{Text: "eq:1"}}},
+					},
+				},
+			},
+		},
 		{
 			input: `$x_i$`,
 			want: ast.List{
@@ -465,6 +612,75 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: `$\begin{pmatrix}a & b \\ c & d\end{pmatrix}$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Table{
+							Name: &ast.Ident{Name: "pmatrix"},
+							Rows: [][]ast.Cell{
+								{
+									{List: ast.List{&ast.Word{Text: "a"}}, Span: 1},
+									{List: ast.List{&ast.Word{Text: "b"}}, Span: 1},
+								},
+								{
+									{List: ast.List{&ast.Word{Text: "c"}}, Span: 1},
+									{List: ast.List{&ast.Word{Text: "d"}}, Span: 1},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: `$\begin{cases}1 & x\end{cases}$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Table{
+							Name: &ast.Ident{Name: "cases"},
+							Rows: [][]ast.Cell{{
+								{List: ast.List{&ast.Literal{Text: "1"}}, Span: 1},
+								{List: ast.List{&ast.Word{Text: "x"}}, Span: 1},
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: `$\left( x \big] \right.$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Macro{Name: &ast.Ident{Name: `\left`}},
+						&ast.Symbol{Text: "("},
+						&ast.Word{Text: "x"},
+						&ast.Macro{Name: &ast.Ident{Name: `\big`}},
+						&ast.Symbol{Text: "]"},
+						&ast.Macro{Name: &ast.Ident{Name: `\right`}},
+						&ast.Symbol{Text: "."},
+					},
+				},
+			},
+		},
+		{
+			input: `$\int\limits_0 \sum\nolimits^n$`,
+			want: ast.List{
+				&ast.MathExpr{
+					List: ast.List{
+						&ast.Macro{Name: &ast.Ident{Name: `\int`}},
+						&ast.Macro{Name: &ast.Ident{Name: `\limits`}},
+						&ast.Sub{Node: &ast.Literal{Text: "0"}},
+						&ast.Macro{Name: &ast.Ident{Name: `\sum`}},
+						&ast.Macro{Name: &ast.Ident{Name: `\nolimits`}},
+						&ast.Sup{Node: &ast.Word{Text: "n"}},
+					},
+				},
+			},
+		},
 	} {
 		t.Run("", func(t *testing.T) {
 			node, err := ParseExpr(tc.input)
@@ -585,18 +801,51 @@ func TestTokenPos(t *testing.T) {
 				},
 			},
 		},
-		//	{ // FIXME(sbinet): not ready
-		//		input: `\[x =3\]`,
-		//		want:  nil,
-		//	},
-		//	{ // FIXME(sbinet): not ready
-		//		input: `\(x =3\)`,
-		//		want:  nil,
-		//	},
-		//	{ // FIXME(sbinet): not ready
-		//		input: `\begin{equation}x=3\end{equation}`,
-		//		want: nil,
-		//	},
+		{
+			input: `\[x =3\]`,
+			want: ast.List{
+				&ast.MathExpr{
+					Delim: `\[`,
+					Left:  0,
+					List: ast.List{
+						&ast.Word{Text: "x", WordPos: 2},
+						&ast.Symbol{Text: "=", SymPos: 4},
+						&ast.Literal{Text: "3", LitPos: 5},
+					},
+					Right: 6,
+				},
+			},
+		},
+		{
+			input: `\begin{equation}x=3\end{equation}`,
+			want: ast.List{
+				&ast.Env{
+					Left: 0,
+					Name: &ast.Ident{Name: "equation", NamePos: 7},
+					List: ast.List{
+						&ast.Word{Text: "x", WordPos: 16},
+						&ast.Symbol{Text: "=", SymPos: 17},
+						&ast.Literal{Text: "3", LitPos: 18},
+					},
+					Right: 19,
+				},
+			},
+		},
+		{
+			input: `\label{eq:1}`,
+			want: ast.List{
+				&ast.Macro{
+					Name: &ast.Ident{Name: `\label`, NamePos: 0},
+					Args: ast.List{
+						&ast.Arg{
+							Lbrace: 6,
+							List:   ast.List{&ast.Word{Text: "eq:1", WordPos: 7}},
+							Rbrace: 11,
+						},
+					},
+				},
+			},
+		},
 		{
 			input: `$x_i$`,
 			want: ast.List{
@@ -628,3 +877,42 @@ func TestTokenPos(t *testing.T) {
 	}
 
 }
+
+func TestMacros(t *testing.T) {
+	names := Macros()
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("macro names are not sorted")
+	}
+	for _, name := range []string{`\frac`, `\sqrt`, `\begin`, `\section`, `\ref`} {
+		i := sort.SearchStrings(names, name)
+		if i == len(names) || names[i] != name {
+			t.Fatalf("missing macro %q", name)
+		}
+	}
+}
+
+func TestEnvs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		math     bool
+		verbatim bool
+	}{
+		{name: "equation", math: true},
+		{name: "align*", math: true},
+		{name: "pmatrix", math: true},
+		{name: "verbatim", verbatim: true},
+		{name: "lstlisting", verbatim: true},
+		{name: "itemize"},
+		{name: "subequations"},
+		{name: "tabular"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := IsMathEnv(tc.name), tc.math; got != want {
+				t.Fatalf("invalid math env: got=%v, want=%v", got, want)
+			}
+			if got, want := IsVerbatimEnv(tc.name), tc.verbatim; got != want {
+				t.Fatalf("invalid verbatim env: got=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package plaintext extracts the prose of LaTeX documents, keeping track
+// of the source position of every extracted character.
+//
+// The extracted text is suitable for spell and grammar checkers: macros,
+// comments and math are removed or replaced by placeholders, and
+// suggestions made on the text can be mapped back to the LaTeX source.
+package plaintext // import "github.com/go-latex/latex/plaintext"
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-latex/latex"
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/token"
+)
+
+// Action describes how a macro or an environment is extracted.
+type Action int
+
+const (
+	Keep    Action = iota // keep the text of the last argument of a macro, or the content of an environment
+	Drop                  // drop the macro and its arguments, or the environment
+	Replace               // replace the macro or the environment with a placeholder
+	Break                 // replace the macro or the environment with a paragraph break
+	Aside                 // like Keep, as a separate paragraph
+)
+
+// Policy describes how a macro or an environment is extracted.
+type Policy struct {
+	Action Action
+	Text   string // placeholder text of the Replace action
+}
+
+// Text is a text extracted from a LaTeX document.
+type Text struct {
+	Text string
+	Pos  []token.Pos // Pos[i] is the source position of the byte Text[i]
+}
+
+// Position returns the source position of the i-th byte of the text.
+func (t *Text) Position(i int) token.Pos {
+	return t.Pos[i]
+}
+
+// Extractor extracts the prose of LaTeX documents.
+type Extractor struct {
+	Macros  map[string]Policy // policies of macros, by name (without star)
+	Envs    map[string]Policy // policies of environments, by name
+	Default Policy            // policy of macros without an explicit policy
+	Math    string            // placeholder of math expressions and environments
+}
+
+// New returns an extractor with policies for the most common LaTeX
+// macros and environments.
+func New() *Extractor {
+	e := &Extractor{
+		Macros: make(map[string]Policy, len(defaultMacros)),
+		Envs:   make(map[string]Policy, len(defaultEnvs)),
+		Math:   "X",
+	}
+	for k, v := range defaultMacros {
+		e.Macros[k] = v
+	}
+	for k, v := range defaultEnvs {
+		e.Envs[k] = v
+	}
+	return e
+}
+
+// Extract extracts the prose of the provided LaTeX source with the
+// default policies.
+func Extract(src string) (*Text, error) {
+	return New().Extract(src)
+}
+
+// Extract extracts the prose of the provided LaTeX source.
+//
+// Only the content of the document environment is extracted.
+// Sources without a document environment are extracted in full.
+func (e *Extractor) Extract(src string) (*Text, error) {
+	doc, err := latex.ParseDocument(src)
+	if err != nil {
+		return nil, fmt.Errorf("plaintext: could not parse source: %w", err)
+	}
+
+	x := extractor{e: e, src: src}
+	switch doc.Body {
+	case nil:
+		x.list(doc.Preamble)
+	default:
+		x.list(doc.Body.List)
+	}
+
+	n := len(strings.TrimRight(string(x.buf), " \n"))
+	return &Text{
+		Text: string(x.buf[:n]),
+		Pos:  x.pos[:n:n],
+	}, nil
+}
+
+type extractor struct {
+	e   *Extractor
+	src string
+
+	buf []byte
+	pos []token.Pos
+}
+
+// write writes text read from the source at position pos.
+func (x *extractor) write(txt string, pos token.Pos) {
+	for i := 0; i < len(txt); i++ {
+		x.buf = append(x.buf, txt[i])
+		x.pos = append(x.pos, pos+token.Pos(i))
+	}
+}
+
+// synth writes text that does not appear in the source, attributing it
+// to position pos.
+func (x *extractor) synth(txt string, pos token.Pos) {
+	if strings.TrimSpace(txt) == "" {
+		x.space(pos)
+		return
+	}
+	for i := 0; i < len(txt); i++ {
+		x.buf = append(x.buf, txt[i])
+		x.pos = append(x.pos, pos)
+	}
+}
+
+// space writes a word separator, unless the text already ends with one.
+func (x *extractor) space(pos token.Pos) {
+	if n := len(x.buf); n == 0 || x.buf[n-1] == ' ' || x.buf[n-1] == '\n' {
+		return
+	}
+	x.buf = append(x.buf, ' ')
+	x.pos = append(x.pos, pos)
+}
+
+// par writes a paragraph break, unless the text already ends with one.
+func (x *extractor) par(pos token.Pos) {
+	for n := len(x.buf); n > 0 && x.buf[n-1] == ' '; n-- {
+		x.buf = x.buf[:n-1]
+		x.pos = x.pos[:n-1]
+	}
+	if n := len(x.buf); n == 0 || x.buf[n-1] == '\n' {
+		return
+	}
+	x.buf = append(x.buf, '\n', '\n')
+	x.pos = append(x.pos, pos, pos)
+}
+
+// gap writes the separator corresponding to the whitespace and comments
+// found in the source between two nodes.
+func (x *extractor) gap(beg, end token.Pos) {
+	if beg < 0 || end <= beg || int(end) > len(x.src) {
+		return
+	}
+	var (
+		txt = x.src[beg:end]
+		nls = 0
+		wsp = token.NoPos
+	)
+	for i := 0; i < len(txt); i++ {
+		switch txt[i] {
+		case '%':
+			// a comment eats the end of its line.
+			j := strings.IndexByte(txt[i:], '\n')
+			if j < 0 {
+				return
+			}
+			i += j
+		case '\n':
+			nls++
+			fallthrough
+		case ' ', '\t', '\r':
+			if wsp == token.NoPos {
+				wsp = beg + token.Pos(i)
+			}
+		}
+	}
+	switch {
+	case nls > 1:
+		x.par(wsp)
+	case wsp != token.NoPos:
+		x.space(wsp)
+	}
+}
+
+func (x *extractor) list(nodes ast.List) {
+	for i, node := range nodes {
+		if i > 0 {
+			x.gap(nodes[i-1].End(), node.Pos())
+		}
+		x.node(node)
+	}
+}
+
+func (x *extractor) node(node ast.Node) {
+	switch node := node.(type) {
+	case nil:
+		// no-op
+	case ast.List:
+		x.list(node)
+	case *ast.Word:
+		x.write(node.Text, node.WordPos)
+	case *ast.Literal:
+		x.write(node.Text, node.LitPos)
+	case *ast.Symbol:
+		switch node.Text {
+		case " ", "~", `\ `:
+			x.space(node.SymPos)
+		default:
+			x.write(node.Text, node.SymPos)
+		}
+	case *ast.MathExpr:
+		x.synth(x.e.Math, node.Pos())
+	case *ast.Sub, *ast.Sup:
+		x.synth(x.e.Math, node.Pos())
+	case *ast.Arg:
+		x.list(node.List)
+	case *ast.OptArg:
+		// optional arguments are not part of the prose.
+	case *ast.Macro:
+		x.macro(node)
+	case *ast.Section:
+		x.par(node.Pos())
+		x.macro(node.Macro)
+		x.par(node.Macro.End())
+		x.list(node.List)
+		x.par(node.End())
+	case *ast.Env:
+		x.env(node.Name.Name, node.Pos(), func() { x.list(node.List) })
+	case *ast.Table:
+		x.env(node.Name.Name, node.Pos(), func() {
+			for _, row := range node.Rows {
+				for _, cell := range row {
+					x.list(cell.List)
+					x.space(cell.List.End())
+				}
+				x.par(node.Pos())
+			}
+		})
+	case *ast.Document:
+		if node.Body != nil {
+			x.list(node.Body.List)
+		}
+	default:
+		panic(fmt.Errorf("plaintext: unknown ast node %T", node))
+	}
+}
+
+func (x *extractor) macro(node *ast.Macro) {
+	name := strings.TrimSuffix(node.Name.Name, "*")
+	pol, ok := x.e.Macros[name]
+	if !ok {
+		if len(name) == 2 && strings.ContainsAny(name[1:], `%&#_${}`) {
+			// escaped character.
+			x.write(name[1:], node.Pos()+1)
+			return
+		}
+		pol = x.e.Default
+	}
+
+	switch pol.Action {
+	case Keep:
+		x.lastArg(node)
+	case Aside:
+		x.par(node.Pos())
+		x.lastArg(node)
+		x.par(node.End())
+	case Drop:
+		// no-op
+	case Replace:
+		x.synth(pol.Text, node.Pos())
+	case Break:
+		x.par(node.Pos())
+	default:
+		panic(fmt.Errorf("plaintext: invalid action %d for macro %s", pol.Action, name))
+	}
+}
+
+// lastArg extracts the last mandatory argument of a macro.
+func (x *extractor) lastArg(node *ast.Macro) {
+	for i := len(node.Args) - 1; i >= 0; i-- {
+		if arg, ok := node.Args[i].(*ast.Arg); ok {
+			x.list(arg.List)
+			return
+		}
+	}
+}
+
+func (x *extractor) env(name string, pos token.Pos, content func()) {
+	pol, ok := x.e.Envs[name]
+	if !ok {
+		pol = Policy{Action: Keep}
+		if latex.IsMathEnv(name) {
+			pol = Policy{Action: Replace, Text: x.e.Math}
+		}
+	}
+
+	switch pol.Action {
+	case Keep:
+		content()
+	case Drop:
+		// no-op
+	case Replace:
+		x.synth(pol.Text, pos)
+	case Break:
+		x.par(pos)
+	case Aside:
+		x.par(pos)
+		content()
+		x.par(pos)
+	default:
+		panic(fmt.Errorf("plaintext: invalid action %d for environment %s", pol.Action, name))
+	}
+}
+
+var (
+	keep  = Policy{Action: Keep}
+	drop  = Policy{Action: Drop}
+	brk   = Policy{Action: Break}
+	aside = Policy{Action: Aside}
+	space = Policy{Action: Replace, Text: " "}
+)
+
+var defaultMacros = map[string]Policy{
+	// text styles
+	`\emph`:       keep,
+	`\textbf`:     keep,
+	`\textit`:     keep,
+	`\textsl`:     keep,
+	`\textsc`:     keep,
+	`\texttt`:     keep,
+	`\textrm`:     keep,
+	`\textsf`:     keep,
+	`\textup`:     keep,
+	`\textmd`:     keep,
+	`\textnormal`: keep,
+	`\underline`:  keep,
+	`\mbox`:       keep,
+	`\text`:       keep,
+	`\textcolor`:  keep,
+	`\footnote`:   aside,
+	`\caption`:    aside,
+	`\href`:       keep,
+
+	// references
+	`\label`:     drop,
+	`\ref`:       {Action: Replace, Text: "1"},
+	`\eqref`:     {Action: Replace, Text: "(1)"},
+	`\pageref`:   {Action: Replace, Text: "1"},
+	`\autoref`:   {Action: Replace, Text: "Section 1"},
+	`\cref`:      {Action: Replace, Text: "Section 1"},
+	`\Cref`:      {Action: Replace, Text: "Section 1"},
+	`\nameref`:   {Action: Replace, Text: "Section"},
+	`\vref`:      {Action: Replace, Text: "1"},
+	`\cite`:      {Action: Replace, Text: "[1]"},
+	`\citep`:     {Action: Replace, Text: "[1]"},
+	`\citet`:     {Action: Replace, Text: "Author [1]"},
+	`\parencite`: {Action: Replace, Text: "[1]"},
+	`\textcite`:  {Action: Replace, Text: "Author [1]"},
+	`\autocite`:  {Action: Replace, Text: "[1]"},
+	`\footcite`:  drop,
+	`\nocite`:    drop,
+	`\url`:       {Action: Replace, Text: "URL"},
+
+	// logos and symbols
+	`\LaTeX`:      {Action: Replace, Text: "LaTeX"},
+	`\TeX`:        {Action: Replace, Text: "TeX"},
+	`\ldots`:      {Action: Replace, Text: "..."},
+	`\dots`:       {Action: Replace, Text: "..."},
+	`\textendash`: {Action: Replace, Text: "-"},
+	`\textemdash`: {Action: Replace, Text: "-"},
+
+	// spacing and breaks
+	`\,`:         space,
+	`\;`:         space,
+	`\:`:         space,
+	`\quad`:      space,
+	`\qquad`:     space,
+	`\\`:         brk,
+	`\newline`:   brk,
+	`\par`:       brk,
+	`\item`:      brk,
+	`\maketitle`: drop,
+
+	// preamble
+	`\documentclass`:       drop,
+	`\usepackage`:          drop,
+	`\RequirePackage`:      drop,
+	`\newcommand`:          drop,
+	`\renewcommand`:        drop,
+	`\providecommand`:      drop,
+	`\def`:                 drop,
+	`\newenvironment`:      drop,
+	`\DeclareMathOperator`: drop,
+
+	// floats and inclusions
+	`\includegraphics`:   drop,
+	`\input`:             drop,
+	`\include`:           drop,
+	`\subfile`:           drop,
+	`\bibliography`:      drop,
+	`\bibliographystyle`: drop,
+	`\vspace`:            drop,
+	`\hspace`:            drop,
+}
+
+var defaultEnvs = map[string]Policy{
+	"verbatim":        drop,
+	"verbatim*":       drop,
+	"lstlisting":      drop,
+	"minted":          drop,
+	"comment":         drop,
+	"thebibliography": drop,
+}
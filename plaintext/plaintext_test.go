@@ -0,0 +1,108 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plaintext
+
+import (
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `Hello   world.`,
+			want:  `Hello world.`,
+		},
+		{
+			input: "This is \\emph{very} nice % a comment\ntext, see \\ref{s}\nand $x^2$ too.",
+			want:  `This is very nice text, see 1 and X too.`,
+		},
+		{
+			input: "one%\ntwo",
+			want:  `onetwo`,
+		},
+		{
+			input: "first\n\nsecond\\\\third",
+			want:  "first\n\nsecond\n\nthird",
+		},
+		{
+			input: `50\% of~them\footnote{Really.} agree \cite[p.~2]{knuth}.`,
+			want:  "50% of them\n\nReally.\n\nagree [1].",
+		},
+		{
+			input: `\begin{itemize}\item one \item two\end{itemize}`,
+			want:  "one\n\ntwo",
+		},
+		{
+			input: `See \[ \int f \] and \begin{align}a&=b\end{align} or \begin{verbatim}code\end{verbatim}.`,
+			want:  `See X and X or .`,
+		},
+		{
+			input: `\begin{tabular}{ll}a & b\\ c & d\end{tabular}`,
+			want:  "a b\n\nc d",
+		},
+		{
+			input: `\documentclass{article}
+\usepackage{amsmath}
+\begin{document}
+\section{Intro}\label{sec:intro}
+Some \unknown{text}.
+\end{document}`,
+			want: "Intro\n\nSome text.",
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			txt, err := Extract(tc.input)
+			if err != nil {
+				t.Fatalf("could not extract text: %+v", err)
+			}
+			if got, want := txt.Text, tc.want; got != want {
+				t.Fatalf("invalid text:\ngot= %q\nwant=%q", got, want)
+			}
+			if got, want := len(txt.Pos), len(txt.Text); got != want {
+				t.Fatalf("invalid number of positions: got=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	const src = "A \\textbf{bold}\nclaim: $x$ \\ref{eq}."
+	txt, err := Extract(src)
+	if err != nil {
+		t.Fatalf("could not extract text: %+v", err)
+	}
+	if got, want := txt.Text, "A bold claim: X 1."; got != want {
+		t.Fatalf("invalid text: got=%q, want=%q", got, want)
+	}
+
+	for i, want := range []int{
+		0, 1, 10, 11, 12, 13, 15, 16, 17, 18, 19, 20, 21, 22, 23, 26, 27, 35,
+	} {
+		if got := int(txt.Position(i)); got != want {
+			t.Errorf("invalid position for byte %d (%q): got=%d, want=%d", i, txt.Text[i], got, want)
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	e := New()
+	e.Math = "MATH"
+	e.Macros[`\ref`] = Policy{Action: Replace, Text: "REF"}
+	e.Macros[`\emph`] = Policy{Action: Drop}
+	e.Macros[`\textsc`] = Policy{Action: Replace, Text: "NAME"}
+	e.Envs["equation"] = Policy{Action: Keep}
+
+	const src = `An \emph{odd} \textsc{sample}, see \ref*{x} and $y$, \begin{equation}z\end{equation}.`
+	txt, err := e.Extract(src)
+	if err != nil {
+		t.Fatalf("could not extract text: %+v", err)
+	}
+	if got, want := txt.Text, "An NAME, see REF and MATH, z."; got != want {
+		t.Fatalf("invalid text: got=%q, want=%q", got, want)
+	}
+}
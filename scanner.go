@@ -15,26 +15,76 @@ import (
 )
 
 type texScanner struct {
-	sc scanner.Scanner
+	sc   *scanner.Scanner
+	base int    // offset of the current source in the file set
+	name string // name of the current source
+
+	srcs []source // stack of suspended sources
 
 	r   rune
 	tok token.Token
 }
 
+// source is a source suspended while an included file is scanned.
+type source struct {
+	sc   *scanner.Scanner
+	base int
+	name string
+}
+
 func newScanner(r io.Reader) *texScanner {
-	sc := &texScanner{}
-	sc.sc.Init(r)
-	sc.sc.Mode = (scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats)
-	sc.sc.Mode |= scanner.ScanStrings
+	return &texScanner{sc: newTextScanner(r)}
+}
+
+func newTextScanner(r io.Reader) *scanner.Scanner {
+	sc := new(scanner.Scanner)
+	sc.Init(r)
+	// decimal numbers are scanned by texScanner: LaTeX numbers have no
+	// exponent, and lengths such as 1em or 2ex are a number and a word.
+	sc.Mode = (scanner.ScanIdents | scanner.ScanInts)
+	sc.Mode |= scanner.ScanStrings
 	//scanner.ScanRawStrings)
-	//	sc.sc.Error = func(s *scanner.Scanner, msg string) {}
-	sc.sc.IsIdentRune = func(ch rune, i int) bool {
+	//	sc.Error = func(s *scanner.Scanner, msg string) {}
+	sc.IsIdentRune = func(ch rune, i int) bool {
 		return unicode.IsLetter(ch) //|| unicode.IsDigit(ch) && i > 0
 	}
-	sc.sc.Whitespace = 1<<'\t' | 1<<'\n' | 1<<'\r'
+	sc.Whitespace = 1<<'\t' | 1<<'\n' | 1<<'\r'
 	return sc
 }
 
+// push suspends the current source and starts scanning r.
+// Positions of tokens from r are offset by base.
+// Scanning of the suspended source resumes once r is exhausted.
+func (s *texScanner) push(name string, r io.Reader, base int) {
+	s.srcs = append(s.srcs, source{sc: s.sc, base: s.base, name: s.name})
+	s.sc = newTextScanner(r)
+	s.sc.Filename = name
+	s.base = base
+	s.name = name
+}
+
+// pop resumes the most recently suspended source.
+func (s *texScanner) pop() {
+	src := s.srcs[len(s.srcs)-1]
+	s.srcs = s.srcs[:len(s.srcs)-1]
+	s.sc = src.sc
+	s.base = src.base
+	s.name = src.name
+}
+
+// opened returns whether the named source is being scanned.
+func (s *texScanner) opened(name string) bool {
+	if s.name == name {
+		return true
+	}
+	for _, src := range s.srcs {
+		if src.name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Token returns the most recently parsed token
 func (s *texScanner) Token() token.Token {
 	return s.tok
@@ -45,6 +95,10 @@ func (s *texScanner) Token() token.Token {
 // It returns false once it reaches token.EOF.
 func (s *texScanner) Next() bool {
 	s.tok = s.scan()
+	for s.tok.Kind == token.EOF && len(s.srcs) > 0 {
+		s.pop()
+		s.tok = s.scan()
+	}
 	return s.tok.Kind != token.EOF
 }
 
@@ -86,8 +140,23 @@ func (s *texScanner) scan() token.Token {
 			Text: line,
 		}
 
+	case '.':
+		if isDigit(s.sc.Peek()) {
+			return token.Token{
+				Kind: token.Number,
+				Pos:  pos,
+				Text: "." + s.scanDigits(),
+			}
+		}
+		return token.Token{
+			Kind: token.Symbol,
+			Pos:  pos,
+			Text: s.sc.TokenText(),
+		}
+
 	case '$', '_', '=', '<', '>', '^', '/', '*', '-', '+',
-		'!', '?', '\'', ':', ',', ';', '.':
+		'!', '?', '\'', ':', ',', ';',
+		'&', '|', '~', '#', '@', '`':
 		return token.Token{
 			Kind: token.Symbol,
 			Pos:  pos,
@@ -130,11 +199,17 @@ func (s *texScanner) scan() token.Token {
 			Pos:  pos,
 			Text: s.sc.TokenText(),
 		}
-	case scanner.Int, scanner.Float:
+	case scanner.Int:
+		txt := s.sc.TokenText()
+		if s.sc.Peek() == '.' {
+			// the fractional part of a decimal number, as in 1.5 or 42.
+			s.sc.Next()
+			txt += "." + s.scanDigits()
+		}
 		return token.Token{
 			Kind: token.Number,
 			Pos:  pos,
-			Text: s.sc.TokenText(),
+			Text: txt,
 		}
 	case scanner.String, scanner.Char:
 		return token.Token{
@@ -156,6 +231,17 @@ func (s *texScanner) next() {
 	s.r = s.sc.Scan()
 }
 
+// scanDigits returns the run of decimal digits that follows.
+func (s *texScanner) scanDigits() string {
+	digits := new(strings.Builder)
+	for isDigit(s.sc.Peek()) {
+		digits.WriteRune(s.sc.Next())
+	}
+	return digits.String()
+}
+
+func isDigit(r rune) bool { return '0' <= r && r <= '9' }
+
 func (s *texScanner) scanMacro() token.Token {
 	var (
 		macro = new(strings.Builder)
@@ -193,6 +279,50 @@ func (s *texScanner) scanComment() string {
 	return comment.String()
 }
 
+// verbatim returns the raw text up to the rune closing the group opened
+// with the most recently scanned token, and the position of that closing rune.
+// Nested groups are balanced.
+func (s *texScanner) verbatim(open, close rune) (string, token.Pos) {
+	var (
+		raw   = new(strings.Builder)
+		depth = 0
+	)
+	for {
+		pos := s.offset()
+		r := s.sc.Next()
+		switch r {
+		case scanner.EOF:
+			panic(fmt.Errorf("unterminated verbatim argument: missing %q", close))
+		case open:
+			depth++
+		case close:
+			if depth == 0 {
+				return raw.String(), pos
+			}
+			depth--
+		}
+		raw.WriteRune(r)
+	}
+}
+
+// verbatimUntil returns the raw text up to the provided end marker, and the
+// position of that marker.
+// The end marker is consumed.
+func (s *texScanner) verbatimUntil(end string) (string, token.Pos) {
+	raw := new(strings.Builder)
+	for {
+		r := s.sc.Next()
+		if r == scanner.EOF {
+			panic(fmt.Errorf("unterminated verbatim environment: missing %q", end))
+		}
+		raw.WriteRune(r)
+		if txt := raw.String(); strings.HasSuffix(txt, end) {
+			pos := s.offset() - token.Pos(len(end))
+			return strings.TrimSuffix(txt, end), pos
+		}
+	}
+}
+
 // func (s *texScanner) expect(want rune) {
 // 	s.next()
 // 	if s.r != want {
@@ -201,5 +331,10 @@ func (s *texScanner) scanComment() string {
 // }
 
 func (s *texScanner) pos() token.Pos {
-	return token.Pos(s.sc.Position.Offset)
+	return token.Pos(s.base + s.sc.Position.Offset)
+}
+
+// offset returns the position of the next character.
+func (s *texScanner) offset() token.Pos {
+	return token.Pos(s.base + s.sc.Pos().Offset)
 }
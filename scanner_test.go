@@ -6,6 +6,7 @@ package latex
 
 import (
 	"log"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -53,3 +54,38 @@ func TestScanner(t *testing.T) {
 		})
 	}
 }
+
+func TestScanNumbers(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  []string // kind:text of the tokens
+	}{
+		{input: "23.4", want: []string{"Number:23.4"}},
+		{input: "42.", want: []string{"Number:42."}},
+		{input: ".5", want: []string{"Number:.5"}},
+		{input: "43.x", want: []string{"Number:43.", "Word:x"}},
+		{input: "2pt", want: []string{"Number:2", "Word:pt"}},
+		{input: "1em", want: []string{"Number:1", "Word:em"}},
+		{input: "1.5em", want: []string{"Number:1.5", "Word:em"}},
+		{input: "2e3", want: []string{"Number:2", "Word:e", "Number:3"}},
+		{input: "x.", want: []string{"Word:x", "Symbol:."}},
+		{
+			input: `\\[2ex]`,
+			want:  []string{`Macro:\\`, "Lbrack:[", "Number:2", "Word:ex", "Rbrack:]"},
+		},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			var (
+				sc  = newScanner(strings.NewReader(tc.input))
+				got []string
+			)
+			for sc.Next() {
+				tok := sc.Token()
+				got = append(got, tok.Kind.String()+":"+tok.Text)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid tokens:\ngot= %q\nwant=%q", got, tc.want)
+			}
+		})
+	}
+}
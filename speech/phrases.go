@@ -0,0 +1,274 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package speech
+
+// Phrases holds the words and patterns used to describe math expressions.
+//
+// Phrases can be replaced to describe expressions in another language,
+// or to change the wording of a construct.
+type Phrases struct {
+	// Words holds the spoken form of symbols and of macros without
+	// arguments, e.g. "+" or `\alpha`.
+	// Macros missing from Words are spoken with their name.
+	Words map[string]string
+
+	// Patterns holds the fmt patterns describing constructs, by verbosity.
+	// Empty patterns fall back to the Normal pattern, then to the English
+	// patterns, so Patterns may only hold some of the constructs.
+	//
+	// Patterns receive the descriptions of the parts of the construct as
+	// arguments: explicit argument indices (%[2]s) can be used to reorder
+	// them.
+	Patterns map[string][3]string
+}
+
+// pattern returns the pattern of the named construct, for the provided
+// verbosity.
+func (ph *Phrases) pattern(name string, v Verbosity) string {
+	for _, pats := range [][3]string{ph.Patterns[name], englishPatterns[name]} {
+		if int(v) < len(pats) && pats[v] != "" {
+			return pats[v]
+		}
+		if pats[Normal] != "" {
+			return pats[Normal]
+		}
+	}
+	return ""
+}
+
+// English returns the English phrases, in the MathSpeak and ClearSpeak
+// style.
+func English() *Phrases {
+	ph := &Phrases{
+		Words:    make(map[string]string, len(englishWords)),
+		Patterns: make(map[string][3]string, len(englishPatterns)),
+	}
+	for k, v := range englishWords {
+		ph.Words[k] = v
+	}
+	for k, v := range englishPatterns {
+		ph.Patterns[k] = v
+	}
+	return ph
+}
+
+var englishPatterns = map[string][3]string{
+	// Normal, Brief, Verbose
+	"frac":     {"the fraction %[1]s over %[2]s", "%[1]s over %[2]s", "start fraction %[1]s over %[2]s end fraction"},
+	"binom":    {"the binomial coefficient %[1]s choose %[2]s", "%[1]s choose %[2]s", "start binomial %[1]s choose %[2]s end binomial"},
+	"sqrt":     {"the square root of %[1]s", "root %[1]s", "start root %[1]s end root"},
+	"cbrt":     {"the cube root of %[1]s", "cube root %[1]s", "start cube root %[1]s end root"},
+	"root":     {"the root of index %[1]s of %[2]s", "index %[1]s root %[2]s", "start root index %[1]s %[2]s end root"},
+	"squared":  {"%[1]s squared", "", ""},
+	"cubed":    {"%[1]s cubed", "", ""},
+	"power":    {"%[1]s to the power of %[2]s", "%[1]s to the %[2]s", "%[1]s superscript %[2]s baseline"},
+	"prime":    {"%[1]s prime", "", ""},
+	"sub":      {"%[1]s sub %[2]s", "", "%[1]s subscript %[2]s baseline"},
+	"base":     {"%[1]s base %[2]s", "", ""},
+	"op":       {"the %[1]s", "%[1]s", "the %[1]s"},
+	"op-from":  {"the %[1]s over %[2]s", "%[1]s over %[2]s", "the %[1]s with lower limit %[2]s"},
+	"op-to":    {"the %[1]s to %[3]s", "%[1]s to %[3]s", "the %[1]s with upper limit %[3]s"},
+	"op-range": {"the %[1]s from %[2]s to %[3]s", "%[1]s from %[2]s to %[3]s", "the %[1]s with lower limit %[2]s and upper limit %[3]s"},
+	"lim":      {"the %[1]s as %[2]s", "%[1]s as %[2]s", "the %[1]s as %[2]s"},
+	"of":       {"%[1]s of %[2]s", "%[1]s %[2]s", ""},
+	"accent":   {"%[1]s %[2]s", "", ""},
+	"vec":      {"vector %[2]s", "", ""},
+	"font":     {"%[2]s", "", "%[1]s %[2]s"},
+	"upper":    {"%[1]s", "", "upper %[1]s"},
+	"group":    {"%[1]s", "", "open group %[1]s close group"},
+
+	// tables receive their number of rows and columns, and their rows.
+	"matrix":      {"the %[1]d by %[2]d matrix; %[3]s", "%[1]d by %[2]d matrix; %[3]s", "start %[1]d by %[2]d matrix %[3]s end matrix"},
+	"determinant": {"the %[1]d by %[2]d determinant; %[3]s", "%[1]d by %[2]d determinant; %[3]s", "start %[1]d by %[2]d determinant %[3]s end determinant"},
+	"cases":       {"the %[1]d cases; %[3]s", "%[1]d cases; %[3]s", "start %[1]d cases %[3]s end cases"},
+	"table":       {"the table of %[1]d rows and %[2]d columns; %[3]s", "%[1]d by %[2]d table; %[3]s", "start table of %[1]d rows and %[2]d columns %[3]s end table"},
+	"row":         {"row %[1]d: %[2]s", "", "row %[1]d %[2]s"},
+	"case":        {"case %[1]d: %[2]s", "", "case %[1]d %[2]s"},
+}
+
+var englishWords = map[string]string{
+	// operators and relations
+	"+":   "plus",
+	"-":   "minus",
+	"*":   "times",
+	"/":   "divided by",
+	"=":   "equals",
+	"<":   "is less than",
+	">":   "is greater than",
+	"!":   "factorial",
+	",":   "comma",
+	";":   "semicolon",
+	":":   "colon",
+	".":   "point",
+	"'":   "prime",
+	"|":   "vertical bar",
+	"(":   "open paren",
+	")":   "close paren",
+	"[":   "open bracket",
+	"]":   "close bracket",
+	"?":   "question mark",
+	"&":   "",
+	"\\{": "open brace",
+	"\\}": "close brace",
+
+	`\pm`:             "plus or minus",
+	`\mp`:             "minus or plus",
+	`\times`:          "times",
+	`\cdot`:           "times",
+	`\div`:            "divided by",
+	`\ast`:            "asterisk",
+	`\star`:           "star",
+	`\circ`:           "composed with",
+	`\bullet`:         "bullet",
+	`\cap`:            "intersection",
+	`\cup`:            "union",
+	`\setminus`:       "set minus",
+	`\wedge`:          "and",
+	`\vee`:            "or",
+	`\oplus`:          "direct sum",
+	`\otimes`:         "tensor product",
+	`\neq`:            "is not equal to",
+	`\ne`:             "is not equal to",
+	`\leq`:            "is less than or equal to",
+	`\le`:             "is less than or equal to",
+	`\geq`:            "is greater than or equal to",
+	`\ge`:             "is greater than or equal to",
+	`\ll`:             "is much less than",
+	`\gg`:             "is much greater than",
+	`\approx`:         "is approximately equal to",
+	`\equiv`:          "is equivalent to",
+	`\cong`:           "is congruent to",
+	`\sim`:            "is similar to",
+	`\simeq`:          "is asymptotically equal to",
+	`\propto`:         "is proportional to",
+	`\in`:             "is an element of",
+	`\notin`:          "is not an element of",
+	`\ni`:             "contains",
+	`\subset`:         "is a subset of",
+	`\subseteq`:       "is a subset of or equal to",
+	`\supset`:         "is a superset of",
+	`\supseteq`:       "is a superset of or equal to",
+	`\mid`:            "divides",
+	`\parallel`:       "is parallel to",
+	`\perp`:           "is perpendicular to",
+	`\to`:             "approaches",
+	`\rightarrow`:     "right arrow",
+	`\leftarrow`:      "left arrow",
+	`\mapsto`:         "maps to",
+	`\Rightarrow`:     "implies",
+	`\Leftarrow`:      "is implied by",
+	`\Leftrightarrow`: "if and only if",
+	`\iff`:            "if and only if",
+	`\implies`:        "implies",
+	`\forall`:         "for all",
+	`\exists`:         "there exists",
+	`\neg`:            "not",
+	`\lnot`:           "not",
+
+	// big operators
+	`\sum`:       "sum",
+	`\prod`:      "product",
+	`\coprod`:    "coproduct",
+	`\int`:       "integral",
+	`\iint`:      "double integral",
+	`\iiint`:     "triple integral",
+	`\oint`:      "contour integral",
+	`\bigcup`:    "union",
+	`\bigcap`:    "intersection",
+	`\bigoplus`:  "direct sum",
+	`\bigotimes`: "tensor product",
+	`\bigvee`:    "disjunction",
+	`\bigwedge`:  "conjunction",
+	`\lim`:       "limit",
+	`\liminf`:    "limit inferior",
+	`\limsup`:    "limit superior",
+	`\max`:       "maximum",
+	`\min`:       "minimum",
+	`\sup`:       "supremum",
+	`\inf`:       "infimum",
+
+	// functions
+	`\sin`:    "sine",
+	`\cos`:    "cosine",
+	`\tan`:    "tangent",
+	`\cot`:    "cotangent",
+	`\sec`:    "secant",
+	`\csc`:    "cosecant",
+	`\arcsin`: "arc sine",
+	`\arccos`: "arc cosine",
+	`\arctan`: "arc tangent",
+	`\sinh`:   "hyperbolic sine",
+	`\cosh`:   "hyperbolic cosine",
+	`\tanh`:   "hyperbolic tangent",
+	`\coth`:   "hyperbolic cotangent",
+	`\log`:    "log",
+	`\ln`:     "natural log",
+	`\lg`:     "log",
+	`\exp`:    "exponential",
+	`\det`:    "determinant",
+	`\dim`:    "dimension",
+	`\ker`:    "kernel",
+	`\deg`:    "degree",
+	`\gcd`:    "greatest common divisor",
+	`\arg`:    "argument",
+	`\Pr`:     "probability",
+
+	// symbols
+	`\infty`:      "infinity",
+	`\partial`:    "partial",
+	`\nabla`:      "nabla",
+	`\emptyset`:   "the empty set",
+	`\hbar`:       "h bar",
+	`\ell`:        "script l",
+	`\prime`:      "prime",
+	`\ldots`:      "dot dot dot",
+	`\cdots`:      "dot dot dot",
+	`\dots`:       "dot dot dot",
+	`\vdots`:      "vertical dots",
+	`\ddots`:      "diagonal dots",
+	`\langle`:     "open angle bracket",
+	`\rangle`:     "close angle bracket",
+	`\lfloor`:     "open floor",
+	`\rfloor`:     "close floor",
+	`\lceil`:      "open ceiling",
+	`\rceil`:      "close ceiling",
+	`\vert`:       "vertical bar",
+	`\Vert`:       "double vertical bar",
+	`\backslash`:  "backslash",
+	`\varepsilon`: "epsilon",
+	`\vartheta`:   "theta",
+	`\varphi`:     "phi",
+
+	// accents
+	`\hat`:       "hat",
+	`\widehat`:   "hat",
+	`\bar`:       "bar",
+	`\overline`:  "bar",
+	`\dot`:       "dot",
+	`\ddot`:      "double dot",
+	`\tilde`:     "tilde",
+	`\widetilde`: "tilde",
+	`\vec`:       "vector",
+	`\underline`: "underline",
+
+	// fonts
+	`\mathbf`:   "bold",
+	`\mathbb`:   "double-struck",
+	`\mathcal`:  "script",
+	`\mathscr`:  "script",
+	`\mathfrak`: "fraktur",
+	`\mathsf`:   "sans-serif",
+	`\mathtt`:   "monospace",
+	`\mathit`:   "italic",
+
+	// spacing
+	`\,`:     "",
+	`\;`:     "",
+	`\:`:     "",
+	`\!`:     "",
+	`\ `:     "",
+	`\quad`:  "",
+	`\qquad`: "",
+}
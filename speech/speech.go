@@ -0,0 +1,396 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package speech describes LaTeX math expressions in words, in the style
+// of MathSpeak and ClearSpeak.
+//
+// Descriptions are suitable for screen readers and for the alt text of
+// rendered formulae:
+//
+//	\frac{a}{b}       the fraction a over b
+//	x^2               x squared
+//	\sum_{i=1}^{n}    the sum from i equals 1 to n
+package speech // import "github.com/go-latex/latex/speech"
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-latex/latex/ast"
+)
+
+// Verbosity controls the level of detail of descriptions.
+type Verbosity int
+
+const (
+	Normal  Verbosity = iota // ClearSpeak-like descriptions
+	Brief                    // terse descriptions
+	Verbose                  // MathSpeak-like descriptions, with explicit start and end markers
+)
+
+// Speaker describes math expressions.
+type Speaker struct {
+	Verbosity Verbosity
+	Phrases   *Phrases
+}
+
+// New returns a new speaker with the provided verbosity, using English
+// phrases.
+func New(v Verbosity) *Speaker {
+	return &Speaker{Verbosity: v, Phrases: English()}
+}
+
+// Speak describes the provided math expression in English, with the
+// Normal verbosity.
+func Speak(node ast.Node) string {
+	return New(Normal).Speak(node)
+}
+
+// Speak describes the provided math expression.
+func (s *Speaker) Speak(node ast.Node) string {
+	return strings.Join(strings.Fields(s.node(node)), " ")
+}
+
+func (s *Speaker) format(name string, args ...interface{}) string {
+	return fmt.Sprintf(s.Phrases.pattern(name, s.Verbosity), args...)
+}
+
+func (s *Speaker) word(name string) string {
+	if w, ok := s.Phrases.Words[name]; ok {
+		return w
+	}
+	return strings.TrimPrefix(name, `\`)
+}
+
+func (s *Speaker) node(node ast.Node) string {
+	switch node := node.(type) {
+	case nil:
+		return ""
+	case ast.List:
+		return s.list(node)
+	case *ast.MathExpr:
+		return s.list(node.List)
+	case *ast.Env:
+		return s.list(node.List)
+	case *ast.Table:
+		return s.table(node)
+	case *ast.Arg:
+		return s.list(node.List)
+	case *ast.OptArg:
+		return s.list(node.List)
+	case *ast.Word:
+		return s.letters(node.Text)
+	case *ast.Literal:
+		return node.Text
+	case *ast.Symbol:
+		return s.word(node.Text)
+	case *ast.Macro:
+		return s.macro(node)
+	case *ast.Sub:
+		return s.format("sub", "", s.node(node.Node))
+	case *ast.Sup:
+		return s.format("power", "", s.node(node.Node))
+	default:
+		return ""
+	}
+}
+
+// letters describes the letters of a word in math mode, one by one.
+func (s *Speaker) letters(txt string) string {
+	var o []string
+	for _, r := range txt {
+		v := string(r)
+		if unicode.IsUpper(r) {
+			v = s.format("upper", v)
+		}
+		o = append(o, v)
+	}
+	return strings.Join(o, " ")
+}
+
+func (s *Speaker) macro(m *ast.Macro) string {
+	name := m.Name.Name
+	switch name {
+	case `\frac`, `\dfrac`, `\tfrac`:
+		if len(m.Args) == 2 {
+			return s.format("frac", s.node(m.Args[0]), s.node(m.Args[1]))
+		}
+	case `\binom`:
+		if len(m.Args) == 2 {
+			return s.format("binom", s.node(m.Args[0]), s.node(m.Args[1]))
+		}
+	case `\sqrt`:
+		switch len(m.Args) {
+		case 1:
+			return s.format("sqrt", s.node(m.Args[0]))
+		case 2:
+			idx := s.node(m.Args[0])
+			if idx == "3" {
+				return s.format("cbrt", s.node(m.Args[1]))
+			}
+			return s.format("root", idx, s.node(m.Args[1]))
+		}
+	case `\text`, `\mbox`, `\textrm`, `\textit`, `\textbf`, `\operatorname`, `\mathrm`:
+		return s.text(m.Args)
+	}
+
+	if len(m.Args) > 0 {
+		arg := s.node(m.Args[len(m.Args)-1])
+		switch {
+		case accents[name]:
+			return s.accent(name, arg)
+		case fonts[name]:
+			return s.format("font", s.word(name), arg)
+		case funcs[name]:
+			return s.format("of", s.word(name), arg)
+		}
+		return s.word(name) + " " + arg
+	}
+	return s.word(name)
+}
+
+func (s *Speaker) accent(name, arg string) string {
+	if name == `\vec` {
+		return s.format("vec", s.word(name), arg)
+	}
+	return s.format("accent", arg, s.word(name))
+}
+
+// text returns the text of the arguments of a text macro.
+func (s *Speaker) text(args ast.List) string {
+	var o []string
+	ast.Inspect(args, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Word:
+			o = append(o, node.Text)
+		case *ast.Literal:
+			o = append(o, node.Text)
+		}
+		return true
+	})
+	return strings.Join(o, " ")
+}
+
+// list describes a list of nodes, attaching scripts to their base.
+func (s *Speaker) list(list ast.List) string {
+	var o []string
+	for i := 0; i < len(list); i++ {
+		var (
+			node = list[i]
+			base string
+		)
+
+		m, _ := node.(*ast.Macro)
+		switch {
+		case m != nil && len(m.Args) == 0 && accents[m.Name.Name] && i+1 < len(list):
+			// accent applied to the next node, e.g. \hat x.
+			i++
+			base = s.accent(m.Name.Name, s.group(list[i]))
+		case m != nil && bigops[m.Name.Name]:
+			sub, sup, n := scripts(list[i+1:])
+			i += n
+			base = s.bigop(m.Name.Name, sub, sup)
+			if i+1 < len(list) && !isRel(list[i+1]) {
+				base = s.format("of", base, "")
+			}
+			o = append(o, base)
+			continue
+		default:
+			base = s.group(node)
+		}
+
+		sub, sup, n := scripts(list[i+1:])
+		i += n
+		if sub != nil {
+			pat := "sub"
+			if m != nil && funcs[m.Name.Name] {
+				pat = "base"
+			}
+			base = s.format(pat, base, s.group(sub))
+		}
+		if sup != nil {
+			base = s.power(base, sup)
+		}
+		if base != "" {
+			o = append(o, base)
+		}
+	}
+	return strings.Join(o, " ")
+}
+
+// table describes a matrix, a set of cases or a table, with its size and
+// its cells row by row.
+func (s *Speaker) table(tbl *ast.Table) string {
+	kind, row := "matrix", "row"
+	switch tbl.Name.Name {
+	case "vmatrix", "Vmatrix":
+		kind = "determinant"
+	case "cases":
+		kind, row = "cases", "case"
+	case "tabular", "tabular*", "tabularx":
+		kind = "table"
+	}
+
+	var (
+		cols int
+		rows = make([]string, len(tbl.Rows))
+	)
+	for i, cells := range tbl.Rows {
+		var (
+			n int
+			o = make([]string, len(cells))
+		)
+		for j, cell := range cells {
+			n += max(cell.Span, 1)
+			o[j] = s.list(cell.List)
+		}
+		cols = max(cols, n)
+		rows[i] = s.format(row, i+1, strings.Join(o, ", "))
+	}
+	return s.format(kind, len(rows), cols, strings.Join(rows, "; "))
+}
+
+// group describes a node, marking groups of multiple nodes.
+func (s *Speaker) group(node ast.Node) string {
+	switch list := node.(type) {
+	case ast.List:
+		if len(list) > 1 {
+			return s.format("group", s.list(list))
+		}
+	}
+	return s.node(node)
+}
+
+func (s *Speaker) power(base string, sup ast.Node) string {
+	if list, ok := sup.(ast.List); ok && len(list) == 1 {
+		sup = list[0]
+	}
+	switch sup := sup.(type) {
+	case *ast.Literal:
+		switch sup.Text {
+		case "2":
+			return s.format("squared", base)
+		case "3":
+			return s.format("cubed", base)
+		}
+	case *ast.Macro:
+		if sup.Name.Name == `\prime` {
+			return s.format("prime", base)
+		}
+	case *ast.Symbol:
+		if sup.Text == "'" {
+			return s.format("prime", base)
+		}
+	}
+	return s.format("power", base, s.node(sup))
+}
+
+func (s *Speaker) bigop(name string, sub, sup ast.Node) string {
+	op := s.word(name)
+	switch {
+	case sub != nil && sup != nil:
+		return s.format("op-range", op, s.node(sub), s.node(sup))
+	case sub != nil && limits[name]:
+		return s.format("lim", op, s.node(sub))
+	case sub != nil:
+		return s.format("op-from", op, s.node(sub))
+	case sup != nil:
+		return s.format("op-to", op, "", s.node(sup))
+	default:
+		return s.format("op", op)
+	}
+}
+
+// scripts returns the subscript and superscript at the start of the
+// provided list, and the number of consumed nodes.
+func scripts(list ast.List) (sub, sup ast.Node, n int) {
+	for _, node := range list {
+		switch node := node.(type) {
+		case *ast.Sub:
+			if sub != nil {
+				return sub, sup, n
+			}
+			sub = node.Node
+		case *ast.Sup:
+			if sup != nil {
+				return sub, sup, n
+			}
+			sup = node.Node
+		default:
+			return sub, sup, n
+		}
+		n++
+	}
+	return sub, sup, n
+}
+
+// isRel returns whether the node is a relation or a punctuation symbol,
+// ending the operand of a big operator.
+func isRel(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.Symbol:
+		return strings.Contains("=<>,;", node.Text)
+	case *ast.Macro:
+		return rels[node.Name.Name]
+	}
+	return false
+}
+
+var (
+	accents = map[string]bool{
+		`\hat`:       true,
+		`\widehat`:   true,
+		`\bar`:       true,
+		`\overline`:  true,
+		`\dot`:       true,
+		`\ddot`:      true,
+		`\tilde`:     true,
+		`\widetilde`: true,
+		`\vec`:       true,
+		`\underline`: true,
+	}
+
+	fonts = map[string]bool{
+		`\mathbf`:   true,
+		`\mathbb`:   true,
+		`\mathcal`:  true,
+		`\mathscr`:  true,
+		`\mathfrak`: true,
+		`\mathsf`:   true,
+		`\mathtt`:   true,
+		`\mathit`:   true,
+	}
+
+	funcs = map[string]bool{
+		`\sin`: true, `\cos`: true, `\tan`: true, `\cot`: true, `\sec`: true, `\csc`: true,
+		`\arcsin`: true, `\arccos`: true, `\arctan`: true,
+		`\sinh`: true, `\cosh`: true, `\tanh`: true, `\coth`: true,
+		`\log`: true, `\ln`: true, `\lg`: true, `\exp`: true,
+		`\det`: true, `\dim`: true, `\ker`: true, `\deg`: true, `\gcd`: true, `\arg`: true, `\Pr`: true,
+	}
+
+	bigops = map[string]bool{
+		`\sum`: true, `\prod`: true, `\coprod`: true,
+		`\int`: true, `\iint`: true, `\iiint`: true, `\oint`: true,
+		`\bigcup`: true, `\bigcap`: true, `\bigoplus`: true, `\bigotimes`: true,
+		`\bigvee`: true, `\bigwedge`: true,
+		`\lim`: true, `\liminf`: true, `\limsup`: true,
+		`\max`: true, `\min`: true, `\sup`: true, `\inf`: true,
+	}
+
+	// limits are the big operators whose subscript describes a limit,
+	// rather than a range.
+	limits = map[string]bool{
+		`\lim`: true, `\liminf`: true, `\limsup`: true,
+	}
+
+	rels = map[string]bool{
+		`\neq`: true, `\leq`: true, `\geq`: true, `\ll`: true, `\gg`: true,
+		`\approx`: true, `\equiv`: true, `\cong`: true, `\sim`: true, `\simeq`: true,
+		`\propto`: true, `\in`: true, `\subset`: true, `\subseteq`: true,
+		`\supset`: true, `\supseteq`: true, `\to`: true, `\rightarrow`: true,
+		`\Rightarrow`: true, `\Leftrightarrow`: true, `\iff`: true, `\implies`: true,
+	}
+)
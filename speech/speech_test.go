@@ -0,0 +1,181 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package speech
+
+import (
+	"testing"
+
+	"github.com/go-latex/latex"
+)
+
+func TestSpeak(t *testing.T) {
+	for _, tc := range []struct {
+		expr    string
+		normal  string
+		brief   string
+		verbose string
+	}{
+		{
+			expr:    `$\frac{a}{b}$`,
+			normal:  "the fraction a over b",
+			brief:   "a over b",
+			verbose: "start fraction a over b end fraction",
+		},
+		{
+			expr:    `$x^2 + y^{3} = z^{n+1}$`,
+			normal:  "x squared plus y cubed equals z to the power of n plus 1",
+			brief:   "x squared plus y cubed equals z to the n plus 1",
+			verbose: "x squared plus y cubed equals z superscript n plus 1 baseline",
+		},
+		{
+			expr:    `$\sum_{i=1}^{n} x_i$`,
+			normal:  "the sum from i equals 1 to n of x sub i",
+			brief:   "sum from i equals 1 to n x sub i",
+			verbose: "the sum with lower limit i equals 1 and upper limit n of x subscript i baseline",
+		},
+		{
+			expr:    `$\int_0^\infty e^{-x} dx$`,
+			normal:  "the integral from 0 to infinity of e to the power of minus x d x",
+			brief:   "integral from 0 to infinity e to the minus x d x",
+			verbose: "the integral with lower limit 0 and upper limit infinity of e superscript minus x baseline d x",
+		},
+		{
+			expr:    `$\lim_{x \to 0} \frac{\sin x}{x} = 1$`,
+			normal:  "the limit as x approaches 0 of the fraction sine x over x equals 1",
+			brief:   "limit as x approaches 0 sine x over x equals 1",
+			verbose: "the limit as x approaches 0 of start fraction sine x over x end fraction equals 1",
+		},
+		{
+			expr:    `$\sqrt{2} \sqrt[3]{x} \sqrt[n]{y}$`,
+			normal:  "the square root of 2 the cube root of x the root of index n of y",
+			brief:   "root 2 cube root x index n root y",
+			verbose: "start root 2 end root start cube root x end root start root index n y end root",
+		},
+		{
+			expr:    `$\hat{x} + \vec{v} \cdot \mathbf{A}$`,
+			normal:  "x hat plus vector v times A",
+			brief:   "x hat plus vector v times A",
+			verbose: "x hat plus vector v times bold upper A",
+		},
+		{
+			expr:    `$\log_2 n \leq \alpha'$`,
+			normal:  "log base 2 n is less than or equal to alpha prime",
+			brief:   "log base 2 n is less than or equal to alpha prime",
+			verbose: "log base 2 n is less than or equal to alpha prime",
+		},
+		{
+			expr:    `$\binom{n}{k}$`,
+			normal:  "the binomial coefficient n choose k",
+			brief:   "n choose k",
+			verbose: "start binomial n choose k end binomial",
+		},
+		{
+			expr:    `$\text{if} \; x \in \mathbb{R}$`,
+			normal:  "if x is an element of R",
+			brief:   "if x is an element of R",
+			verbose: "if x is an element of double-struck upper R",
+		},
+		{
+			expr:    `${a}^{-1} \hat x^2$`,
+			normal:  "a to the power of minus 1 x hat squared",
+			brief:   "a to the minus 1 x hat squared",
+			verbose: "a superscript minus 1 baseline x hat squared",
+		},
+		{
+			expr:    `$\begin{pmatrix}1&2\\3&4\end{pmatrix}$`,
+			normal:  "the 2 by 2 matrix; row 1: 1, 2; row 2: 3, 4",
+			brief:   "2 by 2 matrix; row 1: 1, 2; row 2: 3, 4",
+			verbose: "start 2 by 2 matrix row 1 1, 2; row 2 3, 4 end matrix",
+		},
+		{
+			expr:    `$|x| = \begin{cases}x & x \geq 0\\-x & \text{otherwise}\end{cases}$`,
+			normal:  "vertical bar x vertical bar equals the 2 cases; case 1: x, x is greater than or equal to 0; case 2: minus x, otherwise",
+			brief:   "vertical bar x vertical bar equals 2 cases; case 1: x, x is greater than or equal to 0; case 2: minus x, otherwise",
+			verbose: "vertical bar x vertical bar equals start 2 cases case 1 x, x is greater than or equal to 0; case 2 minus x, otherwise end cases",
+		},
+		{
+			expr:    `$\begin{vmatrix}a&b&c\\d\end{vmatrix}$`,
+			normal:  "the 2 by 3 determinant; row 1: a, b, c; row 2: d",
+			brief:   "2 by 3 determinant; row 1: a, b, c; row 2: d",
+			verbose: "start 2 by 3 determinant row 1 a, b, c; row 2 d end determinant",
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			node, err := latex.ParseExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.expr, err)
+			}
+			for _, v := range []struct {
+				verb Verbosity
+				want string
+			}{
+				{Normal, tc.normal},
+				{Brief, tc.brief},
+				{Verbose, tc.verbose},
+			} {
+				if got, want := New(v.verb).Speak(node), v.want; got != want {
+					t.Fatalf("invalid description (verbosity=%d):\ngot= %q\nwant=%q", v.verb, got, want)
+				}
+			}
+			if got, want := Speak(node), tc.normal; got != want {
+				t.Fatalf("invalid default description:\ngot= %q\nwant=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestPhrases(t *testing.T) {
+	ph := English()
+	ph.Words["+"] = "plus"
+	ph.Words["="] = "égale"
+	ph.Words[`\alpha`] = "alpha"
+	ph.Patterns["frac"] = [3]string{"%[1]s sur %[2]s"}
+	ph.Patterns["squared"] = [3]string{"%[1]s au carré"}
+
+	node, err := latex.ParseExpr(`$\frac{a}{b} + x^2 = \alpha$`)
+	if err != nil {
+		t.Fatalf("could not parse expression: %+v", err)
+	}
+
+	s := &Speaker{Verbosity: Verbose, Phrases: ph}
+	if got, want := s.Speak(node), "a sur b plus x au carré égale alpha"; got != want {
+		t.Fatalf("invalid description:\ngot= %q\nwant=%q", got, want)
+	}
+
+	// English phrases are not modified.
+	if got, want := Speak(node), "the fraction a over b plus x squared equals alpha"; got != want {
+		t.Fatalf("invalid description:\ngot= %q\nwant=%q", got, want)
+	}
+}
+
+func TestPartialPhrases(t *testing.T) {
+	node, err := latex.ParseExpr(`$\frac{a}{b} + \sqrt{x}^2$`)
+	if err != nil {
+		t.Fatalf("could not parse expression: %+v", err)
+	}
+
+	// constructs missing from the phrases, or only partly described, fall
+	// back to English.
+	ph := &Phrases{
+		Words: map[string]string{"+": "plus"},
+		Patterns: map[string][3]string{
+			"frac": {"%[1]s sur %[2]s"},
+			"sqrt": {"", "racine %[1]s"},
+		},
+	}
+	for _, tc := range []struct {
+		verb Verbosity
+		want string
+	}{
+		{Normal, "a sur b plus the square root of x squared"},
+		{Brief, "a sur b plus racine x squared"},
+		{Verbose, "a sur b plus start root x end root squared"},
+	} {
+		s := &Speaker{Verbosity: tc.verb, Phrases: ph}
+		if got, want := s.Speak(node), tc.want; got != want {
+			t.Fatalf("invalid description (verbosity=%d):\ngot= %q\nwant=%q", tc.verb, got, want)
+		}
+	}
+}
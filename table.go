@@ -0,0 +1,280 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package latex
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/internal/tex2unicode"
+)
+
+// newTable converts a tabular-like environment into a table.
+func newTable(env *ast.Env) *ast.Table {
+	tbl := &ast.Table{
+		Left:  env.Left,
+		Name:  env.Name,
+		Right: env.Right,
+	}
+	if n := len(env.Args); n > 0 {
+		tbl.Args = env.Args[:n-1]
+		tbl.Spec = parseColSpec(verbatimText(env.Args[n-1].(*ast.Arg).List))
+	}
+
+	var (
+		row  []ast.Cell
+		cell = ast.Cell{Span: 1}
+	)
+	endCell := func() {
+		cell.List = trimSpaces(cell.List)
+		row = append(row, cell)
+		cell = ast.Cell{Span: 1}
+	}
+	endRow := func() {
+		endCell()
+		tbl.Rows = append(tbl.Rows, row)
+		row = nil
+	}
+
+	for _, node := range env.List {
+		switch node := node.(type) {
+		case *ast.Symbol:
+			if node.Text == "&" {
+				endCell()
+				continue
+			}
+		case *ast.Macro:
+			switch node.Name.Name {
+			case `\\`, `\\*`, `\tabularnewline`:
+				endRow()
+				continue
+			case `\hline`:
+				tbl.Lines = append(tbl.Lines, ast.HLine{Row: len(tbl.Rows)})
+				continue
+			case `\cline`:
+				line := ast.HLine{Row: len(tbl.Rows)}
+				line.From, line.To = parseColRange(verbatimText(node.Args[0].(*ast.Arg).List))
+				tbl.Lines = append(tbl.Lines, line)
+				continue
+			case `\multicolumn`:
+				span := verbatimText(node.Args[0].(*ast.Arg).List)
+				n, err := strconv.Atoi(strings.TrimSpace(span))
+				if err != nil || n < 1 {
+					panic(fmt.Errorf(`invalid \multicolumn span %q`, span))
+				}
+				cell.Span = n
+				cell.Spec = parseColSpec(verbatimText(node.Args[1].(*ast.Arg).List))
+				cell.List = append(cell.List, node.Args[2].(*ast.Arg).List...)
+				continue
+			}
+		}
+		cell.List = append(cell.List, node)
+	}
+
+	// a trailing \\ does not start a new row.
+	if cell.List = trimSpaces(cell.List); len(row) > 0 || len(cell.List) > 0 || cell.Span > 1 {
+		endRow()
+	}
+
+	return tbl
+}
+
+// parseColSpec parses the column specification of a table.
+// ex:
+//  l|c|r
+//  @{}lp{3cm}@{}
+//  *{3}{c}
+func parseColSpec(spec string) []ast.ColSpec {
+	var (
+		cols []ast.ColSpec
+		rs   = []rune(spec)
+	)
+
+	// group returns the content of the braced group starting at rs[i].
+	group := func(i int) (string, int) {
+		for i < len(rs) && unicode.IsSpace(rs[i]) {
+			i++
+		}
+		if i >= len(rs) || rs[i] != '{' {
+			panic(fmt.Errorf("invalid column specification %q: missing argument", spec))
+		}
+		depth := 0
+		for j := i; j < len(rs); j++ {
+			switch rs[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return string(rs[i+1 : j]), j + 1
+				}
+			}
+		}
+		panic(fmt.Errorf("invalid column specification %q: unbalanced braces", spec))
+	}
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		i++
+		switch {
+		case unicode.IsSpace(r):
+			// no-op
+		case r == '|', r == 'l', r == 'c', r == 'r':
+			cols = append(cols, ast.ColSpec{Kind: r})
+		case r == 'p', r == 'm', r == 'b', r == '@', r == '!', r == '>', r == '<':
+			var arg string
+			arg, i = group(i)
+			cols = append(cols, ast.ColSpec{Kind: r, Arg: arg})
+		case r == '*':
+			var num, sub string
+			num, i = group(i)
+			sub, i = group(i)
+			n, err := strconv.Atoi(strings.TrimSpace(num))
+			if err != nil || n < 0 {
+				panic(fmt.Errorf("invalid column specification %q: invalid repeat count %q", spec, num))
+			}
+			for j := 0; j < n; j++ {
+				cols = append(cols, parseColSpec(sub)...)
+			}
+		default:
+			// column types defined by packages (X, S, ...)
+			cols = append(cols, ast.ColSpec{Kind: r})
+		}
+	}
+	return cols
+}
+
+// parseColRange parses the column range of a \cline.
+// ex:
+//  2-3
+func parseColRange(v string) (from, to int) {
+	a, b, ok := strings.Cut(v, "-")
+	if !ok {
+		b = a
+	}
+	var err1, err2 error
+	from, err1 = strconv.Atoi(strings.TrimSpace(a))
+	to, err2 = strconv.Atoi(strings.TrimSpace(b))
+	if err1 != nil || err2 != nil || from < 1 || to < from {
+		panic(fmt.Errorf(`invalid \cline range %q`, v))
+	}
+	return from, to
+}
+
+// trimSpaces removes the leading and trailing spaces of a list.
+func trimSpaces(nodes ast.List) ast.List {
+	isSpace := func(node ast.Node) bool {
+		sym, ok := node.(*ast.Symbol)
+		return ok && strings.TrimSpace(sym.Text) == ""
+	}
+	for len(nodes) > 0 && isSpace(nodes[0]) {
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && isSpace(nodes[len(nodes)-1]) {
+		nodes = nodes[:len(nodes)-1]
+	}
+	return nodes
+}
+
+// WriteCSV writes the content of the table as CSV to w.
+//
+// Cells are converted to plain text. Cells spanning multiple columns are
+// followed by empty cells, so all the records have the same number of fields
+// as the column specification.
+// Horizontal rules are ignored.
+func WriteCSV(w io.Writer, tbl *ast.Table) error {
+	var (
+		ncols = len(tbl.Columns())
+		o     = csv.NewWriter(w)
+	)
+	for _, row := range tbl.Rows {
+		var rec []string
+		for _, cell := range row {
+			rec = append(rec, cellText(cell.List))
+			for i := 1; i < cell.Span; i++ {
+				rec = append(rec, "")
+			}
+		}
+		for len(rec) < ncols {
+			rec = append(rec, "")
+		}
+		err := o.Write(rec)
+		if err != nil {
+			return fmt.Errorf("latex: could not write CSV record: %w", err)
+		}
+	}
+	o.Flush()
+	if err := o.Error(); err != nil {
+		return fmt.Errorf("latex: could not write CSV: %w", err)
+	}
+	return nil
+}
+
+// cellText returns the plain text content of a cell.
+func cellText(nodes ast.List) string {
+	o := new(strings.Builder)
+	writeText(o, nodes)
+	return strings.Join(strings.Fields(o.String()), " ")
+}
+
+func writeText(o *strings.Builder, node ast.Node) {
+	switch node := node.(type) {
+	case nil:
+		// no-op
+	case ast.List:
+		for _, n := range node {
+			writeText(o, n)
+		}
+	case *ast.Word:
+		o.WriteString(node.Text)
+	case *ast.Literal:
+		o.WriteString(node.Text)
+	case *ast.Symbol:
+		switch node.Text {
+		case "~", `\ `:
+			o.WriteString(" ")
+		default:
+			o.WriteString(node.Text)
+		}
+	case *ast.MathExpr:
+		writeText(o, node.List)
+	case *ast.Sup:
+		o.WriteString("^")
+		writeText(o, node.Node)
+	case *ast.Sub:
+		o.WriteString("_")
+		writeText(o, node.Node)
+	case *ast.Arg:
+		writeText(o, node.List)
+	case *ast.OptArg:
+		// optional arguments are not part of the text.
+	case *ast.Macro:
+		name := node.Name.Name
+		switch {
+		case len(name) == 2 && strings.ContainsAny(name[1:], `%&#_${} `):
+			o.WriteString(name[1:])
+		case len(node.Args) > 0:
+			writeText(o, node.Args[len(node.Args)-1])
+		case tex2unicode.HasSymbol(name[1:]):
+			o.WriteRune(tex2unicode.Index(name, true))
+		}
+	case *ast.Env:
+		writeText(o, node.List)
+	case *ast.Table:
+		for _, row := range node.Rows {
+			for _, cell := range row {
+				writeText(o, cell.List)
+				o.WriteString(" ")
+			}
+		}
+	default:
+		panic(fmt.Errorf("latex: unknown ast node %T", node))
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package latex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-latex/latex/ast"
+)
+
+func TestParseTable(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+		lines []ast.HLine
+	}{
+		{
+			input: `\begin{tabular}{lc}a & b\\ c & d\\\end{tabular}`,
+			want:  `ast.Table{"tabular", Spec:"lc", Rows:[[ast.List{ast.Word{"a"}}, ast.List{ast.Word{"b"}}], [ast.List{ast.Word{"c"}}, ast.List{ast.Word{"d"}}]]}`,
+		},
+		{
+			input: `\begin{tabular}{|l c r p{3cm}| @{}}\hline a & & $x^2$ & \textbf{d}\\ \hline\end{tabular}`,
+			want:  `ast.Table{"tabular", Spec:"|lcrp{3cm}|@{}", Rows:[[ast.List{ast.Word{"a"}}, ast.List{}, ast.List{ast.MathExpr{List:ast.Word{"x"}, ast.Sup{ast.Lit{"2"}}}}, ast.List{ast.Macro{"\\textbf", Args:{ast.Word{"d"}}}}]]}`,
+			lines: []ast.HLine{{Row: 0}, {Row: 1}},
+		},
+		{
+			input: `\begin{tabular}{*{3}{c}}\multicolumn{2}{c|}{ab} & c \\ \cline{1-2} 1 & 2 & 3\end{tabular}`,
+			want:  `ast.Table{"tabular", Spec:"ccc", Rows:[[2:ast.List{ast.Word{"ab"}}, ast.List{ast.Word{"c"}}], [ast.List{ast.Lit{"1"}}, ast.List{ast.Lit{"2"}}, ast.List{ast.Lit{"3"}}]]}`,
+			lines: []ast.HLine{{Row: 1, From: 1, To: 2}},
+		},
+		{
+			input: `\begin{tabular*}{10cm}[t]{ll}a & b\end{tabular*}`,
+			want:  `ast.Table{"tabular*", Spec:"ll", Rows:[[ast.List{ast.Word{"a"}}, ast.List{ast.Word{"b"}}]]}`,
+		},
+		{
+			input: `$\begin{array}{cc}1 & 2\\3 & 4\end{array}$`,
+			want:  `ast.Table{"array", Spec:"cc", Rows:[[ast.List{ast.Lit{"1"}}, ast.List{ast.Lit{"2"}}], [ast.List{ast.Lit{"3"}}, ast.List{ast.Lit{"4"}}]]}`,
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			node, err := ParseExpr(tc.input)
+			if err != nil {
+				t.Fatalf("could not parse %q: %+v", tc.input, err)
+			}
+
+			var tbl *ast.Table
+			ast.Inspect(node, func(n ast.Node) bool {
+				if v, ok := n.(*ast.Table); ok && tbl == nil {
+					tbl = v
+				}
+				return true
+			})
+			if tbl == nil {
+				t.Fatalf("could not find table in %q", tc.input)
+			}
+
+			o := new(strings.Builder)
+			ast.Print(o, tbl)
+			if got, want := o.String(), tc.want; got != want {
+				t.Fatalf("invalid table:\ngot= %s\nwant=%s", got, want)
+			}
+
+			if got, want := tbl.Lines, tc.lines; !reflect.DeepEqual(got, want) {
+				t.Fatalf("invalid rules:\ngot= %+v\nwant=%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseColSpec(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want []ast.ColSpec
+	}{
+		{
+			spec: "l|c|r",
+			want: []ast.ColSpec{{Kind: 'l'}, {Kind: '|'}, {Kind: 'c'}, {Kind: '|'}, {Kind: 'r'}},
+		},
+		{
+			spec: "@{} l p{3cm} >{\\bfseries}c @{}",
+			want: []ast.ColSpec{
+				{Kind: '@'}, {Kind: 'l'}, {Kind: 'p', Arg: "3cm"},
+				{Kind: '>', Arg: "\\bfseries"}, {Kind: 'c'}, {Kind: '@'},
+			},
+		},
+		{
+			spec: "*{2}{l|}X",
+			want: []ast.ColSpec{{Kind: 'l'}, {Kind: '|'}, {Kind: 'l'}, {Kind: '|'}, {Kind: 'X'}},
+		},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			got := parseColSpec(tc.spec)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid column spec:\ngot= %+v\nwant=%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	const src = `\begin{tabular}{|l|r|r|}
+\hline
+\textbf{Name} & \multicolumn{2}{c|}{Values} \\
+\hline
+foo, bar & 50\% & $\alpha$ \\
+baz & 1.5 \\
+\hline
+\end{tabular}`
+
+	node, err := ParseExpr(src)
+	if err != nil {
+		t.Fatalf("could not parse table: %+v", err)
+	}
+
+	o := new(strings.Builder)
+	err = WriteCSV(o, node.(ast.List)[0].(*ast.Table))
+	if err != nil {
+		t.Fatalf("could not write CSV: %+v", err)
+	}
+
+	want := `Name,Values,
+"foo, bar",50%,α
+baz,1.5,
+`
+	if got := o.String(); got != want {
+		t.Fatalf("invalid CSV:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
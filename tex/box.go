@@ -6,6 +6,7 @@ package tex
 
 import (
 	"fmt"
+	"image/color"
 	"log"
 	"math"
 
@@ -27,16 +28,34 @@ const (
 // such as sub- and superscripts are laid out.
 // These are all metrics that can't be reliably retrieved from the font metrics
 // in the font itself.
+//
+// Sup1, Sup2, Sup3, Sub1, Sub2, SupDrop and SubDrop correspond to the TeX
+// font parameters σ13 to σ19 of Appendix G of the TeXbook, and
+// BigOpSpacing1 to BigOpSpacing5 to the parameters ξ9 to ξ13.
 type FontConstants struct {
 	// Percentage of x-height of additional horiz. space after sub/superscripts
 	ScriptSpace float64
 
-	// Percentage of x-height that sub/superscripts drop below the baseline
+	// Percentage of x-height of the script font that subscripts of boxes
+	// drop below the bottom of the box
 	SubDrop float64
 
+	// Percentage of x-height of the script font that superscripts of boxes
+	// are raised from the top of the box
+	SupDrop float64
+
 	// Percentage of x-height that superscripts are raised from the baseline
+	// in display style
 	Sup1 float64
 
+	// Percentage of x-height that superscripts are raised from the baseline
+	// in non-cramped styles
+	Sup2 float64
+
+	// Percentage of x-height that superscripts are raised from the baseline
+	// in cramped styles
+	Sup3 float64
+
 	// Percentage of x-height that subscripts drop below the baseline
 	Sub1 float64
 
@@ -56,17 +75,47 @@ type FontConstants struct {
 	// Percentage of x-height that supercripts and subscripts are offset for
 	// integrals
 	DeltaIntegral float64
+
+	// Percentage of x-height of minimum space between the upper limit and
+	// a big operator
+	BigOpSpacing1 float64
+
+	// Percentage of x-height of minimum space between a big operator and
+	// the lower limit
+	BigOpSpacing2 float64
+
+	// Percentage of x-height of minimum distance between the baseline of
+	// the upper limit and a big operator
+	BigOpSpacing3 float64
+
+	// Percentage of x-height of minimum distance between a big operator
+	// and the baseline of the lower limit
+	BigOpSpacing4 float64
+
+	// Percentage of x-height of padding above and below the limits of a
+	// big operator
+	BigOpSpacing5 float64
 }
 
+// DefaultFontConstants are the font constants of Computer Modern, the
+// default TeX math fonts.
 var DefaultFontConstants = FontConstants{
-	ScriptSpace:   0.05,
-	SubDrop:       0.4,
-	Sup1:          0.7,
-	Sub1:          0.3,
-	Sub2:          0.5,
+	ScriptSpace:   0.116,
+	SubDrop:       0.116,
+	SupDrop:       0.897,
+	Sup1:          0.959,
+	Sup2:          0.843,
+	Sup3:          0.671,
+	Sub1:          0.348,
+	Sub2:          0.574,
 	Delta:         0.025,
 	DeltaSlanted:  0.2,
 	DeltaIntegral: 0.1,
+	BigOpSpacing1: 0.258,
+	BigOpSpacing2: 0.387,
+	BigOpSpacing3: 0.465,
+	BigOpSpacing4: 1.394,
+	BigOpSpacing5: 0.232,
 }
 
 // Node represents a node in the TeX box model.
@@ -181,21 +230,24 @@ type Char struct {
 	width   float64
 	height  float64
 	depth   float64
+	italic  float64
 	metrics font.Metrics
 
-	be   font.Backend
-	font font.Font
-	dpi  float64
-	math bool
+	be    font.Backend
+	font  font.Font
+	dpi   float64
+	math  bool
+	color color.Color
 }
 
 func NewChar(c string, state State, math bool) *Char {
 	ch := &Char{
-		c:    c,
-		be:   state.Backend(),
-		font: state.Font,
-		dpi:  state.DPI,
-		math: math,
+		c:     c,
+		be:    state.Backend(),
+		font:  state.Font,
+		dpi:   state.DPI,
+		math:  math,
+		color: state.Color,
 	}
 	ch.updateMetrics()
 	return ch
@@ -214,6 +266,10 @@ func (ch *Char) updateMetrics() {
 	}
 	ch.height = ch.metrics.Iceberg
 	ch.depth = -(ch.metrics.Iceberg - ch.metrics.Height)
+	ch.italic = 0
+	if ch.metrics.Slanted {
+		ch.italic = math.Max(0, ch.metrics.XMax-ch.metrics.Advance)
+	}
 }
 
 func (c *Char) String() string { return c.c }
@@ -237,6 +293,8 @@ func (box *Char) Shrink() {
 		box.width *= shrinkFactor
 		box.height *= shrinkFactor
 		box.depth *= shrinkFactor
+		box.italic *= shrinkFactor
+		box.metrics.Advance *= shrinkFactor
 	}
 }
 
@@ -246,9 +304,12 @@ func (box *Char) Grow() {
 	box.width *= growFactor
 	box.height *= growFactor
 	box.depth *= growFactor
+	box.italic *= growFactor
+	box.metrics.Advance *= growFactor
 }
 
 func (c *Char) Render(x, y float64) {
+	c.be.SetColor(c.color)
 	c.be.RenderGlyph(x, y, c.font, c.c, c.dpi)
 }
 
@@ -261,6 +322,13 @@ func (c *Char) Height() float64 { return c.height }
 // Depth returns the depth of this node.
 func (c *Char) Depth() float64 { return c.depth }
 
+// Italic returns the italic correction of this node: the amount by which
+// the glyph of a slanted character extends past its advance.
+func (c *Char) Italic() float64 { return c.italic }
+
+// Slanted returns whether the glyph of this node is slanted.
+func (c *Char) Slanted() bool { return c.metrics.Slanted }
+
 func (c Char) hpackDims(width, height, depth *float64, stretch, shrink []float64) {
 	*width += c.width
 	*height = math.Max(*height, c.height)
@@ -281,11 +349,12 @@ type Accent struct {
 func NewAccent(c string, state State, math bool) *Accent {
 	acc := &Accent{
 		char: Char{
-			c:    c,
-			be:   state.Backend(),
-			font: state.Font,
-			dpi:  state.DPI,
-			math: math,
+			c:     c,
+			be:    state.Backend(),
+			font:  state.Font,
+			dpi:   state.DPI,
+			math:  math,
+			color: state.Color,
 		},
 	}
 	acc.updateMetrics()
@@ -316,6 +385,7 @@ func (acc *Accent) Grow() {
 }
 
 func (acc *Accent) Render(x, y float64) {
+	acc.char.be.SetColor(acc.char.color)
 	acc.char.be.RenderGlyph(
 		x-acc.char.metrics.XMin,
 		y+acc.char.metrics.YMin,
@@ -686,14 +756,16 @@ func (lst *VList) vpackDims(width, height, depth *float64, stretch, shrink []flo
 // The width is never running in an HList; the height and depth are never
 // running in a VList.
 type Rule struct {
-	box Box
-	out font.Backend
+	box   Box
+	out   font.Backend
+	color color.Color
 }
 
 func NewRule(w, h, d float64, state State) *Rule {
 	return &Rule{
-		box: *newBox(w, h, d),
-		out: state.Backend(),
+		box:   *newBox(w, h, d),
+		out:   state.Backend(),
+		color: state.Color,
 	}
 }
 
@@ -705,6 +777,7 @@ func (rule *Rule) String() string {
 }
 
 func (rule *Rule) render(x, y, w, h float64) {
+	rule.out.SetColor(rule.color)
 	rule.out.RenderRectFilled(x, y, x+w, y+h)
 }
 
@@ -748,6 +821,89 @@ func VRule(state State) *Rule {
 	return NewRule(thickness, math.Inf(+1), math.Inf(+1), state)
 }
 
+// StrokeKind describes the line drawn by a Stroke.
+type StrokeKind int
+
+const (
+	StrokeFrame   StrokeKind = iota // outline of the box
+	StrokeRising                    // diagonal from the bottom-left corner to the top-right one
+	StrokeFalling                   // diagonal from the top-left corner to the bottom-right one
+)
+
+// Stroke is a box drawn as a line of a given thickness: the outline of the
+// box, or one of its diagonals.
+// The line is drawn within the box, which is otherwise empty.
+type Stroke struct {
+	box       Box
+	out       font.Backend
+	color     color.Color
+	kind      StrokeKind
+	thickness float64
+}
+
+func NewStroke(kind StrokeKind, w, h, d, thickness float64, state State) *Stroke {
+	return &Stroke{
+		box:       *newBox(w, h, d),
+		out:       state.Backend(),
+		color:     state.Color,
+		kind:      kind,
+		thickness: thickness,
+	}
+}
+
+func (s *Stroke) String() string {
+	return fmt.Sprintf(
+		"Stroke{w=%g, h=%g, d=%g}",
+		s.Width(), s.Height(), s.Depth(),
+	)
+}
+
+func (s *Stroke) render(x, y, w, h float64) {
+	s.out.SetColor(s.color)
+	switch t := s.thickness; s.kind {
+	case StrokeFrame:
+		s.out.RenderRectStroked(x+t/2, y+t/2, x+w-t/2, y+h-t/2, t)
+	case StrokeRising:
+		s.out.RenderLine(x, y+h, x+w, y, t)
+	case StrokeFalling:
+		s.out.RenderLine(x, y, x+w, y+h, t)
+	default:
+		panic(fmt.Errorf("tex: invalid stroke kind %d", s.kind))
+	}
+}
+
+func (s *Stroke) Kerning(next Node) float64 { return s.box.Kerning(next) }
+func (s *Stroke) Render(x, y float64)       { s.box.Render(x, y) }
+
+func (s *Stroke) Shrink() {
+	s.box.Shrink()
+	if s.box.size < numSizeLevels {
+		s.thickness *= shrinkFactor
+	}
+}
+
+func (s *Stroke) Grow() {
+	s.box.Grow()
+	s.thickness *= growFactor
+}
+
+// Width returns the width of this node.
+func (s *Stroke) Width() float64 { return s.box.Width() }
+
+// Height returns the height of this node.
+func (s *Stroke) Height() float64 { return s.box.Height() }
+
+// Depth returns the depth of this node.
+func (s *Stroke) Depth() float64 { return s.box.Depth() }
+
+func (s *Stroke) hpackDims(width, height, depth *float64, stretch, shrink []float64) {
+	s.box.hpackDims(width, height, depth, stretch, shrink)
+}
+
+func (s *Stroke) vpackDims(width, height, depth *float64, stretch, shrink []float64) {
+	s.box.vpackDims(width, height, depth, stretch, shrink)
+}
+
 type Glue struct {
 	size         int
 	width        float64
@@ -780,6 +936,15 @@ func NewGlue(typ string) *Glue {
 	}
 }
 
+// NewSpaceGlue returns the interword glue of the font of the provided state.
+// As in TeX, its natural width is the width of a space, and it stretches
+// by half and shrinks by a third of that width.
+func NewSpaceGlue(state State) *Glue {
+	const math = false
+	w := state.Backend().Metrics(" ", state.Font, state.DPI, math).Advance
+	return newGlue(w, w/2, 0, w/3, 0)
+}
+
 func newGlue(w, st float64, sto int, sh float64, sho int) *Glue {
 	return &Glue{
 		size:         0,
@@ -903,11 +1068,14 @@ func (k *Kern) vpackDims(width, height, depth *float64, stretch, shrink []float6
 	*depth = 0
 }
 
+// SubSuperCluster is a nucleus with its subscript and superscript, laid
+// out as a horizontal list.
+// Sub and Super are nil when the nucleus has no subscript or superscript.
 type SubSuperCluster struct {
 	*HList
-	//	nucleus interface{} // FIXME
-	//	sub     interface{} // FIXME
-	//	super   interface{} // FIXME
+	Nucleus Node
+	Sub     Node
+	Super   Node
 }
 
 // AutoHeightChar creats a character as close to the given height and depth
@@ -956,6 +1124,97 @@ func AutoHeightChar(c string, height, depth float64, state State, factor float64
 	return hlist
 }
 
+// assembly describes how a horizontally extensible symbol is assembled from
+// glyphs.
+type assembly struct {
+	left  string   // left piece
+	rep   string   // piece repeated as needed
+	right string   // right piece
+	mid   []string // middle pieces, if any
+}
+
+// assemblies are the assemblies of the horizontally extensible symbols.
+// As with \rightarrowfill in TeX, arrows are assembled from their head and
+// minus signs.
+// As with \upbracefill and \downbracefill in TeX, horizontal braces are
+// assembled from their curled ends and middle, joined by rules: box drawing
+// arcs joined by box drawing lines.
+var assemblies = map[string]assembly{
+	`\rightarrow`:     {"-", "-", `\rightarrow`, nil},
+	`\leftarrow`:      {`\leftarrow`, "-", "-", nil},
+	`\leftrightarrow`: {`\leftarrow`, "-", `\rightarrow`, nil},
+	"⏞":               {"╭", "─", "╮", []string{"╯", "╰"}},
+	"⏟":               {"╰", "─", "╯", []string{"╮", "╭"}},
+}
+
+// minOverlap is the minimal overlap of the pieces of an assembly,
+// relatively to the width of its repeated piece.
+const minOverlap = 0.1
+
+// AutoWidthChar creates a horizontally extensible symbol, such as an arrow,
+// as close to the given width as possible.
+// The symbol is assembled from a left and a right piece joined by as many
+// copies of a repeated piece as needed, on both sides of its middle pieces
+// if any. The pieces overlap so the symbol spans exactly the given width,
+// unless it is narrower than the symbol itself.
+// Symbols without an assembly are made of their single glyph.
+func AutoWidthChar(c string, width float64, state State) *HList {
+	const math = true
+	parts, ok := assemblies[c]
+	if !ok {
+		return HListOf([]Node{NewChar(c, state, math)}, false)
+	}
+
+	var (
+		left    = NewChar(parts.left, state, math)
+		right   = NewChar(parts.right, state, math)
+		rep     = NewChar(parts.rep, state, math)
+		mid     = make([]*Char, len(parts.mid))
+		natural = left.Width() + right.Width()
+		minimum = minOverlap * rep.Width()
+		step    = 1 // number of repeated pieces added at once
+		n       = 0 // number of repeated pieces
+	)
+	for i, c := range parts.mid {
+		mid[i] = NewChar(c, state, math)
+		natural += mid[i].Width()
+	}
+	if len(mid) > 0 {
+		// the middle pieces stay centered.
+		step = 2
+	}
+	joints := func(n int) float64 { return float64(n + len(mid) + 1) }
+	for natural+float64(n)*rep.Width()-joints(n)*minimum < width {
+		n += step
+	}
+	overlap := (natural + float64(n)*rep.Width() - width) / joints(n)
+	overlap = max(minimum, min(overlap, rep.Width()/2))
+
+	pieces := []*Char{left}
+	reps := func(n int) {
+		for i := 0; i < n; i++ {
+			pieces = append(pieces, NewChar(parts.rep, state, math))
+		}
+	}
+	reps(n / step)
+	pieces = append(pieces, mid...)
+	reps(n - n/step)
+	pieces = append(pieces, right)
+
+	// glyphs are drawn from their origin: kerns account for the left side
+	// bearings of the pieces, so their inks overlap.
+	nodes := make([]Node, 0, 2*len(pieces))
+	for i, ch := range pieces {
+		if i > 0 {
+			prev := pieces[i-1]
+			nodes = append(nodes, NewKern(prev.metrics.XMin-ch.metrics.XMin-overlap))
+		}
+		nodes = append(nodes, ch)
+	}
+	nodes = append(nodes, NewKern(right.metrics.XMin-left.metrics.XMin))
+	return HListOf(nodes, false)
+}
+
 // Ship boxes to output once boxes have been set up.
 //
 // Since boxes can be inside of boxes inside of boxes... the main work of
@@ -998,6 +1257,9 @@ func (ship *Ship) hlistOut(box Tree) {
 	ship.maxPush = maxInt(ship.cur.s, ship.maxPush)
 
 	for _, node := range box.Nodes() {
+		if c, ok := node.(*SubSuperCluster); ok {
+			node = c.HList
+		}
 		switch node := node.(type) {
 		case *Char:
 			node.Render(ship.cur.h+ship.off.h, ship.cur.v+ship.off.v)
@@ -1060,14 +1322,16 @@ func (ship *Ship) hlistOut(box Tree) {
 				ruleDepth = box.Depth()
 			}
 			if ruleHeight > 0 && ruleWidth > 0 {
-				ship.cur.v = baseLine + ruleDepth
+				// the rule spans from its top, above the baseline,
+				// down to its depth below the baseline.
+				ship.cur.v = baseLine - ruleHeight
 				type renderXYWH interface {
 					render(x, y, w, h float64)
 				}
 				node.(renderXYWH).render(
 					ship.cur.h+ship.off.h,
 					ship.cur.v+ship.off.v,
-					ruleWidth, ruleHeight,
+					ruleWidth, ruleHeight+ruleDepth,
 				)
 				ship.cur.v = baseLine
 			}
@@ -1194,6 +1458,7 @@ var (
 	_ Node = (*HList)(nil)
 	_ Node = (*VList)(nil)
 	_ Node = (*Rule)(nil)
+	_ Node = (*Stroke)(nil)
 	_ Node = (*Glue)(nil)
 	_ Node = (*Kern)(nil)
 	_ Node = (*SubSuperCluster)(nil)
@@ -1205,6 +1470,7 @@ var (
 	_ hpacker = (*HList)(nil)
 	_ hpacker = (*VList)(nil)
 	_ hpacker = (*Rule)(nil)
+	_ hpacker = (*Stroke)(nil)
 	_ hpacker = (*Glue)(nil)
 	_ hpacker = (*Kern)(nil)
 	_ hpacker = (*SubSuperCluster)(nil)
@@ -1216,6 +1482,7 @@ var (
 	_ vpacker = (*HList)(nil)
 	_ vpacker = (*VList)(nil)
 	_ vpacker = (*Rule)(nil)
+	_ vpacker = (*Stroke)(nil)
 	_ vpacker = (*Glue)(nil)
 	_ vpacker = (*Kern)(nil)
 	_ vpacker = (*SubSuperCluster)(nil)
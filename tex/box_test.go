@@ -241,6 +241,12 @@ func TestBox(t *testing.T) {
 			h:    0,
 			d:    0,
 		},
+		{
+			node: NewSpaceGlue(state),
+			w:    3.814453125,
+			h:    0,
+			d:    0,
+		},
 		{
 			node: NewChar(`\sigma`, state, true),
 			w:    6.578125,
@@ -416,6 +422,41 @@ func TestBox(t *testing.T) {
 	}
 }
 
+func TestAutoWidthChar(t *testing.T) {
+	const dpi = 72
+	be := fakebackend.New()
+	state := NewState(be, font.Font{
+		Name: "default",
+		Size: 12,
+		Type: "rm",
+	}, dpi)
+
+	var (
+		minus = NewChar("-", state, true).Width()
+		arrow = NewChar(`\rightarrow`, state, true).Width()
+	)
+	for _, tc := range []struct {
+		sym   string
+		width float64
+		want  float64
+	}{
+		{sym: `\rightarrow`, width: 0, want: minus/2 + arrow},
+		{sym: `\rightarrow`, width: 14, want: 14},
+		{sym: `\leftarrow`, width: 20, want: 20},
+		{sym: `\leftrightarrow`, width: 20, want: 20},
+		{sym: `\rightarrow`, width: 100.5, want: 100.5},
+		{sym: `\leftrightarrow`, width: 100.5, want: 100.5},
+		{sym: `\uparrow`, width: 100, want: NewChar(`\uparrow`, state, true).Width()},
+	} {
+		t.Run(tc.sym, func(t *testing.T) {
+			box := AutoWidthChar(tc.sym, tc.width, state)
+			if got, want := box.Width(), tc.want; math.Abs(got-want) > 1e-9 {
+				t.Fatalf("invalid width: got=%g, want=%g", got, want)
+			}
+		})
+	}
+}
+
 func TestShip(t *testing.T) {
 	const dpi = 72
 	be := fakebackend.New()
@@ -5,6 +5,8 @@
 package tex
 
 import (
+	"image/color"
+
 	"github.com/go-latex/latex/font"
 )
 
@@ -12,6 +14,10 @@ type State struct {
 	be   font.Backend
 	Font font.Font
 	DPI  float64
+
+	// Color is the color of the glyphs and rules.
+	// A nil color is black.
+	Color color.Color
 }
 
 func NewState(be font.Backend, font font.Font, dpi float64) State {
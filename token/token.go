@@ -48,3 +48,30 @@ func (t Token) String() string { return t.Text }
 //
 // Aliased from go/token.Pos
 type Pos = token.Pos
+
+// NoPos is the zero value for Pos.
+// There is no file and line information associated with it.
+//
+// Aliased from go/token.NoPos
+const NoPos = token.NoPos
+
+// Position describes an arbitrary source position including the file,
+// line, and column location.
+//
+// Aliased from go/token.Position
+type Position = token.Position
+
+// File is a handle for a file belonging to a FileSet.
+//
+// Aliased from go/token.File
+type File = token.File
+
+// FileSet represents a set of source files.
+//
+// Aliased from go/token.FileSet
+type FileSet = token.FileSet
+
+// NewFileSet creates a new file set.
+func NewFileSet() *FileSet {
+	return token.NewFileSet()
+}
@@ -0,0 +1,298 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xref indexes the labels, references and citations of LaTeX
+// documents.
+package xref // import "github.com/go-latex/latex/xref"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-latex/latex/ast"
+	"github.com/go-latex/latex/token"
+)
+
+// Label is a label defined with \label.
+type Label struct {
+	Name    string
+	Pos     token.Pos    // position of the name of the label
+	Macro   *ast.Macro   // \label command
+	Section *ast.Section // innermost section holding the label, if any
+}
+
+// Ref is a reference to a label (\ref, \eqref, \pageref, \autoref, ...)
+// or a citation of a bibliography entry (\cite, \citep, \textcite, ...).
+type Ref struct {
+	Name    string       // label or citation key
+	Pos     token.Pos    // position of the name of the label or citation key
+	Macro   *ast.Macro   // referencing command
+	Section *ast.Section // innermost section holding the reference, if any
+}
+
+// Cmd returns the name of the referencing command, without its star.
+func (ref *Ref) Cmd() string {
+	return strings.TrimSuffix(ref.Macro.Name.Name, "*")
+}
+
+// Index holds the labels, references and citations of a document.
+type Index struct {
+	Labels []*Label // labels, in document order
+	Refs   []*Ref   // references to labels, in document order
+	Cites  []*Ref   // citations, in document order
+
+	labels map[string][]*Label
+	refs   map[string][]*Ref
+	cites  map[string][]*Ref
+}
+
+var (
+	refCmds = map[string]bool{
+		`\ref`:     true,
+		`\eqref`:   true,
+		`\pageref`: true,
+		`\autoref`: true,
+		`\nameref`: true,
+		`\vref`:    true,
+		`\cref`:    true,
+		`\Cref`:    true,
+	}
+
+	citeCmds = map[string]bool{
+		`\cite`:       true,
+		`\citep`:      true,
+		`\citet`:      true,
+		`\citealp`:    true,
+		`\citealt`:    true,
+		`\citeauthor`: true,
+		`\citeyear`:   true,
+		`\parencite`:  true,
+		`\textcite`:   true,
+		`\autocite`:   true,
+		`\footcite`:   true,
+		`\nocite`:     true,
+	}
+)
+
+// New returns the index of the labels, references and citations found
+// in the provided node.
+func New(node ast.Node) *Index {
+	idx := &Index{
+		labels: make(map[string][]*Label),
+		refs:   make(map[string][]*Ref),
+		cites:  make(map[string][]*Ref),
+	}
+
+	var stack []ast.Node
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		stack = append(stack, n)
+
+		macro, ok := n.(*ast.Macro)
+		if !ok {
+			return true
+		}
+		name := strings.TrimSuffix(macro.Name.Name, "*")
+		switch {
+		case name == `\label`:
+			for _, key := range keys(macro) {
+				lbl := &Label{
+					Name:    key.name,
+					Pos:     key.pos,
+					Macro:   macro,
+					Section: section(stack),
+				}
+				idx.Labels = append(idx.Labels, lbl)
+				idx.labels[lbl.Name] = append(idx.labels[lbl.Name], lbl)
+			}
+		case refCmds[name]:
+			for _, key := range keys(macro) {
+				ref := &Ref{
+					Name:    key.name,
+					Pos:     key.pos,
+					Macro:   macro,
+					Section: section(stack),
+				}
+				idx.Refs = append(idx.Refs, ref)
+				idx.refs[ref.Name] = append(idx.refs[ref.Name], ref)
+			}
+		case citeCmds[name]:
+			for _, key := range keys(macro) {
+				ref := &Ref{
+					Name:    key.name,
+					Pos:     key.pos,
+					Macro:   macro,
+					Section: section(stack),
+				}
+				idx.Cites = append(idx.Cites, ref)
+				idx.cites[ref.Name] = append(idx.cites[ref.Name], ref)
+			}
+		}
+		return true
+	})
+
+	return idx
+}
+
+// Label returns the first definition of the named label, or nil.
+func (idx *Index) Label(name string) *Label {
+	lbls := idx.labels[name]
+	if len(lbls) == 0 {
+		return nil
+	}
+	return lbls[0]
+}
+
+// Backlinks returns the references to the named label, in document order.
+func (idx *Index) Backlinks(name string) []*Ref {
+	return idx.refs[name]
+}
+
+// Citations returns the citations of the named bibliography entry,
+// in document order.
+func (idx *Index) Citations(key string) []*Ref {
+	return idx.cites[key]
+}
+
+// Keys returns the sorted list of cited bibliography keys.
+func (idx *Index) Keys() []string {
+	keys := make([]string, 0, len(idx.cites))
+	for k := range idx.cites {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Graph returns the references between labels.
+//
+// Each label is associated with the labels it references: a reference
+// originates from the label of its innermost enclosing section.
+// References outside of any section, or inside an unlabeled section,
+// originate from the empty label.
+func (idx *Index) Graph() map[string][]string {
+	var (
+		graph = make(map[string][]string)
+		seen  = make(map[[2]string]bool)
+	)
+	for _, ref := range idx.Refs {
+		from := ""
+		if ref.Section != nil {
+			from = ref.Section.Label
+		}
+		edge := [2]string{from, ref.Name}
+		if seen[edge] {
+			continue
+		}
+		seen[edge] = true
+		graph[from] = append(graph[from], ref.Name)
+	}
+	return graph
+}
+
+// ProblemKind describes the kind of a cross-reference problem.
+type ProblemKind int
+
+const (
+	Undefined ProblemKind = iota // reference to an undefined label
+	Duplicate                    // label defined multiple times
+	Unused                       // label never referenced
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case Undefined:
+		return "undefined reference"
+	case Duplicate:
+		return "duplicate label"
+	case Unused:
+		return "unused label"
+	default:
+		return fmt.Sprintf("ProblemKind(%d)", int(k))
+	}
+}
+
+// Problem is an issue found in the cross-references of a document.
+type Problem struct {
+	Pos  token.Pos
+	Kind ProblemKind
+	Name string // name of the label
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%v %q", p.Kind, p.Name)
+}
+
+// Check reports the undefined references, duplicate labels and unused
+// labels of the index, sorted by position.
+func (idx *Index) Check() []Problem {
+	var ps []Problem
+	for _, ref := range idx.Refs {
+		if len(idx.labels[ref.Name]) == 0 {
+			ps = append(ps, Problem{Pos: ref.Pos, Kind: Undefined, Name: ref.Name})
+		}
+	}
+	for _, lbl := range idx.Labels {
+		defs := idx.labels[lbl.Name]
+		if defs[0] != lbl {
+			ps = append(ps, Problem{Pos: lbl.Pos, Kind: Duplicate, Name: lbl.Name})
+			continue
+		}
+		if len(idx.refs[lbl.Name]) == 0 {
+			ps = append(ps, Problem{Pos: lbl.Pos, Kind: Unused, Name: lbl.Name})
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].Pos < ps[j].Pos
+	})
+	return ps
+}
+
+type key struct {
+	name string
+	pos  token.Pos
+}
+
+// keys returns the comma-separated keys of the last argument of a macro.
+func keys(macro *ast.Macro) []key {
+	if len(macro.Args) == 0 {
+		return nil
+	}
+	arg, ok := macro.Args[len(macro.Args)-1].(*ast.Arg)
+	if !ok || len(arg.List) != 1 {
+		return nil
+	}
+	w, ok := arg.List[0].(*ast.Word)
+	if !ok {
+		return nil
+	}
+
+	var (
+		ks  []key
+		off = 0
+	)
+	for _, v := range strings.Split(w.Text, ",") {
+		name := strings.TrimSpace(v)
+		if name != "" {
+			pos := w.WordPos + token.Pos(off+strings.Index(v, name))
+			ks = append(ks, key{name: name, pos: pos})
+		}
+		off += len(v) + 1
+	}
+	return ks
+}
+
+// section returns the innermost section of the stack of nodes.
+func section(stack []ast.Node) *ast.Section {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if sec, ok := stack[i].(*ast.Section); ok {
+			return sec
+		}
+	}
+	return nil
+}
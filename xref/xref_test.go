@@ -0,0 +1,112 @@
+// Copyright ©2020 The go-latex Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xref
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-latex/latex"
+)
+
+const doc = `\documentclass{article}
+\begin{document}
+\section{Intro}\label{sec:intro}
+See \autoref{sec:eqs} and \cref{eq:a,eq:missing}, as in~\cite[p.~2]{knuth84, lamport94}.
+\section{Equations}\label{sec:eqs}
+\begin{equation}x=1\label{eq:a}\end{equation}
+\begin{equation}y=2\label{eq:b}\end{equation}
+Back to \ref*{sec:intro} (p.~\pageref{sec:intro}) and \eqref{eq:a}.
+\label{eq:b}
+\nocite{knuth84}
+\end{document}
+`
+
+func TestIndex(t *testing.T) {
+	d, err := latex.ParseDocument(doc)
+	if err != nil {
+		t.Fatalf("could not parse document: %+v", err)
+	}
+	idx := New(d)
+
+	var labels []string
+	for _, lbl := range idx.Labels {
+		labels = append(labels, fmt.Sprintf("%s@%d", lbl.Name, lbl.Pos))
+	}
+	want := []string{"sec:intro@63", "sec:eqs@189", "eq:a@224", "eq:b@270", "eq:b@365"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Fatalf("invalid labels:\ngot= %q\nwant=%q", labels, want)
+	}
+
+	var refs []string
+	for _, ref := range idx.Refs {
+		refs = append(refs, ref.Cmd()+"{"+ref.Name+"}")
+	}
+	want = []string{
+		`\autoref{sec:eqs}`, `\cref{eq:a}`, `\cref{eq:missing}`,
+		`\ref{sec:intro}`, `\pageref{sec:intro}`, `\eqref{eq:a}`,
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Fatalf("invalid references:\ngot= %q\nwant=%q", refs, want)
+	}
+
+	if got, want := idx.Keys(), []string{"knuth84", "lamport94"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid citation keys: got=%q, want=%q", got, want)
+	}
+	if got, want := len(idx.Citations("knuth84")), 2; got != want {
+		t.Fatalf("invalid number of citations: got=%d, want=%d", got, want)
+	}
+	if got, want := doc[idx.Cites[1].Pos:][:len("lamport94")], "lamport94"; got != want {
+		t.Fatalf("invalid citation position: got=%q, want=%q", got, want)
+	}
+
+	if got, want := idx.Label("eq:b").Pos, idx.Labels[3].Pos; got != want {
+		t.Fatalf("invalid label lookup: got=%d, want=%d", got, want)
+	}
+	if got, want := idx.Label("eq:a").Section.Label, "sec:eqs"; got != want {
+		t.Fatalf("invalid label section: got=%q, want=%q", got, want)
+	}
+	if idx.Label("nope") != nil {
+		t.Fatalf("unexpected label")
+	}
+
+	var backlinks []string
+	for _, ref := range idx.Backlinks("sec:intro") {
+		backlinks = append(backlinks, ref.Cmd()+" in "+ref.Section.Label)
+	}
+	if got, want := backlinks, []string{`\ref in sec:eqs`, `\pageref in sec:eqs`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid backlinks: got=%q, want=%q", got, want)
+	}
+
+	graph := idx.Graph()
+	wantGraph := map[string][]string{
+		"sec:intro": {"sec:eqs", "eq:a", "eq:missing"},
+		"sec:eqs":   {"sec:intro", "eq:a"},
+	}
+	if !reflect.DeepEqual(graph, wantGraph) {
+		t.Fatalf("invalid graph:\ngot= %q\nwant=%q", graph, wantGraph)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	d, err := latex.ParseDocument(doc)
+	if err != nil {
+		t.Fatalf("could not parse document: %+v", err)
+	}
+
+	var got []string
+	for _, p := range New(d).Check() {
+		got = append(got, fmt.Sprintf("%d: %v", p.Pos, p))
+	}
+	want := []string{
+		`111: undefined reference "eq:missing"`,
+		`270: unused label "eq:b"`,
+		`365: duplicate label "eq:b"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid problems:\ngot= %q\nwant=%q", got, want)
+	}
+}